@@ -0,0 +1,150 @@
+package alert
+
+import (
+	"github.com/harveywangdao/road/protocol"
+	"github.com/harveywangdao/road/telemetry"
+	"sync"
+)
+
+type thingState struct {
+	lastTime uint32          //receive time of the last sample
+	inside   map[string]bool //geofence rule name -> inside
+	speeding map[string]bool
+	absent   map[string]bool
+}
+
+//Engine keeps per thing state, alerts fire on transitions only
+type Engine struct {
+	rules  []*Rule
+	groups map[string]string
+	things map[string]*thingState
+	lock   sync.Mutex
+}
+
+func NewEngine(config *RulesConfig) *Engine {
+	engine := &Engine{
+		rules:  config.Rules,
+		groups: config.Groups,
+		things: make(map[string]*thingState),
+	}
+
+	if engine.groups == nil {
+		engine.groups = make(map[string]string)
+	}
+
+	return engine
+}
+
+func (engine *Engine) state(thingid string) *thingState {
+	state, ok := engine.things[thingid]
+	if !ok {
+		state = &thingState{
+			inside:   make(map[string]bool),
+			speeding: make(map[string]bool),
+			absent:   make(map[string]bool),
+		}
+		engine.things[thingid] = state
+	}
+
+	return state
+}
+
+func (engine *Engine) Evaluate(record *telemetry.Record) []Alert {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+
+	state := engine.state(record.ThingId)
+
+	state.lastTime = record.ReceiveTime
+	if state.lastTime == 0 {
+		state.lastTime = record.Sample.Time
+	}
+
+	group := engine.groups[record.ThingId]
+	position, hasPosition := telemetry.Position(&record.Sample)
+	speed := record.Sample.SpeedKmh()
+
+	var alerts []Alert
+	for _, rule := range engine.rules {
+		if !rule.match(record.ThingId, group) {
+			continue
+		}
+
+		alert := Alert{
+			Rule:    rule.Name,
+			Type:    rule.Type,
+			ThingId: record.ThingId,
+			Time:    record.Sample.Time,
+			Speed:   speed,
+		}
+		if hasPosition {
+			alert.Latitude = position.Latitude
+			alert.Longitude = position.Longitude
+		}
+
+		switch rule.Type {
+		case RuleGeofenceExit, RuleGeofenceEnter:
+			if !hasPosition {
+				continue
+			}
+
+			inside := rule.Geofence.Contains(position)
+			wasInside, known := state.inside[rule.Name]
+			state.inside[rule.Name] = inside
+
+			//The first position only sets the state
+			if !known || inside == wasInside {
+				continue
+			}
+
+			if (rule.Type == RuleGeofenceExit && !inside) || (rule.Type == RuleGeofenceEnter && inside) {
+				alerts = append(alerts, alert)
+			}
+
+		case RuleSpeed:
+			if record.Sample.TelemetryVersion == protocol.TelemetryVersionLegacy {
+				continue
+			}
+
+			speeding := speed > rule.SpeedLimit
+			if speeding && !state.speeding[rule.Name] {
+				alerts = append(alerts, alert)
+			}
+			state.speeding[rule.Name] = speeding
+
+		case RuleAbsence:
+			state.absent[rule.Name] = false
+		}
+	}
+
+	return alerts
+}
+
+//CheckAbsence only knows things that reported at least once since the engine started
+func (engine *Engine) CheckAbsence(now uint32) []Alert {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+
+	var alerts []Alert
+	for thingid, state := range engine.things {
+		group := engine.groups[thingid]
+
+		for _, rule := range engine.rules {
+			if rule.Type != RuleAbsence || !rule.match(thingid, group) {
+				continue
+			}
+
+			if now > state.lastTime && now-state.lastTime > rule.Absence && !state.absent[rule.Name] {
+				state.absent[rule.Name] = true
+				alerts = append(alerts, Alert{
+					Rule:    rule.Name,
+					Type:    rule.Type,
+					ThingId: thingid,
+					Time:    now,
+				})
+			}
+		}
+	}
+
+	return alerts
+}
@@ -0,0 +1,35 @@
+package alert
+
+import (
+	"github.com/harveywangdao/road/telemetry"
+)
+
+type Circle struct {
+	Center telemetry.Point
+	Radius float64 //meter
+}
+
+//Geofence is a circle or a polygon, a polygon is closed from its last point back to the first
+type Geofence struct {
+	Circle  *Circle           `json:",omitempty"`
+	Polygon []telemetry.Point `json:",omitempty"`
+}
+
+func (fence *Geofence) Contains(p telemetry.Point) bool {
+	if fence.Circle != nil {
+		return telemetry.Distance(fence.Circle.Center, p) <= fence.Circle.Radius
+	}
+
+	//Ray casting, good enough for fences much smaller than a hemisphere
+	inside := false
+	n := len(fence.Polygon)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		a, b := fence.Polygon[i], fence.Polygon[j]
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) &&
+			p.Longitude < (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
@@ -0,0 +1,111 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"io/ioutil"
+)
+
+const (
+	RuleGeofenceExit  = "geofence_exit"
+	RuleGeofenceEnter = "geofence_enter"
+	RuleSpeed         = "speed"
+	RuleAbsence       = "absence"
+)
+
+var (
+	ErrBadRule = errors.New("Bad alert rule!")
+)
+
+//Rule applies to the things in ThingIds and to the things of Group, with both empty it applies to every thing
+type Rule struct {
+	Name     string
+	Type     string
+	ThingIds []string `json:",omitempty"`
+	Group    string   `json:",omitempty"`
+
+	Geofence   *Geofence `json:",omitempty"` //geofence_exit, geofence_enter
+	SpeedLimit float64   `json:",omitempty"` //speed, km/h
+	Absence    uint32    `json:",omitempty"` //absence, seconds without telemetry
+}
+
+type Alert struct {
+	Rule      string
+	Type      string
+	ThingId   string
+	Time      uint32
+	Latitude  float64 `json:",omitempty"`
+	Longitude float64 `json:",omitempty"`
+	Speed     float64 `json:",omitempty"`
+}
+
+func (rule *Rule) check() error {
+	switch rule.Type {
+	case RuleGeofenceExit, RuleGeofenceEnter:
+		if rule.Geofence == nil || (rule.Geofence.Circle == nil && len(rule.Geofence.Polygon) < 3) {
+			return ErrBadRule
+		}
+	case RuleSpeed:
+		if rule.SpeedLimit <= 0 {
+			return ErrBadRule
+		}
+	case RuleAbsence:
+		if rule.Absence == 0 {
+			return ErrBadRule
+		}
+	default:
+		return ErrBadRule
+	}
+
+	return nil
+}
+
+func (rule *Rule) match(thingid, group string) bool {
+	if len(rule.ThingIds) == 0 && rule.Group == "" {
+		return true
+	}
+
+	if rule.Group != "" && rule.Group == group {
+		return true
+	}
+
+	for _, id := range rule.ThingIds {
+		if id == thingid {
+			return true
+		}
+	}
+
+	return false
+}
+
+//RulesConfig is the rules file, Groups maps a thingid to its group
+type RulesConfig struct {
+	Rules  []*Rule
+	Groups map[string]string
+}
+
+func LoadRules(path string) (*RulesConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	config := &RulesConfig{}
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	for _, rule := range config.Rules {
+		err = rule.check()
+		if err != nil {
+			logger.Error(rule.Name, err)
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/telemetry"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTopic     = "ThingAlert"
+	AbsenceCheckTime = 30 * time.Second
+)
+
+//Service evaluates the telemetry topic and publishes alerts keyed by thingid
+type Service struct {
+	MQAddr             string
+	TelemetryTopic     string
+	AlertTopic         string
+	ConsumerRoutineNum int
+	Engine             *Engine
+
+	publisher *msgqueue.MqPublisher
+	pending   []pendingAlerts //raised but not published yet, the engine does not raise them again. Lost on restart
+	lock      sync.Mutex
+}
+
+type pendingAlerts struct {
+	correlationId string
+	alerts        []Alert
+}
+
+//publish keeps the correlation id of the record that raised the alerts, absence alerts get new ones.
+//Alerts that fail stay pending and go out first on the next call
+func (s *Service) publish(correlationId string, alerts []Alert) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(alerts) > 0 {
+		s.pending = append(s.pending, pendingAlerts{correlationId: correlationId, alerts: alerts})
+	}
+
+	for len(s.pending) > 0 {
+		p := &s.pending[0]
+		for len(p.alerts) > 0 {
+			err := s.publishAlert(p.correlationId, &p.alerts[0])
+			if err != nil {
+				return err
+			}
+			p.alerts = p.alerts[1:]
+		}
+		s.pending = s.pending[1:]
+	}
+
+	return nil
+}
+
+func (s *Service) publishAlert(correlationId string, alert *Alert) error {
+	env, err := msgqueue.NewEnvelope(msgqueue.MessageTypeAlert, alert.ThingId, correlationId, alert)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	data, err := env.Marshal()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	logger.Info("Alert =", string(data))
+
+	err = s.publisher.Publish(alert.ThingId, data)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+//handle acks the record once its alerts are pending, they go out with the next record or absence check
+//when the alert topic is down. Only bad records go to the dead letter topic
+func (s *Service) handle(msg *msgqueue.Message) error {
+	env, err := msgqueue.UnmarshalEnvelope(msg.Value)
+	if err != nil {
+		return msgqueue.Permanent(err)
+	}
+
+	record, err := telemetry.EnvelopeRecord(env)
+	if err != nil {
+		return msgqueue.Permanent(err)
+	}
+
+	err = s.publish(env.CorrelationId, s.Engine.Evaluate(record))
+	if err != nil {
+		logger.Error(err)
+	}
+
+	return nil
+}
+
+func (s *Service) Run() {
+	var err error
+	s.publisher, err = msgqueue.NewMqPublisher(s.MQAddr, s.AlertTopic)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	defer s.publisher.Close()
+
+	mqs, err := msgqueue.NewMqService(s.MQAddr, s.TelemetryTopic, "", s.ConsumerRoutineNum, 0, nil, nil)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	mqs.ConsumerName = "Alert"
+	mqs.Handler = s.handle
+
+	go mqs.Start()
+
+	ticker := time.NewTicker(AbsenceCheckTime)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err = s.publish("", s.Engine.CheckAbsence(uint32(time.Now().Unix())))
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+}
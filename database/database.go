@@ -1,11 +1,18 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/mattn/go-sqlite3"
+	"io/ioutil"
+	"net"
 	"sync"
+	"time"
 )
 
 const (
@@ -13,85 +20,369 @@ const (
 	DBUsername = "root"           //用户名
 	DBPassword = "180498"         //密码
 	DriverName = "mysql"
+
+	SQLiteDriverName = "sqlite3"
+)
+
+const (
+	DefaultMaxOpenConns        = 32
+	DefaultMaxIdleConns        = 8
+	DefaultConnMaxLifetime     = 30 * 60 //seconds
+	DefaultHealthCheckInterval = 30      //seconds
+
+	PingTimeout = 3 * time.Second
 )
 
-var dbMap map[string]*sql.DB
-var lock sync.Mutex
+var (
+	ErrNotFound    = errors.New("Record not found!")
+	ErrUnavailable = errors.New("Database unavailable!")
+)
+
+//Config describes one named database, zero values take the defaults above
+type Config struct {
+	Name   string
+	Driver string
+	DSN    string
+
+	MaxOpenConns        int   `json:",omitempty"`
+	MaxIdleConns        int   `json:",omitempty"`
+	ConnMaxLifetime     int64 `json:",omitempty"` //seconds
+	HealthCheckInterval int64 `json:",omitempty"` //seconds, negative disables the check
+
+	Schema []string `json:",omitempty"` //statements run after open, e.g. CREATE TABLE IF NOT EXISTS
+}
+
+type entry struct {
+	db     *sql.DB
+	config *Config
+	stop   chan bool
+
+	//guarded by lock
+	healthy bool
+	lastErr error
+}
+
+//Health is the result of the last check
+type Health struct {
+	Healthy   bool
+	LastError string `json:",omitempty"`
+	Stats     sql.DBStats
+}
+
+var (
+	configMap = make(map[string]*Config)
+	dbMap     = make(map[string]*entry)
+	lock      sync.Mutex
+)
 
-func init() {
-	dbMap = make(map[string]*sql.DB)
+//MySQLConfig is used for names that were never registered
+func MySQLConfig(dbName string) *Config {
+	return &Config{
+		Name:   dbName,
+		Driver: DriverName,
+		DSN:    DBUsername + ":" + DBPassword + "@tcp(" + DBHostIP + ")/" + dbName + "?charset=utf8",
+	}
 }
 
-type Database struct {
+//Register must be called before the first GetDB of config.Name
+func Register(config *Config) error {
+	if config.Name == "" || config.Driver == "" || config.DSN == "" {
+		return errors.New("Database config needs name, driver and dsn!")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, ok := dbMap[config.Name]; ok {
+		return errors.New(config.Name + " already opened!")
+	}
+
+	configMap[config.Name] = config
+
+	return nil
 }
 
-func openDB(dbName string) (*sql.DB, error) {
-	dataSourceName := DBUsername + ":" + DBPassword + "@tcp(" + DBHostIP + ")/" + dbName + "?charset=utf8"
-	logger.Debug("dataSourceName =", dataSourceName)
+//LoadConfig reads a JSON array of Config and registers every entry
+func LoadConfig(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
 
-	db, err := sql.Open(DriverName, dataSourceName)
+	var configs []*Config
+	err = json.Unmarshal(data, &configs)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	for _, config := range configs {
+		err = Register(config)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+func openDB(config *Config) (*sql.DB, error) {
+	logger.Debug("Open database", config.Name, "driver =", config.Driver)
+
+	db, err := sql.Open(config.Driver, config.DSN)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
-	//defer db.Close()
 
-	/*	err = db.Ping()
+	maxOpenConns := config.MaxOpenConns
+	if maxOpenConns == 0 {
+		maxOpenConns = DefaultMaxOpenConns
+	}
+
+	maxIdleConns := config.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = DefaultMaxIdleConns
+	}
+
+	connMaxLifetime := config.ConnMaxLifetime
+	if connMaxLifetime == 0 {
+		connMaxLifetime = DefaultConnMaxLifetime
+	}
+
+	db.SetMaxOpenConns(maxOpenConns)
+	db.SetMaxIdleConns(maxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(connMaxLifetime) * time.Second)
+
+	err = ping(db)
+	if err != nil {
+		db.Close()
+		logger.Error(err)
+		return nil, err
+	}
+
+	for _, stmt := range config.Schema {
+		_, err = db.Exec(stmt)
 		if err != nil {
 			db.Close()
 			logger.Error(err)
 			return nil, err
-		}*/
+		}
+	}
 
 	return db, nil
 }
 
-func Close(dbname string) error {
-	db, ok := dbMap[dbname]
-	if !ok {
-		return errors.New(dbname + " not exist!")
+func ping(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), PingTimeout)
+	defer cancel()
+
+	return db.PingContext(ctx)
+}
+
+func (e *entry) setHealth(err error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	e.healthy = err == nil
+	e.lastErr = err
+}
+
+func healthCheck(name string, e *entry, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := ping(e.db)
+			if err != nil {
+				logger.Error(name, "health check failed:", err)
+			}
+			e.setHealth(err)
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+//Classify maps driver errors to ErrNotFound or ErrUnavailable, other errors are returned as they are
+func Classify(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case sql.ErrNoRows, ErrNotFound:
+		return ErrNotFound
+	case ErrUnavailable, sql.ErrConnDone, driver.ErrBadConn, mysql.ErrInvalidConn, context.DeadlineExceeded:
+		return ErrUnavailable
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrUnavailable
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1040, 1053, 1205, 1213: //too many connections, shutdown, lock wait timeout, deadlock
+			return ErrUnavailable
+		}
 	}
 
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		if sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked {
+			return ErrUnavailable
+		}
+	}
+
+	return err
+}
+
+func Close(dbname string) error {
 	lock.Lock()
 	defer lock.Unlock()
 
-	db, ok = dbMap[dbname]
+	e, ok := dbMap[dbname]
 	if !ok {
 		return errors.New(dbname + " not exist!")
 	}
 
-	err := db.Close()
+	delete(dbMap, dbname)
+
+	if e.stop != nil {
+		close(e.stop)
+	}
+
+	err := e.db.Close()
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
-	delete(dbMap, dbname)
-
 	return nil
 }
 
+//GetDB opens the database on first use, registered names use their config and others the MySQL defaults.
+//ErrUnavailable is returned while the database does not answer, so callers can tell it from a missing record
 func GetDB(dbname string) (*sql.DB, error) {
-	db, ok := dbMap[dbname]
-	if ok {
-		return db, nil
+	e, err := getEntry(dbname)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
 	}
 
 	lock.Lock()
-	defer lock.Unlock()
+	healthy := e.healthy
+	lock.Unlock()
+
+	if healthy {
+		return e.db, nil
+	}
+
+	err = ping(e.db)
+	e.setHealth(err)
+	if err != nil {
+		logger.Error(dbname, err)
+		return nil, ErrUnavailable
+	}
+
+	return e.db, nil
+}
+
+//getEntry opens the database outside the lock, a slow ping or schema does not hold up the other databases.
+//When two callers open the same name at once the first one wins and the other closes its pool
+func getEntry(dbname string) (*entry, error) {
+	lock.Lock()
+	e, ok := dbMap[dbname]
+	config, registered := configMap[dbname]
+	lock.Unlock()
 
-	db, ok = dbMap[dbname]
 	if ok {
-		return db, nil
+		return e, nil
 	}
 
-	db, err := openDB(dbname)
+	if !registered {
+		config = MySQLConfig(dbname)
+	}
+
+	db, err := openDB(config)
 	if err != nil {
 		logger.Error(err)
+		if Classify(err) == ErrUnavailable {
+			return nil, ErrUnavailable
+		}
 		return nil, err
 	}
 
-	dbMap[dbname] = db
+	lock.Lock()
+	defer lock.Unlock()
 
-	return db, nil
+	if e, ok := dbMap[dbname]; ok {
+		db.Close()
+		return e, nil
+	}
+
+	e = &entry{
+		db:      db,
+		config:  config,
+		healthy: true,
+	}
+
+	interval := config.HealthCheckInterval
+	if interval == 0 {
+		interval = DefaultHealthCheckInterval
+	}
+
+	if interval > 0 {
+		e.stop = make(chan bool)
+		go healthCheck(dbname, e, time.Duration(interval)*time.Second)
+	}
+
+	dbMap[dbname] = e
+
+	return e, nil
+}
+
+func Healthy(dbname string) bool {
+	lock.Lock()
+	defer lock.Unlock()
+
+	e, ok := dbMap[dbname]
+	return ok && e.healthy
+}
+
+//Stats returns the pool statistics and health of every opened database
+func Stats() map[string]Health {
+	lock.Lock()
+	defer lock.Unlock()
+
+	stats := make(map[string]Health, len(dbMap))
+	for name, e := range dbMap {
+		h := Health{
+			Healthy: e.healthy,
+			Stats:   e.db.Stats(),
+		}
+		if e.lastErr != nil {
+			h.LastError = e.lastErr.Error()
+		}
+		stats[name] = h
+	}
+
+	return stats
+}
+
+//Driver lets callers pick dialect specific SQL, names that were never registered use the MySQL defaults
+func Driver(dbname string) string {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if config, ok := configMap[dbname]; ok {
+		return config.Driver
+	}
+
+	return DriverName
 }
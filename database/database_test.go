@@ -0,0 +1,293 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/mattn/go-sqlite3"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func registerSQLite(t *testing.T) string {
+	name := t.Name()
+	config := SQLiteConfig(name, filepath.Join(t.TempDir(), "road.db"))
+
+	err := Register(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		Close(name)
+
+		lock.Lock()
+		delete(configMap, name)
+		lock.Unlock()
+	})
+
+	return name
+}
+
+func TestGetDB(t *testing.T) {
+	name := registerSQLite(t)
+
+	db, err := GetDB(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//The schema ran on open
+	_, err = db.Exec("INSERT INTO thingbaseinfodata_tbl (thingid, bid) VALUES (?, ?)", "WDDUX52684DFR4582", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := GetDB(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != db {
+		t.Error("GetDB opened the database twice")
+	}
+
+	if !Healthy(name) {
+		t.Error("Healthy = false after open")
+	}
+
+	if Driver(name) != SQLiteDriverName {
+		t.Errorf("Driver = %s, want %s", Driver(name), SQLiteDriverName)
+	}
+
+	if _, ok := Stats()[name]; !ok {
+		t.Error("Stats misses the opened database")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	name := registerSQLite(t)
+
+	err := Register(&Config{Name: name})
+	if err == nil {
+		t.Error("Register accepted a config without driver and dsn")
+	}
+
+	_, err = GetDB(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Register(SQLiteConfig(name, filepath.Join(t.TempDir(), "other.db")))
+	if err == nil {
+		t.Error("Register replaced an opened database")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	name := t.Name()
+	path := filepath.Join(dir, "database.json")
+
+	data := `[{"Name": "` + name + `", "Driver": "sqlite3", "DSN": "file:` + filepath.Join(dir, "road.db") + `", "HealthCheckInterval": -1}]`
+	err := ioutil.WriteFile(path, []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close(name)
+
+	if Driver(name) != SQLiteDriverName {
+		t.Errorf("Driver = %s, want %s", Driver(name), SQLiteDriverName)
+	}
+
+	_, err = GetDB(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetDBOpenError(t *testing.T) {
+	name := t.Name()
+	err := Register(SQLiteConfig(name, filepath.Join(t.TempDir(), "missing", "road.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = GetDB(name)
+	if err == nil {
+		t.Fatal("GetDB opened a database in a missing directory")
+	}
+
+	if _, ok := Stats()[name]; ok {
+		t.Error("A database that failed to open is in Stats")
+	}
+}
+
+func TestCloseUnknown(t *testing.T) {
+	if Close(t.Name()) == nil {
+		t.Error("Close of a database never opened returned nil")
+	}
+}
+
+func TestConcurrentGetDB(t *testing.T) {
+	name := registerSQLite(t)
+
+	const n = 16
+	dbs := make([]*sql.DB, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dbs[i], errs[i] = GetDB(name)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if dbs[i] != dbs[0] {
+			t.Fatal("Concurrent GetDB returned different pools")
+		}
+	}
+
+	err := dbs[0].Ping()
+	if err != nil {
+		t.Error("The pool kept by the registry was closed:", err)
+	}
+}
+
+func TestConcurrentGetDBAndClose(t *testing.T) {
+	name := registerSQLite(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				db, err := GetDB(name)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				//A pool closed under the caller fails with sql.ErrConnDone or "sql: database is closed"
+				db.Exec("SELECT 1")
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				Close(name)
+			}
+		}()
+	}
+	wg.Wait()
+
+	db, err := GetDB(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Ping()
+	if err != nil {
+		t.Error("GetDB after Close returned a closed pool:", err)
+	}
+}
+
+//blockingDriver is sqlite3 whose connections open only once release is closed
+type blockingDriver struct {
+	opening chan bool
+	release chan bool
+}
+
+func (d *blockingDriver) Open(dsn string) (driver.Conn, error) {
+	d.opening <- true
+	<-d.release
+	return (&sqlite3.SQLiteDriver{}).Open(dsn)
+}
+
+var (
+	blocking         = &blockingDriver{opening: make(chan bool, 1)}
+	registerBlocking sync.Once
+)
+
+//A slow open of one database must not block the others
+func TestOpenOutsideLock(t *testing.T) {
+	registerBlocking.Do(func() { sql.Register("blocking", blocking) })
+	blocking.release = make(chan bool)
+
+	name := registerSQLite(t)
+
+	slow := t.Name() + "Slow"
+	err := Register(&Config{Name: slow, Driver: "blocking", DSN: "file:" + filepath.Join(t.TempDir(), "slow.db"), HealthCheckInterval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slowDone := make(chan error)
+	go func() {
+		_, err := GetDB(slow)
+		slowDone <- err
+	}()
+	<-blocking.opening
+
+	done := make(chan error)
+	go func() {
+		_, err := GetDB(name)
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(PingTimeout):
+		t.Error("GetDB waited for the open of another database")
+	}
+
+	close(blocking.release)
+	err = <-slowDone
+	if err != nil {
+		t.Fatal(err)
+	}
+	Close(slow)
+}
+
+func TestClassify(t *testing.T) {
+	other := errors.New("other")
+
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{nil, nil},
+		{sql.ErrNoRows, ErrNotFound},
+		{ErrNotFound, ErrNotFound},
+		{sql.ErrConnDone, ErrUnavailable},
+		{context.DeadlineExceeded, ErrUnavailable},
+		{other, other},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"github.com/harveywangdao/road/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolOpenDesc = prometheus.NewDesc("iot_database_open_connections",
+		"Established connections, in use and idle.", []string{"db"}, nil)
+	poolInUseDesc = prometheus.NewDesc("iot_database_in_use_connections",
+		"Connections currently in use.", []string{"db"}, nil)
+	poolIdleDesc = prometheus.NewDesc("iot_database_idle_connections",
+		"Idle connections.", []string{"db"}, nil)
+	poolWaitCountDesc = prometheus.NewDesc("iot_database_wait_count_total",
+		"Connections waited for.", []string{"db"}, nil)
+	poolWaitDurationDesc = prometheus.NewDesc("iot_database_wait_duration_seconds_total",
+		"Time blocked waiting for a connection.", []string{"db"}, nil)
+	healthyDesc = prometheus.NewDesc("iot_database_healthy",
+		"1 when the last health check passed.", []string{"db"}, nil)
+)
+
+//statsCollector exports Stats on every scrape
+type statsCollector struct{}
+
+func (statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolOpenDesc
+	ch <- poolInUseDesc
+	ch <- poolIdleDesc
+	ch <- poolWaitCountDesc
+	ch <- poolWaitDurationDesc
+	ch <- healthyDesc
+}
+
+func (statsCollector) Collect(ch chan<- prometheus.Metric) {
+	for name, h := range Stats() {
+		healthy := 0.0
+		if h.Healthy {
+			healthy = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(poolOpenDesc, prometheus.GaugeValue, float64(h.Stats.OpenConnections), name)
+		ch <- prometheus.MustNewConstMetric(poolInUseDesc, prometheus.GaugeValue, float64(h.Stats.InUse), name)
+		ch <- prometheus.MustNewConstMetric(poolIdleDesc, prometheus.GaugeValue, float64(h.Stats.Idle), name)
+		ch <- prometheus.MustNewConstMetric(poolWaitCountDesc, prometheus.CounterValue, float64(h.Stats.WaitCount), name)
+		ch <- prometheus.MustNewConstMetric(poolWaitDurationDesc, prometheus.CounterValue, h.Stats.WaitDuration.Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(healthyDesc, prometheus.GaugeValue, healthy, name)
+	}
+}
+
+func init() {
+	metrics.Registry.MustRegister(statsCollector{})
+}
@@ -1,83 +1,286 @@
 package mongo
 
 import (
-	"errors"
-	"gopkg.in/mgo.v2"
-	/*	"gopkg.in/mgo.v2/bson"*/
+	"context"
+	"encoding/json"
 	"github.com/harveywangdao/road/log/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"io/ioutil"
+	"strconv"
 	"sync"
+	"time"
 )
 
 const (
-	MgoAddr = "localhost:27017" //IP地址
+	DefaultURI      = "mongodb://localhost:27017"
+	DefaultDatabase = "iotmgodb"
+	DefaultTimeout  = 10 * time.Second
+	MaxPoolSize     = 64
 )
 
-var mgos map[string]*mgo.Session
-var lock sync.Mutex
+type Config struct {
+	URI          string
+	Username     string `json:",omitempty"`
+	Password     string `json:",omitempty"`
+	AuthSource   string `json:",omitempty"`
+	Database     string
+	WriteConcern string `json:",omitempty"` //"majority" or the number of nodes, empty is the server default
+	Journal      bool   `json:",omitempty"`
+	Timeout      int64  `json:",omitempty"` //seconds, for connect and every operation
 
-func init() {
-	mgos = make(map[string]*mgo.Session)
+	Retention map[string]int64 `json:",omitempty"` //collection -> seconds, documents older are removed by a TTL index
 }
 
-type Database struct {
+func DefaultConfig() *Config {
+	return &Config{
+		URI:      DefaultURI,
+		Database: DefaultDatabase,
+	}
 }
 
-func openMongo(ipport string) (*mgo.Session, error) {
-	session, err := mgo.Dial(ipport)
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
-	//defer session.Close()
 
-	session.SetMode(mgo.Monotonic, true)
-	session.SetPoolLimit(64)
+	config := DefaultConfig()
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
 
-	return session, nil
+	return config, nil
 }
 
-func Close(mgoAddr string) error {
-	session, ok := mgos[mgoAddr]
-	if !ok {
-		return errors.New(mgoAddr + " not exist!")
+func (config *Config) timeout() time.Duration {
+	if config.Timeout > 0 {
+		return time.Duration(config.Timeout) * time.Second
 	}
 
-	lock.Lock()
-	defer lock.Unlock()
+	return DefaultTimeout
+}
+
+func (config *Config) writeConcern() (*writeconcern.WriteConcern, error) {
+	wc := &writeconcern.WriteConcern{}
 
-	session, ok = mgos[mgoAddr]
-	if !ok {
-		return errors.New(mgoAddr + " not exist!")
+	switch config.WriteConcern {
+	case "":
+	case "majority":
+		wc.W = "majority"
+	default:
+		w, err := strconv.Atoi(config.WriteConcern)
+		if err != nil {
+			return nil, err
+		}
+		wc.W = w
 	}
 
-	session.Close()
-	delete(mgos, mgoAddr)
+	if config.Journal {
+		journal := true
+		wc.Journal = &journal
+	}
 
-	return nil
+	return wc, nil
+}
+
+type DB struct {
+	Client   *driver.Client
+	Database *driver.Database
+	Config   *Config
+}
+
+func Open(config *Config) (*DB, error) {
+	wc, err := config.writeConcern()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	opts := options.Client().
+		ApplyURI(config.URI).
+		SetConnectTimeout(config.timeout()).
+		SetMaxPoolSize(MaxPoolSize).
+		SetWriteConcern(wc)
+
+	if config.Username != "" {
+		opts.SetAuth(options.Credential{
+			Username:   config.Username,
+			Password:   config.Password,
+			AuthSource: config.AuthSource,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.timeout())
+	defer cancel()
+
+	client, err := driver.Connect(ctx, opts)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		logger.Error(err)
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	db := &DB{
+		Client:   client,
+		Database: client.Database(config.Database),
+		Config:   config,
+	}
+
+	return db, nil
+}
+
+//Context bounds one operation by the configured timeout
+func (db *DB) Context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), db.Config.timeout())
+}
+
+func (db *DB) Collection(name string) *driver.Collection {
+	return db.Database.Collection(name)
+}
+
+//indexSpec is an index as listed by the server, ExpireAfterSeconds is nil for indexes without TTL
+type indexSpec struct {
+	Name               string `bson:"name"`
+	Key                bson.D `bson:"key"`
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+}
+
+//fieldIndex returns the index on field alone, nil when there is none
+func (db *DB) fieldIndex(ctx context.Context, collection, field string) (*indexSpec, error) {
+	cursor, err := db.Collection(collection).Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []indexSpec
+	err = cursor.All(ctx, &indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range indexes {
+		if len(indexes[i].Key) == 1 && indexes[i].Key[0].Key == field {
+			return &indexes[i], nil
+		}
+	}
+
+	return nil, nil
 }
 
-func CloneMgoSession() (*mgo.Session, error) {
-	mgoAddr := MgoAddr
-	session, ok := mgos[mgoAddr]
-	if ok {
-		return session.Clone(), nil
+//EnsureRetention creates the TTL index on field, a date field, when the collection has a retention configured.
+//A TTL index of another retention is changed with collMod, creating it again would conflict
+func (db *DB) EnsureRetention(collection, field string) error {
+	seconds, ok := db.Config.Retention[collection]
+	if !ok || seconds <= 0 {
+		return nil
 	}
 
+	ctx, cancel := db.Context()
+	defer cancel()
+
+	index, err := db.fieldIndex(ctx, collection, field)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if index == nil {
+		_, err = db.Collection(collection).Indexes().CreateOne(ctx, driver.IndexModel{
+			Keys:    bson.D{{Key: field, Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(seconds)),
+		})
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		return nil
+	}
+
+	if index.ExpireAfterSeconds != nil && *index.ExpireAfterSeconds == seconds {
+		return nil
+	}
+
+	logger.Info("Retention of", collection, "changes to", seconds, "seconds")
+
+	err = db.Database.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: collection},
+		{Key: "index", Value: bson.D{
+			{Key: "name", Value: index.Name},
+			{Key: "expireAfterSeconds", Value: seconds},
+		}},
+	}).Err()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (db *DB) Close() error {
+	ctx, cancel := db.Context()
+	defer cancel()
+
+	return db.Client.Disconnect(ctx)
+}
+
+var (
+	defaultConfig = DefaultConfig()
+	defaultDB     *DB
+	lock          sync.Mutex
+)
+
+//SetConfig must be called before the first GetDB
+func SetConfig(config *Config) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	defaultConfig = config
+}
+
+//GetDB opens the configured database on first use and shares it afterwards
+func GetDB() (*DB, error) {
 	lock.Lock()
 	defer lock.Unlock()
 
-	session, ok = mgos[mgoAddr]
-	if ok {
-		return session.Clone(), nil
+	if defaultDB != nil {
+		return defaultDB, nil
 	}
 
-	session, err := openMongo(mgoAddr)
+	db, err := Open(defaultConfig)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
-	mgos[mgoAddr] = session
+	defaultDB = db
+
+	return defaultDB, nil
+}
+
+func Close() error {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if defaultDB == nil {
+		return nil
+	}
+
+	err := defaultDB.Close()
+	defaultDB = nil
 
-	return session.Clone(), nil
+	return err
 }
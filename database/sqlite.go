@@ -0,0 +1,28 @@
+package database
+
+const (
+	ThingBaseInfoTableSQLite = `CREATE TABLE IF NOT EXISTS thingbaseinfodata_tbl (
+	id                INTEGER PRIMARY KEY,
+	thingserialno     TEXT    NOT NULL DEFAULT '',
+	prethingaes128key TEXT    NOT NULL DEFAULT '',
+	thingid           TEXT    NOT NULL DEFAULT '',
+	iccid             TEXT    NOT NULL DEFAULT '',
+	imsi              TEXT    NOT NULL DEFAULT '',
+	status            INTEGER NOT NULL DEFAULT 0,
+	bid               INTEGER NOT NULL DEFAULT 0,
+	thingaes128key    TEXT    NOT NULL DEFAULT '',
+	eventcreationtime INTEGER NOT NULL DEFAULT 0
+)`
+)
+
+//SQLiteConfig runs the gateway or the simulator on a local file, ":memory:" keeps everything in process
+func SQLiteConfig(dbName, path string) *Config {
+	return &Config{
+		Name:         dbName,
+		Driver:       SQLiteDriverName,
+		DSN:          "file:" + path + "?cache=shared&_busy_timeout=5000",
+		MaxOpenConns: 1,
+		MaxIdleConns: 1,
+		Schema:       []string{ThingBaseInfoTableSQLite},
+	}
+}
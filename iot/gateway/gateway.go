@@ -1,16 +1,33 @@
 package gateway
 
 import (
-	"encoding/json"
+	"errors"
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/metrics"
+	"github.com/harveywangdao/road/msgqueue"
 	"net"
+	"os"
 	"sync"
+	"sync/atomic"
 )
 
 const (
 	Port = ":6024"
+
+	WebToGatewayTopic = "WebToGateway"
+	GatewayToWebTopic = "GatewayToWeb"
+
+	webConsumerPrefix = "Gateway-" //kafka group of a gateway on WebToGateway, one per gateway
 )
 
+//TelemetryPublisher takes telemetry out of the thing event loop, Publish returns once the data is durably enqueued
+type TelemetryPublisher interface {
+	Publish(key string, datas ...[]byte) error
+}
+
+//receivedMessages counts every message read from any thing connection
+var receivedMessages uint64
+
 type ThingConn struct {
 	ThingID      string
 	ThingService *Thing
@@ -21,6 +38,13 @@ type Gateway struct {
 	AddThingChan    chan ThingConn
 	DeleteThingChan chan ThingConn
 	lock            sync.Mutex
+
+	ID             string //optional, the host name and Port by default, unique among the gateways on one kafka
+	MQAddr         string
+	TelemetryTopic string
+	Broker         msgqueue.Broker //optional, kafka at MQAddr by default
+	telemetry      TelemetryPublisher
+	lifecycle      *Lifecycle
 }
 
 func (gw *Gateway) ShowAllThings() {
@@ -29,6 +53,18 @@ func (gw *Gateway) ShowAllThings() {
 	}
 }
 
+//ConnectedThings is the number of logged in things
+func (gw *Gateway) ConnectedThings() int {
+	gw.lock.Lock()
+	defer gw.lock.Unlock()
+
+	return len(gw.ThingConns)
+}
+
+func (gw *Gateway) ReceivedMessages() uint64 {
+	return atomic.LoadUint64(&receivedMessages)
+}
+
 func (gw *Gateway) recvThingConnection() {
 	for {
 		select {
@@ -59,64 +95,110 @@ type WebResponse struct {
 	Status  string `json:"status"`
 }
 
+var (
+	ErrThingBusy = errors.New("Thing message queue full!")
+)
+
+//handleWebRequest answers the valid requests for things of this gateway on gatewayToWebChan. Malformed requests
+//are permanent errors, a thing with a full queue is tried again
+func (gw *Gateway) handleWebRequest(msg *msgqueue.Message, gatewayToWebChan chan []byte) error {
+	logger.Info("data from web =", string(msg.Value))
+
+	env, err := msgqueue.UnmarshalEnvelope(msg.Value)
+	if err != nil {
+		logger.Error(err)
+		return msgqueue.Permanent(err)
+	}
+
+	webRequest := &WebRequest{}
+	err = env.Decode(webRequest)
+	if err != nil {
+		logger.Error(err)
+		return msgqueue.Permanent(err)
+	}
+
+	if webRequest.ThingId == "" || webRequest.Command == "" {
+		logger.Error("Web request without thingid or command")
+		return msgqueue.Permanent(errors.New("Web request needs thingid and command!"))
+	}
+
+	//Bare requests have no thingid in the envelope
+	if env.ThingId == "" {
+		env.ThingId = webRequest.ThingId
+	}
+
+	//{"thingid":"fsdvsdvsdvsdv","command":"lock"}
+	//{"thingid":"WDDUX52684DFR4582","command":"lock"}
+	gw.lock.Lock()
+	thing, ok := gw.ThingConns[webRequest.ThingId]
+	if !ok {
+		gw.lock.Unlock()
+
+		//Every gateway gets every request, only the one holding the thing answers
+		logger.Debug("Thing not on this gateway, thingid =", webRequest.ThingId)
+		return nil
+	}
+
+	if !thing.TryPushEventChannel2(EventRemoteOperationRequest, nil, webRequest.Command) {
+		gw.lock.Unlock()
+		return ErrThingBusy
+	}
+	gw.lock.Unlock()
+
+	webResponse := &WebResponse{
+		ThingId: webRequest.ThingId,
+		Status:  "success",
+	}
+
+	respEnv, err := env.Reply(msgqueue.MessageTypeWebResponse, webResponse)
+	if err != nil {
+		logger.Error(err)
+		return msgqueue.Permanent(err)
+	}
+
+	webResponseJson, err := respEnv.Marshal()
+	if err != nil {
+		logger.Error(err)
+		return msgqueue.Permanent(err)
+	}
+
+	gatewayToWebChan <- webResponseJson
+
+	return nil
+}
+
+//webConsumerName is the kafka group of gw on WebToGateway. A thing is connected to one gateway only,
+//so the gateways must not share the requests
+func (gw *Gateway) webConsumerName() string {
+	if gw.ID != "" {
+		return webConsumerPrefix + gw.ID
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		logger.Error(err)
+		hostname = "localhost"
+	}
+
+	return webConsumerPrefix + hostname + Port
+}
+
+//WebTask serves WebToGateway, requests it can not serve go to its dead letter topic
 func (gw *Gateway) WebTask() {
-	WebToGatewayChan := make(chan []byte, 128)
 	gatewayToWebChan := make(chan []byte, 128)
 
-	go func() {
-		for {
-			select {
-			case data := <-WebToGatewayChan:
-				logger.Info("data from web =", string(data))
-
-				webRequest := &WebRequest{}
-				err := json.Unmarshal(data, webRequest)
-				if err != nil {
-					logger.Error(err)
-					return
-				}
-
-				//{"thingid":"fsdvsdvsdvsdv","command":"lock"}
-				//{"thingid":"WDDUX52684DFR4582","command":"lock"}
-				var webResponse *WebResponse
-
-				gw.lock.Lock()
-				thing, ok := gw.ThingConns[webRequest.ThingId]
-				if ok {
-					thing.PushEventChannel2(EventRemoteOperationRequest, nil, webRequest.Command)
-
-					webResponse = &WebResponse{
-						ThingId: webRequest.ThingId,
-						Status:  "success",
-					}
-				} else {
-					logger.Error("Can not find the thing, thingid = ", webRequest.ThingId)
-					webResponse = &WebResponse{
-						ThingId: webRequest.ThingId,
-						Status:  "fail",
-					}
-				}
-				gw.lock.Unlock()
-
-				webResponseJson, err := json.Marshal(webResponse)
-				if err != nil {
-					logger.Error(err)
-					return
-				}
-
-				gatewayToWebChan <- webResponseJson
-			}
-		}
-	}()
-
 	listenMQ := ListenMQ{
+		ConsumerName:       gw.webConsumerName(),
 		ConsumerRoutineNum: 1,
 		ProducerRoutineNum: 10,
-		MQAddr:             "localhost:9092",
-		RecvMessageTopic:   "WebToGateway",
-		SendMessageTopic:   "GatewayToWeb",
-		MqToGatewayChan:    WebToGatewayChan,
+		MQAddr:             gw.MQAddr,
+		RecvMessageTopic:   WebToGatewayTopic,
+		SendMessageTopic:   GatewayToWebTopic,
 		GatewayToMqChan:    gatewayToWebChan,
+		Broker:             gw.Broker,
+		Handler: func(msg *msgqueue.Message) error {
+			return gw.handleWebRequest(msg, gatewayToWebChan)
+		},
 	}
 
 	listenMQ.Run()
@@ -127,9 +209,12 @@ func (gw *Gateway) thingHandler(conn net.Conn) {
 	logger.Debug("Net =", conn.LocalAddr().Network(), ", Addr =", conn.LocalAddr().String())
 	logger.Debug("Remote net =", conn.RemoteAddr().Network(), ", Remote addr =", conn.RemoteAddr().String())
 
+	metrics.ConnectedThings.Inc()
+	defer metrics.ConnectedThings.Dec()
+
 	msgChan := make(chan ThingMessage, 128)
 
-	thing, err := NewThing(msgChan, conn, gw.AddThingChan, gw.DeleteThingChan)
+	thing, err := NewThing(msgChan, conn, gw.AddThingChan, gw.DeleteThingChan, gw.telemetry, gw.lifecycle)
 	if err != nil {
 		logger.Error(err)
 		return
@@ -147,6 +232,24 @@ func (gw *Gateway) GatewayStart() {
 	gw.DeleteThingChan = make(chan ThingConn, 128)
 	gw.ThingConns = make(map[string]*Thing)
 
+	//Things are still served without kafka, telemetry uploads get an error reply
+	var publisher *msgqueue.MqPublisher
+	var err error
+	if gw.Broker != nil {
+		publisher = msgqueue.NewBrokerPublisher(gw.Broker, gw.TelemetryTopic)
+	} else {
+		publisher, err = msgqueue.NewMqPublisher(gw.MQAddr, gw.TelemetryTopic)
+	}
+	if err != nil {
+		logger.Error(err)
+	} else {
+		gw.telemetry = publisher
+		defer publisher.Close()
+	}
+
+	gw.lifecycle = NewLifecycle()
+	go gw.lifecycle.Run(gw.MQAddr, gw.Broker)
+
 	go gw.recvThingConnection()
 	go gw.WebTask()
 
@@ -0,0 +1,121 @@
+package gateway
+
+import (
+	"github.com/harveywangdao/road/msgqueue"
+	"testing"
+	"time"
+)
+
+func publishWebRequest(t *testing.T, broker msgqueue.Broker, correlationId string, req *WebRequest) {
+	env, err := msgqueue.NewEnvelope(msgqueue.MessageTypeWebCommand, req.ThingId, correlationId, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = broker.Publish(WebToGatewayTopic, req.ThingId, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func receive(t *testing.T, sub msgqueue.Subscription) *msgqueue.Envelope {
+	select {
+	case msg := <-sub.Messages():
+		msg.Ack()
+
+		env, err := msgqueue.UnmarshalEnvelope(msg.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return env
+
+	case <-time.After(5 * time.Second):
+		t.Fatal("Nothing received")
+	}
+
+	return nil
+}
+
+//Each gateway gets every request and only the one holding the thing answers, nobody answers for an offline thing
+func TestWebTask(t *testing.T) {
+	broker := msgqueue.NewMemoryBroker()
+	defer broker.Close()
+
+	const otherThingId = "WDDUX52684DFR4583"
+
+	thing := &Thing{ThingMsgChan: make(chan ThingMessage, 1)}
+	other := &Thing{ThingMsgChan: make(chan ThingMessage, 1)}
+	gateways := []*Gateway{
+		{ID: "1", Broker: broker, ThingConns: map[string]*Thing{testThingId: thing}},
+		{ID: "2", Broker: broker, ThingConns: map[string]*Thing{otherThingId: other}},
+	}
+	for _, gw := range gateways {
+		go gw.WebTask()
+	}
+
+	responses, err := broker.Subscribe(GatewayToWebTopic, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer responses.Close()
+
+	deadLetters, err := broker.Subscribe(msgqueue.DeadLetterTopic(WebToGatewayTopic), "operator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer deadLetters.Close()
+
+	publishWebRequest(t, broker, "1", &WebRequest{ThingId: "offline", Command: "lock"})
+
+	tests := []struct {
+		correlationId string
+		req           *WebRequest
+		thing         *Thing
+	}{
+		{"2", &WebRequest{ThingId: testThingId, Command: "lock"}, thing},
+		{"3", &WebRequest{ThingId: otherThingId, Command: "unlock"}, other},
+	}
+
+	for _, tt := range tests {
+		publishWebRequest(t, broker, tt.correlationId, tt.req)
+
+		env := receive(t, responses)
+		if env.Type != msgqueue.MessageTypeWebResponse || env.CorrelationId != tt.correlationId {
+			t.Fatalf("Got %s %s, want %s %s", env.Type, env.CorrelationId, msgqueue.MessageTypeWebResponse, tt.correlationId)
+		}
+
+		resp := &WebResponse{}
+		err = env.Decode(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.ThingId != tt.req.ThingId || resp.Status != "success" {
+			t.Errorf("Response %+v, want %s success", resp, tt.req.ThingId)
+		}
+
+		thingMsg := <-tt.thing.ThingMsgChan
+		if thingMsg.Event != EventRemoteOperationRequest || thingMsg.Param != tt.req.Command {
+			t.Errorf("Thing got %s %v, want EventRemoteOperationRequest %s", GetEventName(thingMsg.Event), thingMsg.Param, tt.req.Command)
+		}
+	}
+
+	//A request without command is never answered, it goes to the dead letter topic at once
+	publishWebRequest(t, broker, "4", &WebRequest{ThingId: testThingId})
+
+	env := receive(t, deadLetters)
+	if env.Type != msgqueue.MessageTypeDeadLetter || env.CorrelationId != "4" {
+		t.Errorf("Got %s %s, want %s 4", env.Type, env.CorrelationId, msgqueue.MessageTypeDeadLetter)
+	}
+
+	select {
+	case msg := <-responses.Messages():
+		t.Errorf("Unexpected response %s", msg.Value)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
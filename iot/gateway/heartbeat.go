@@ -3,22 +3,13 @@ package gateway
 import (
 	/*"encoding/json"*/
 	"errors"
-	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
-const (
-	/*HeartbeatTimeoutTime = 5 * time.Second*/
-	HeartbeatMessageFlag = 0x0
-
-	HeartbeatReqAid = 0xB
-	HeartbeatReqMid = 0x1
-
-	HeartbeatAckAid = 0xB
-	HeartbeatAckMid = 0x2
-)
+/*const HeartbeatTimeoutTime = 5 * time.Second*/
 
 const (
 	HeartbeatStop = iota
@@ -26,6 +17,11 @@ const (
 	/*HeartbeatAckStatus*/
 )
 
+const (
+	EventHeartbeatRequest = protocol.MsgHeartbeatReq
+	EventHeartbeatAck     = protocol.MsgHeartbeatAck
+)
+
 type Heartbeat struct {
 	heartbeatTimer      *time.Timer
 	closeHeartbeatTimer chan bool
@@ -33,23 +29,41 @@ type Heartbeat struct {
 	heartbeatStatus int
 }
 
-type HeartbeatReqServData struct {
-	AppointMF  byte `json:"appointmf"`
-	AppointAid byte `json:"appointaid"`
+func init() {
+	RegisterApp(App{
+		Name:     "heartbeat",
+		NewState: func() interface{} { return &Heartbeat{} },
+		Events: []AppEvent{
+			{
+				Event: EventHeartbeatRequest,
+				Name:  "EventHeartbeatRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Heartbeat).HeartbeatReq(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventHeartbeatAck,
+				Name:  "EventHeartbeatAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Heartbeat).HeartbeatAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (hb *Heartbeat) HeartbeatReq(thing *Thing, reqMsg *message.Message) error {
 	if hb.heartbeatStatus != HeartbeatStop {
-		logger.Error("Heartbeat already start!")
-		return errors.New("Heartbeat already start!")
+		thing.log.Error("Heartbeat already start!")
+		return message.NewResultError(message.ResultBusy, errors.New("Heartbeat already start!"))
 	}
 
-	hb.heartbeatStatus = HeartbeatReqStatus
+	if !thing.IsLogined() {
+		thing.log.Error("Not login or register")
+		return message.NewResultError(message.ResultNotLogin, errors.New("Not login or register"))
+	}
 
-	/*	if thing.ThingStatus != ThingRegisteredLogined {
-		logger.Error("Not login or register")
-		return errors.New("Not login or register")
-	}*/
+	hb.heartbeatStatus = HeartbeatReqStatus
 
 	thing.PushEventChannel(EventHeartbeatAck, reqMsg)
 
@@ -58,7 +72,7 @@ func (hb *Heartbeat) HeartbeatReq(thing *Thing, reqMsg *message.Message) error {
 
 func (hb *Heartbeat) HeartbeatAck(thing *Thing, reqMsg *message.Message) error {
 	if hb.heartbeatStatus != HeartbeatReqStatus {
-		logger.Error("Need HeartbeatReq!")
+		thing.log.Error("Need HeartbeatReq!")
 		return errors.New("Need HeartbeatReq!")
 	}
 
@@ -69,25 +83,25 @@ func (hb *Heartbeat) HeartbeatAck(thing *Thing, reqMsg *message.Message) error {
 	//Service data
 
 	serviceData := make([]byte, 1)
-	logger.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceData =", serviceData)
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
-		Aid:                  HeartbeatAckAid,
-		Mid:                  HeartbeatAckMid,
+		Aid:                  protocol.HeartbeatAckAid,
+		Mid:                  protocol.HeartbeatAckMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -97,7 +111,7 @@ func (hb *Heartbeat) HeartbeatAck(thing *Thing, reqMsg *message.Message) error {
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -105,25 +119,25 @@ func (hb *Heartbeat) HeartbeatAck(thing *Thing, reqMsg *message.Message) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              reqMsg.MesHeader.Bid,
-		MessageFlag:      HeartbeatMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.HeartbeatMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("Send HeartbeatAck Success---")
+	thing.log.Debug("Send HeartbeatAck Success---")
 
 	hb.heartbeatStatus = HeartbeatStop
 
@@ -0,0 +1,99 @@
+package gateway
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+	"net"
+	"time"
+)
+
+const (
+	LifecycleTopic      = "ThingLifecycle"
+	LifecycleBufferSize = 1024
+)
+
+//Lifecycle events
+const (
+	LifecycleOnline        = "online"         //login success
+	LifecycleOffline       = "offline"        //connection of a logged in thing closed
+	LifecycleLoginFailure  = "login_failure"  //Reason is the login result
+	LifecycleRegistered    = "registered"     //register accepted, Bid is the new bid
+	LifecycleConfigChanged = "config_changed" //the thing acked SetConfig, Config holds the values
+)
+
+//LifecycleEvent is the payload of msgqueue.MessageTypeLifecycle envelopes on LifecycleTopic, the key is ThingId
+type LifecycleEvent struct {
+	Event      string            `json:"event"`
+	ThingId    string            `json:"thingid"`
+	Bid        uint32            `json:"bid,omitempty"`
+	Time       int64             `json:"time"` //unix milliseconds at the gateway
+	RemoteAddr string            `json:"remoteaddr,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Config     map[string]string `json:"config,omitempty"`
+}
+
+//Lifecycle queues events for publishing, a full queue drops events instead of stalling the things
+type Lifecycle struct {
+	sendChan chan []byte
+}
+
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{
+		sendChan: make(chan []byte, LifecycleBufferSize),
+	}
+}
+
+//Run publishes the queued events on LifecycleTopic, retrying until the broker takes them
+func (lc *Lifecycle) Run(mqAddr string, broker msgqueue.Broker) {
+	mqs, err := msgqueue.NewMqService(mqAddr, "", LifecycleTopic, 0, 1, nil, lc.sendChan)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	mqs.Broker = broker
+
+	mqs.Start()
+}
+
+func (lc *Lifecycle) Emit(event *LifecycleEvent) {
+	if lc == nil {
+		return
+	}
+
+	event.Time = time.Now().UnixNano() / int64(time.Millisecond)
+
+	env, err := msgqueue.NewEnvelope(msgqueue.MessageTypeLifecycle, event.ThingId, "", event)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	data, err := env.Marshal()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	logger.Info("Lifecycle", event.Event, "thingid =", event.ThingId)
+
+	select {
+	case lc.sendChan <- data:
+	default:
+		logger.Warn("Lifecycle queue full, event dropped:", event.Event, event.ThingId)
+	}
+}
+
+//emitLifecycle fills in the thing, fields already set in event are kept
+func (thing *Thing) emitLifecycle(event *LifecycleEvent) {
+	if event.ThingId == "" {
+		event.ThingId = thing.thingid
+	}
+	if event.Bid == 0 {
+		event.Bid = thing.bid
+	}
+	if conn, ok := thing.Conn.(net.Conn); ok {
+		event.RemoteAddr = conn.RemoteAddr().String()
+	}
+
+	thing.lifecycle.Emit(event)
+}
@@ -1,26 +1,32 @@
-package gateway
-
-import (
-	"github.com/harveywangdao/road/log/logger"
-	"github.com/harveywangdao/road/msgqueue"
-)
-
-type ListenMQ struct {
-	ConsumerRoutineNum int
-	ProducerRoutineNum int
-	MQAddr             string
-	RecvMessageTopic   string
-	SendMessageTopic   string
-	MqToGatewayChan    chan []byte
-	GatewayToMqChan    chan []byte
-}
-
-func (listen *ListenMQ) Run() {
-	mqs, err := msgqueue.NewMqService(listen.MQAddr, listen.RecvMessageTopic, listen.SendMessageTopic, listen.ConsumerRoutineNum, listen.ProducerRoutineNum, listen.MqToGatewayChan, listen.GatewayToMqChan)
-	if err != nil {
-		logger.Error(err)
-		return
-	}
-
-	mqs.Start()
-}
+package gateway
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+)
+
+type ListenMQ struct {
+	ConsumerName       string //optional, the kafka group
+	ConsumerRoutineNum int
+	ProducerRoutineNum int
+	MQAddr             string
+	RecvMessageTopic   string
+	SendMessageTopic   string
+	MqToGatewayChan    chan []byte
+	GatewayToMqChan    chan []byte
+	Broker             msgqueue.Broker               //optional, kafka at MQAddr by default
+	Handler            func(*msgqueue.Message) error //optional, replaces MqToGatewayChan with retries and a dead letter topic
+}
+
+func (listen *ListenMQ) Run() {
+	mqs, err := msgqueue.NewMqService(listen.MQAddr, listen.RecvMessageTopic, listen.SendMessageTopic, listen.ConsumerRoutineNum, listen.ProducerRoutineNum, listen.MqToGatewayChan, listen.GatewayToMqChan)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	mqs.ConsumerName = listen.ConsumerName
+	mqs.Broker = listen.Broker
+	mqs.Handler = listen.Handler
+
+	mqs.Start()
+}
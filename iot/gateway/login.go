@@ -2,32 +2,19 @@ package gateway
 
 import (
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/crypto/md5"
 	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/metrics"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	TimeoutTime = 10 * time.Second
-
-	LoginResultCodeSuccess       = 0x00
-	LoginResultCodeSnVinErr      = 0xA8
-	LoginResultCodeBidErrOrUnreg = 0xA9
-	LoginResultCodeInterrupt     = 0xAA
-	LoginResultCodeAbstractErr   = 0xAB
-	LoginResultCodeAesOutOfDate  = 0xAC
-
-	KeyTypePreAesKey     = 0
-	KeyTypeCurrentAesKey = 1
-
-	FailureCodeKeyErr       = 0
-	FailureCodeKeyOutOfDate = 1
-	FailureCodeSystemErr    = 2
 )
 
 const (
@@ -37,13 +24,21 @@ const (
 	LoginResponseStatus
 )
 
+const (
+	EventLoginRequest   = protocol.MsgLoginRequest
+	EventLoginChallenge = protocol.MsgLoginChallenge
+	EventLoginResponse  = protocol.MsgLoginResponse
+	EventLoginFailure   = protocol.MsgLoginFailure
+	EventLoginSuccess   = protocol.MsgLoginSuccess
+)
+
 type Login struct {
 	timeoutTimer      *time.Timer
 	closeTimeoutTimer chan bool
 
-	loginReqServData   *LoginReqServData
-	loginChallServData *LoginChallengeServData
-	loginRespServData  *LoginResponseServData
+	loginReqServData   *protocol.LoginReqServData
+	loginChallServData *protocol.LoginChallengeServData
+	loginRespServData  *protocol.LoginResponseServData
 
 	loginFailureResult byte
 
@@ -51,65 +46,86 @@ type Login struct {
 	loginEventCreatTime uint32
 }
 
-type LoginReqServData struct {
-	KeyType     byte   `json:"keytype"`
-	ThingSN     string `json:"thingsn"`
-	ThingId     string `json:"thingid"`
-	ThingRandom string `json:"thingrandom"`
-}
-
-type LoginChallengeServData struct {
-	ThingRandomMd5 string `json:"thingrandommd5"`
-	PlatRandom     string `json:"platrandom"`
-}
-
-type LoginResponseServData struct {
-	SerialUP  string `json:"serialup"`
-	AccessKey string `json:"accesskey"`
-}
-
-type LoginFailureServData struct {
-	FailureCode byte `json:"failurecode"`
-}
-
-type LoginSuccessServData struct {
-	AesRandom     string `json:"aesrandom"`
-	InitSerial    byte   `json:"initserial"`
-	TimeStamp     int64  `json:"timestamp"`
-	WorkWindow    int64  `json:"workwindow"`
-	LinkHeartbeat int64  `json:"linkheartbeat"`
+func init() {
+	RegisterApp(App{
+		Name:     "login",
+		NewState: func() interface{} { return &Login{} },
+		Events: []AppEvent{
+			{
+				Event: EventLoginRequest,
+				Name:  "EventLoginRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginRequest(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventLoginChallenge,
+				Name:  "EventLoginChallenge",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginChallenge(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventLoginResponse,
+				Name:  "EventLoginResponse",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginResponse(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventLoginFailure,
+				Name:  "EventLoginFailure",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginFailure(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventLoginSuccess,
+				Name:  "EventLoginSuccess",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginSuccess(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (login *Login) checkLoginReqData(reqMsg *message.Message) (bool, byte) {
 	thingDB, err := database.GetDB(DBName)
 	if err != nil {
 		logger.Error(err)
-		return false, LoginResultCodeBidErrOrUnreg
+		return false, protocol.LoginResultCodeInterrupt
 	}
 
 	var thingserialno string
 	var status uint8
 	var bid uint32
+	start := time.Now()
 	err = thingDB.QueryRow("SELECT thingserialno,status,bid FROM thingbaseinfodata_tbl WHERE thingid = ?", login.loginReqServData.ThingId).Scan(
 		&thingserialno,
 		&status,
 		&bid)
+	metrics.ObserveDBQuery("checkLoginReqData", start, err)
 	if err != nil {
 		logger.Error(err)
-		return false, LoginResultCodeBidErrOrUnreg
+		//Only a missing record sends the thing back to register, otherwise it retries the login
+		if database.Classify(err) == database.ErrNotFound {
+			return false, protocol.LoginResultCodeBidErrOrUnreg
+		}
+		return false, protocol.LoginResultCodeInterrupt
 	}
 
 	//Check status
 	if status == ThingUnRegister {
-		return false, LoginResultCodeBidErrOrUnreg
+		return false, protocol.LoginResultCodeBidErrOrUnreg
 	}
 
 	//SN ThingID
 	if reqMsg.MesHeader.Bid != bid || login.loginReqServData.ThingSN != thingserialno {
-		return false, LoginResultCodeSnVinErr
+		return false, protocol.LoginResultCodeSnVinErr
 	}
 
-	return true, LoginResultCodeSuccess
+	return true, protocol.LoginResultCodeSuccess
 }
 
 func (login *Login) genMd5Abstract16Bytes(s string) string {
@@ -130,16 +146,18 @@ func (login *Login) saveNewAesKeyAndThingStatus(aesRandom string, eventCreationT
 	}
 
 	var prethingaes128key, thingaes128key string
+	start := time.Now()
 	err = thingDB.QueryRow("SELECT prethingaes128key,thingaes128key FROM thingbaseinfodata_tbl WHERE thingid = ?", login.loginReqServData.ThingId).Scan(
 		&prethingaes128key,
 		&thingaes128key)
+	metrics.ObserveDBQuery("saveNewAesKeyAndThingStatus", start, err)
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
 	var key string
-	if login.loginReqServData.KeyType == KeyTypePreAesKey {
+	if login.loginReqServData.KeyType == protocol.KeyTypePreAesKey {
 		key = prethingaes128key
 	} else {
 		key = thingaes128key
@@ -154,7 +172,9 @@ func (login *Login) saveNewAesKeyAndThingStatus(aesRandom string, eventCreationT
 	}
 	defer stmtUpd.Close()
 
+	start = time.Now()
 	_, err = stmtUpd.Exec(ThingRegisteredLogined, newKey, eventCreationTime, login.loginReqServData.ThingId)
+	metrics.ObserveDBQuery("saveNewAesKeyAndThingStatus", start, err)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -171,15 +191,17 @@ func (login *Login) getAesKeyByKeyType(keyType uint8) (string, error) {
 	}
 
 	var prethingaes128key, thingaes128key string
+	start := time.Now()
 	err = thingDB.QueryRow("SELECT prethingaes128key,thingaes128key FROM thingbaseinfodata_tbl WHERE thingid = ?", login.loginReqServData.ThingId).Scan(
 		&prethingaes128key,
 		&thingaes128key)
+	metrics.ObserveDBQuery("getAesKeyByKeyType", start, err)
 	if err != nil {
 		logger.Error(err)
 		return "", err
 	}
 
-	if keyType == KeyTypeCurrentAesKey {
+	if keyType == protocol.KeyTypeCurrentAesKey {
 		return thingaes128key, nil
 	}
 
@@ -188,22 +210,26 @@ func (login *Login) getAesKeyByKeyType(keyType uint8) (string, error) {
 
 func (login *Login) LoginRequest(thing *Thing, reqMsg *message.Message) error {
 	if login.loginStatus != LoginStop {
-		logger.Error("Login already start!")
-		return errors.New("Login already start!")
+		thing.log.Error("Login already start!")
+		return message.NewResultError(message.ResultBusy, errors.New("Login already start!"))
 	}
 
 	login.loginStatus = LoginRequestStatus
 
-	login.loginReqServData = &LoginReqServData{}
-	err := json.Unmarshal(reqMsg.ServData, login.loginReqServData)
+	login.loginReqServData = &protocol.LoginReqServData{}
+	err := reqMsg.UnmarshalServData(login.loginReqServData)
 	if err != nil {
-		logger.Error(err)
-		return err
+		thing.log.Error(err)
+		login.loginStatus = LoginStop
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
 	login.loginEventCreatTime = reqMsg.DisPatch.EventCreationTime
 
-	logger.Debug("login.loginReqServData =", string(reqMsg.ServData))
+	//Not checked yet, but failed logins must be found by thingid too
+	thing.setLog(thing.log.With("thingid", login.loginReqServData.ThingId, "bid", reqMsg.MesHeader.Bid))
+
+	thing.log.Debug("login.loginReqServData =", string(reqMsg.ServData))
 
 	thing.PushEventChannel(EventLoginChallenge, reqMsg)
 	return nil
@@ -211,75 +237,70 @@ func (login *Login) LoginRequest(thing *Thing, reqMsg *message.Message) error {
 
 func (login *Login) LoginChallenge(thing *Thing, reqMsg *message.Message) error {
 	if login.loginStatus != LoginRequestStatus {
-		logger.Error("Need LoginRequest!")
+		thing.log.Error("Need LoginRequest!")
 		return errors.New("Need LoginRequest!")
 	}
 
 	login.loginStatus = LoginChallengeStatus
 
-	var result byte
 	var key string
+	var err error
 
 	msg := message.Message{
 		Connection: thing.Conn,
 	}
 
 	//Service data
-	login.loginChallServData = &LoginChallengeServData{}
+	login.loginChallServData = &protocol.LoginChallengeServData{}
 
 	//Check data validity
 	ok, result := login.checkLoginReqData(reqMsg)
 	if ok {
-		thingDB, err := database.GetDB(DBName)
-		if err != nil {
-			logger.Error(err)
-			login.loginStatus = LoginStop
-			return err
-		}
-
-		var prethingaes128key, thingaes128key string
-		err = thingDB.QueryRow("SELECT prethingaes128key,thingaes128key FROM thingbaseinfodata_tbl WHERE thingid = ?", login.loginReqServData.ThingId).Scan(
-			&prethingaes128key,
-			&thingaes128key)
+		key, err = login.getAesKeyByKeyType(login.loginReqServData.KeyType)
 		if err != nil {
-			logger.Error(err)
-			login.loginStatus = LoginStop
-			return err
-		}
-
-		if login.loginReqServData.KeyType == KeyTypePreAesKey {
-			key = prethingaes128key
-		} else {
-			key = thingaes128key
+			thing.log.Error(err)
+			ok, result = false, protocol.LoginResultCodeInterrupt
 		}
+	}
 
+	//A failure is sent in clear, there may be no key to encrypt it and it carries no secret
+	securityVersion := message.Encrypt_AES128
+	if ok {
 		login.loginChallServData.PlatRandom = util.GenRandomString(16)
 		login.loginChallServData.ThingRandomMd5 = login.genMd5Abstract16Bytes(login.loginReqServData.ThingRandom + key + login.loginChallServData.PlatRandom)
 	} else {
+		securityVersion = message.Encrypt_No
 		login.loginChallServData.PlatRandom = ""
 		login.loginChallServData.ThingRandomMd5 = ""
+		metrics.Logins.WithLabelValues(protocol.LoginResultName(result)).Inc()
+		thing.emitLifecycle(&LifecycleEvent{
+			Event:   LifecycleLoginFailure,
+			ThingId: login.loginReqServData.ThingId,
+			Bid:     reqMsg.MesHeader.Bid,
+			Reason:  protocol.LoginResultName(result),
+		})
 	}
 
-	serviceData, err := json.Marshal(login.loginChallServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), login.loginChallServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Debug("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceDataJson =", string(serviceData))
 
 	/*	aesKey, err := login.getAesKeyByKeyType(login.loginReqServData.KeyType)
 		if err != nil {
-			logger.Error(err)
+			thing.log.Error(err)
 			login.loginStatus = LoginStop
 			return err
 		}
 	*/
-	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, key, serviceData)
+	encryptServData, err := msg.EncryptServiceData(securityVersion, key, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -287,18 +308,18 @@ func (login *Login) LoginChallenge(thing *Thing, reqMsg *message.Message) error
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
-		Aid:                  0x2,
-		Mid:                  0x2,
+		Aid:                  protocol.LoginChallengeAid,
+		Mid:                  protocol.LoginChallengeMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               result,
-		SecurityVersion:      message.Encrypt_AES128,
+		SecurityVersion:      securityVersion,
 		DispatchCreationTime: uint32(time.Now().Unix()),
 	}
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -307,14 +328,14 @@ func (login *Login) LoginChallenge(thing *Thing, reqMsg *message.Message) error
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              reqMsg.MesHeader.Bid,
-		MessageFlag:      0x0,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.LoginMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -322,12 +343,19 @@ func (login *Login) LoginChallenge(thing *Thing, reqMsg *message.Message) error
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
-	logger.Debug("Send LoginChallenge Success---")
+	thing.log.Debug("Send LoginChallenge Success---")
+
+	//The thing does not answer a failure, it registers or logs in again later
+	if !ok {
+		thing.log.Info("Login failure, result =", protocol.LoginResultName(result))
+		login.loginStatus = LoginStop
+		return nil
+	}
 
 	login.timeoutTimer = time.NewTimer(TimeoutTime)
 
@@ -336,17 +364,17 @@ func (login *Login) LoginChallenge(thing *Thing, reqMsg *message.Message) error
 	}
 
 	go func() {
-		logger.Debug("Start timer......")
+		thing.log.Debug("Start timer......")
 		select {
 		case <-login.timeoutTimer.C:
-			logger.Warn("Timeout timer coming, login fail!")
+			thing.log.Warn("Timeout timer coming, login fail!")
 			//thing.PushEventChannel(RegisterReqEventMessage, nil)
 			login.loginStatus = LoginStop
 		case <-login.closeTimeoutTimer:
-			logger.Debug("Close Timeout timer!")
+			thing.log.Debug("Close Timeout timer!")
 		}
 
-		logger.Debug("Timer Close......")
+		thing.log.Debug("Timer Close......")
 	}()
 
 	return nil
@@ -354,12 +382,12 @@ func (login *Login) LoginChallenge(thing *Thing, reqMsg *message.Message) error
 
 func (login *Login) LoginResponse(thing *Thing, respMsg *message.Message) error {
 	if login.loginStatus != LoginChallengeStatus {
-		logger.Error("Need LoginChallenge!")
+		thing.log.Error("Need LoginChallenge!")
 		return errors.New("Need LoginChallenge!")
 	}
 
 	if login.loginEventCreatTime != respMsg.DisPatch.EventCreationTime {
-		logger.Error("Package out of date!")
+		thing.log.Error("Package out of date!")
 		return errors.New("Package out of date!")
 	}
 
@@ -369,37 +397,39 @@ func (login *Login) LoginResponse(thing *Thing, respMsg *message.Message) error
 
 	thingDB, err := database.GetDB(DBName)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
-		return err
+		return message.NewResultError(message.ResultInternalError, err)
 	}
 
 	var prethingaes128key, thingaes128key string
+	start := time.Now()
 	err = thingDB.QueryRow("SELECT prethingaes128key,thingaes128key FROM thingbaseinfodata_tbl WHERE thingid = ?", login.loginReqServData.ThingId).Scan(
 		&prethingaes128key,
 		&thingaes128key)
+	metrics.ObserveDBQuery("LoginResponse", start, err)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
-		return err
+		return message.NewResultError(message.ResultInternalError, err)
 	}
 
 	var key string
-	if login.loginReqServData.KeyType == KeyTypePreAesKey {
+	if login.loginReqServData.KeyType == protocol.KeyTypePreAesKey {
 		key = prethingaes128key
 	} else {
 		key = thingaes128key
 	}
 
-	login.loginRespServData = &LoginResponseServData{}
-	err = json.Unmarshal(respMsg.ServData, login.loginRespServData)
+	login.loginRespServData = &protocol.LoginResponseServData{}
+	err = respMsg.UnmarshalServData(login.loginRespServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
-		return err
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
-	logger.Debug("login.loginRespServData =", string(respMsg.ServData))
+	thing.log.Debug("login.loginRespServData =", string(respMsg.ServData))
 
 	if login.loginRespServData.AccessKey == login.genMd5Abstract16Bytes(login.loginChallServData.PlatRandom+key) {
 		thing.PushEventChannel(EventLoginSuccess, respMsg)
@@ -412,8 +442,13 @@ func (login *Login) LoginResponse(thing *Thing, respMsg *message.Message) error
 
 func (login *Login) LoginFailure(thing *Thing, respMsg *message.Message) error {
 	if login.loginStatus != LoginResponseStatus {
-		logger.Error("Need LoginResponse!")
-		//return errors.New("Need LoginResponse!")
+		thing.log.Error("Need LoginResponse!")
+		return errors.New("Need LoginResponse!")
+	}
+
+	if login.loginReqServData == nil {
+		thing.log.Error("Need LoginRequest!")
+		return errors.New("Need LoginRequest!")
 	}
 
 	msg := message.Message{
@@ -421,41 +456,49 @@ func (login *Login) LoginFailure(thing *Thing, respMsg *message.Message) error {
 	}
 
 	//Service data
-	loginFailServData := &LoginFailureServData{}
-	loginFailServData.FailureCode = FailureCodeKeyErr
+	loginFailServData := &protocol.LoginFailureServData{}
+	loginFailServData.FailureCode = protocol.FailureCodeKeyErr
 
-	var result byte = LoginResultCodeAbstractErr
+	var result byte = protocol.LoginResultCodeAbstractErr
 	if thing.CheckAesKeyOutOfDate(respMsg.MesHeader.Bid) {
-		result = LoginResultCodeAesOutOfDate
-		loginFailServData.FailureCode = FailureCodeKeyOutOfDate
+		result = protocol.LoginResultCodeAesOutOfDate
+		loginFailServData.FailureCode = protocol.FailureCodeKeyOutOfDate
 	}
 
 	if login.loginStatus == LoginStop {
-		result = LoginResultCodeInterrupt
+		result = protocol.LoginResultCodeInterrupt
 	}
 
-	serviceData, err := json.Marshal(loginFailServData)
+	metrics.Logins.WithLabelValues(protocol.LoginResultName(result)).Inc()
+	thing.emitLifecycle(&LifecycleEvent{
+		Event:   LifecycleLoginFailure,
+		ThingId: login.loginReqServData.ThingId,
+		Bid:     respMsg.MesHeader.Bid,
+		Reason:  protocol.LoginResultName(result),
+	})
+
+	serviceData, err := protocol.Marshal(thing.Encoding(), loginFailServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Debug("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceDataJson =", string(serviceData))
 
 	var aesKey string
 	if login.loginStatus == LoginResponseStatus {
 		aesKey, err = login.getAesKeyByKeyType(login.loginReqServData.KeyType)
 		if err != nil {
-			logger.Error(err)
+			thing.log.Error(err)
 			login.loginStatus = LoginStop
 			return err
 		}
 	} else {
-		aesKey, err = login.getAesKeyByKeyType(KeyTypePreAesKey)
+		aesKey, err = login.getAesKeyByKeyType(protocol.KeyTypePreAesKey)
 		if err != nil {
-			logger.Error(err)
+			thing.log.Error(err)
 			login.loginStatus = LoginStop
 			return err
 		}
@@ -463,7 +506,7 @@ func (login *Login) LoginFailure(thing *Thing, respMsg *message.Message) error {
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -471,8 +514,8 @@ func (login *Login) LoginFailure(thing *Thing, respMsg *message.Message) error {
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    respMsg.DisPatch.EventCreationTime,
-		Aid:                  0x2,
-		Mid:                  0x4,
+		Aid:                  protocol.LoginFailureAid,
+		Mid:                  protocol.LoginFailureMid,
 		MessageCounter:       respMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               result,
@@ -482,7 +525,7 @@ func (login *Login) LoginFailure(thing *Thing, respMsg *message.Message) error {
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -491,14 +534,14 @@ func (login *Login) LoginFailure(thing *Thing, respMsg *message.Message) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              respMsg.MesHeader.Bid,
-		MessageFlag:      0x0,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.LoginMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -506,19 +549,19 @@ func (login *Login) LoginFailure(thing *Thing, respMsg *message.Message) error {
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
-	logger.Debug("Send LoginFailure Success---")
+	thing.log.Debug("Send LoginFailure Success---")
 	login.loginStatus = LoginStop
 	return nil
 }
 
 func (login *Login) LoginSuccess(thing *Thing, respMsg *message.Message) error {
 	if login.loginStatus != LoginResponseStatus {
-		logger.Error("Need LoginResponse!")
+		thing.log.Error("Need LoginResponse!")
 		return errors.New("Need LoginResponse!")
 	}
 
@@ -527,7 +570,7 @@ func (login *Login) LoginSuccess(thing *Thing, respMsg *message.Message) error {
 	}
 
 	//Service data
-	loginSuccessServData := &LoginSuccessServData{
+	loginSuccessServData := &protocol.LoginSuccessServData{
 		AesRandom:     util.GenRandomString(16),
 		InitSerial:    0,
 		TimeStamp:     time.Now().Unix(),
@@ -535,26 +578,26 @@ func (login *Login) LoginSuccess(thing *Thing, respMsg *message.Message) error {
 		LinkHeartbeat: 0,
 	}
 
-	serviceData, err := json.Marshal(loginSuccessServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), loginSuccessServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Debug("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceDataJson =", string(serviceData))
 
 	aesKey, err := login.getAesKeyByKeyType(login.loginReqServData.KeyType)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -562,18 +605,18 @@ func (login *Login) LoginSuccess(thing *Thing, respMsg *message.Message) error {
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    respMsg.DisPatch.EventCreationTime,
-		Aid:                  0x2,
-		Mid:                  0x5,
+		Aid:                  protocol.LoginSuccessAid,
+		Mid:                  protocol.LoginSuccessMid,
 		MessageCounter:       respMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
-		Result:               LoginResultCodeSuccess,
+		Result:               protocol.LoginResultCodeSuccess,
 		SecurityVersion:      message.Encrypt_AES128,
 		DispatchCreationTime: uint32(time.Now().Unix()),
 	}
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -582,21 +625,21 @@ func (login *Login) LoginSuccess(thing *Thing, respMsg *message.Message) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              respMsg.MesHeader.Bid,
-		MessageFlag:      0x0,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.LoginMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
 	err = login.saveNewAesKeyAndThingStatus(loginSuccessServData.AesRandom, respMsg.DisPatch.EventCreationTime)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
@@ -604,21 +647,23 @@ func (login *Login) LoginSuccess(thing *Thing, respMsg *message.Message) error {
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
-	logger.Debug("Send LoginSuccess Success---")
+	thing.log.Debug("Send LoginSuccess Success---")
 
 	err = thing.SetThingIdAndBid(login.loginReqServData.ThingId, respMsg.MesHeader.Bid)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		login.loginStatus = LoginStop
 		return err
 	}
 
-	logger.Info(login.loginReqServData.ThingId, "Login success!")
+	thing.log.Info(login.loginReqServData.ThingId, "Login success!")
+	metrics.Logins.WithLabelValues(protocol.LoginResultName(protocol.LoginResultCodeSuccess)).Inc()
+	thing.emitLifecycle(&LifecycleEvent{Event: LifecycleOnline})
 
 	login.loginStatus = LoginStop
 	return nil
@@ -0,0 +1,193 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/harveywangdao/road/database"
+	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	testThingId   = "WDDUX52684DFR4582"
+	testThingSN   = "TB0001"
+	testBid       = 7
+	testPreAesKey = "0123456789abcdef"
+)
+
+//testConn keeps what the gateway sends, the thing never sends anything
+type testConn struct {
+	sent bytes.Buffer
+}
+
+func (c *testConn) Read(b []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (c *testConn) Write(b []byte) (int, error) {
+	return c.sent.Write(b)
+}
+
+//registerThingDB points DBName at a SQLite file in a new directory, with the test thing when registered is true
+func registerThingDB(t *testing.T, path string, registered bool) {
+	err := database.Register(database.SQLiteConfig(DBName, path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close(DBName) })
+
+	if !registered {
+		return
+	}
+
+	db, err := database.GetDB(DBName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec("INSERT INTO thingbaseinfodata_tbl (thingserialno,prethingaes128key,thingid,status,bid) VALUES (?,?,?,?,?)",
+		testThingSN, testPreAesKey, testThingId, ThingRegisteredUnLogin, testBid)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+//login runs LoginRequest and LoginChallenge and returns the challenge the thing got
+func login(t *testing.T) (*Login, *message.Message) {
+	conn := &testConn{}
+	thing, err := NewThing(make(chan ThingMessage, 8), conn, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	servData, err := json.Marshal(&protocol.LoginReqServData{
+		KeyType:     protocol.KeyTypePreAesKey,
+		ThingSN:     testThingSN,
+		ThingId:     testThingId,
+		ThingRandom: "fedcba9876543210",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqMsg := &message.Message{
+		MesHeader: message.MessageHeader{Bid: testBid},
+		DisPatch: message.DispatchData{
+			EventCreationTime: 100,
+			Aid:               protocol.LoginRequestAid,
+			Mid:               protocol.LoginRequestMid,
+		},
+		ServData: servData,
+	}
+
+	l := &Login{}
+	err = l.LoginRequest(thing, reqMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thingMsg := <-thing.ThingMsgChan
+	if thingMsg.Event != EventLoginChallenge {
+		t.Fatalf("LoginRequest pushed %s, want EventLoginChallenge", GetEventName(thingMsg.Event))
+	}
+
+	err = l.LoginChallenge(thing, thingMsg.Msg)
+	if err != nil {
+		t.Fatal("LoginChallenge:", err)
+	}
+
+	challenge := &message.Message{
+		CallbackFn: func(bid uint32) string { return testPreAesKey },
+	}
+	_, err = challenge.ParseOneMessage(conn.sent.Bytes())
+	if err != nil {
+		t.Fatal("No valid LoginChallenge sent:", err)
+	}
+
+	if challenge.DisPatch.Aid != protocol.LoginChallengeAid || challenge.DisPatch.Mid != protocol.LoginChallengeMid {
+		t.Fatalf("Sent %s, want LoginChallenge", protocol.MessageName(challenge.DisPatch.Aid, challenge.DisPatch.Mid))
+	}
+
+	if l.closeTimeoutTimer != nil {
+		l.closeTimeoutTimer <- true
+	}
+
+	return l, challenge
+}
+
+func checkLoginFailure(t *testing.T, l *Login, challenge *message.Message, result byte) {
+	if challenge.DisPatch.Result != result {
+		t.Errorf("Result = %s, want %s", protocol.LoginResultName(challenge.DisPatch.Result), protocol.LoginResultName(result))
+	}
+
+	if challenge.DisPatch.SecurityVersion != message.Encrypt_No {
+		t.Errorf("Failure sent with security version %d", challenge.DisPatch.SecurityVersion)
+	}
+
+	if l.loginStatus != LoginStop {
+		t.Errorf("loginStatus = %d after a failure, want LoginStop", l.loginStatus)
+	}
+}
+
+func TestLoginStoreUnavailable(t *testing.T) {
+	registerThingDB(t, filepath.Join(t.TempDir(), "missing", "thing.db"), false)
+
+	l, challenge := login(t)
+	checkLoginFailure(t, l, challenge, protocol.LoginResultCodeInterrupt)
+}
+
+func TestLoginUnregistered(t *testing.T) {
+	registerThingDB(t, filepath.Join(t.TempDir(), "thing.db"), false)
+
+	l, challenge := login(t)
+	checkLoginFailure(t, l, challenge, protocol.LoginResultCodeBidErrOrUnreg)
+}
+
+func TestLoginChallenge(t *testing.T) {
+	registerThingDB(t, filepath.Join(t.TempDir(), "thing.db"), true)
+
+	l, challenge := login(t)
+
+	if challenge.DisPatch.Result != protocol.LoginResultCodeSuccess || challenge.DisPatch.SecurityVersion != message.Encrypt_AES128 {
+		t.Errorf("Result = %s, security version %d", protocol.LoginResultName(challenge.DisPatch.Result), challenge.DisPatch.SecurityVersion)
+	}
+
+	if l.loginStatus != LoginChallengeStatus {
+		t.Errorf("loginStatus = %d, want LoginChallengeStatus", l.loginStatus)
+	}
+
+	servData := &protocol.LoginChallengeServData{}
+	err := challenge.UnmarshalServData(servData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if servData.ThingRandomMd5 != l.genMd5Abstract16Bytes("fedcba9876543210"+testPreAesKey+servData.PlatRandom) {
+		t.Error("ThingRandomMd5 is not made with the pre-shared key")
+	}
+}
+
+//A LoginFailure out of turn, before any LoginRequest, is dropped without an answer
+func TestLoginFailureOutOfTurn(t *testing.T) {
+	conn := &testConn{}
+	thing, err := NewThing(make(chan ThingMessage, 1), conn, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	respMsg := &message.Message{}
+	for _, status := range []int{LoginStop, LoginResponseStatus} {
+		l := &Login{loginStatus: status}
+		err = l.LoginFailure(thing, respMsg)
+		if err == nil {
+			t.Errorf("LoginFailure in status %d succeeded", status)
+		}
+	}
+
+	if conn.sent.Len() != 0 {
+		t.Errorf("Sent %d bytes", conn.sent.Len())
+	}
+}
@@ -1,13 +1,12 @@
 package gateway
 
 import (
-	"encoding/json"
 	"errors"
 	/*"gopkg.in/mgo.v2/bson"*/
 	//"github.com/harveywangdao/road/database"
 	//"github.com/harveywangdao/road/database/mongo"
-	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	//"github.com/jinzhu/gorm"
 	"time"
@@ -15,14 +14,6 @@ import (
 
 const (
 	ReadConfigTimeoutTime = 5 * time.Second
-
-	ReadConfigMessageFlag = 0x0
-
-	ReadConfigReqAid = 0x5
-	ReadConfigReqMid = 0x1
-
-	ReadConfigAckAid = 0x5
-	ReadConfigAckMid = 0x2
 )
 
 const (
@@ -31,10 +22,8 @@ const (
 )
 
 const (
-	ThingSN  = "thingsn"
-	Version  = "version"
-	WorkAddr = "workaddr"
-	WorkPort = "workport"
+	EventReadConfigRequest = protocol.MsgReadConfigReq
+	EventReadConfigAck     = protocol.MsgReadConfigAck
 )
 
 type ReadConfig struct {
@@ -43,33 +32,48 @@ type ReadConfig struct {
 
 	readConfigStatus int
 
-	readConfReqServData *ReadConfigReqServData
+	readConfReqServData *protocol.ReadConfigReqServData
 }
 
-type ReadConfigReqServData struct {
-	IndexList []string `json:"indexlist"`
-}
-
-type ReadConfigAckServData struct {
-	WorkConfigList []string `json:"workconfiglist"`
+func init() {
+	RegisterApp(App{
+		Name:     "readconfig",
+		NewState: func() interface{} { return &ReadConfig{} },
+		Events: []AppEvent{
+			{
+				Event: EventReadConfigRequest,
+				Name:  "EventReadConfigRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReadConfig).ReadConfigReq(thing)
+				},
+			},
+			{
+				Event: EventReadConfigAck,
+				Name:  "EventReadConfigAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReadConfig).ReadConfigAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (readConf *ReadConfig) GetIndexList() []string {
 	indexs := make([]string, 0, 16)
-	indexs = append(indexs, ThingSN)
-	indexs = append(indexs, Version)
-	indexs = append(indexs, WorkPort)
+	indexs = append(indexs, protocol.ThingSN)
+	indexs = append(indexs, protocol.Version)
+	indexs = append(indexs, protocol.WorkPort)
 	return indexs
 }
 
 func (readConf *ReadConfig) ReadConfigReq(thing *Thing) error {
 	if readConf.readConfigStatus != ReadConfigStop {
-		logger.Error("ReadConfig already start!")
+		thing.log.Error("ReadConfig already start!")
 		return errors.New("ReadConfig already start!")
 	}
 
 	/*	if thing.ThingStatus != ThingRegisteredLogined{
-		logger.Error("Not login or register")
+		thing.log.Error("Not login or register")
 		return errors.New("Not login or register")
 	}*/
 
@@ -80,36 +84,36 @@ func (readConf *ReadConfig) ReadConfigReq(thing *Thing) error {
 	}
 
 	//Service data
-	readConf.readConfReqServData = &ReadConfigReqServData{
+	readConf.readConfReqServData = &protocol.ReadConfigReqServData{
 		IndexList: readConf.GetIndexList(),
 	}
 
-	serviceData, err := json.Marshal(readConf.readConfReqServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), readConf.readConfReqServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Info("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Info("serviceDataJson =", string(serviceData))
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  ReadConfigReqAid,
-		Mid:                  ReadConfigReqMid,
+		Aid:                  protocol.ReadConfigReqAid,
+		Mid:                  protocol.ReadConfigReqMid,
 		MessageCounter:       0,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -119,7 +123,7 @@ func (readConf *ReadConfig) ReadConfigReq(thing *Thing) error {
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -127,25 +131,25 @@ func (readConf *ReadConfig) ReadConfigReq(thing *Thing) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              thing.GetBid(),
-		MessageFlag:      ReadConfigMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ReadConfigMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("Send ReadConfigReq Success---")
+	thing.log.Debug("Send ReadConfigReq Success---")
 
 	readConf.readConfTimeoutTimer = time.NewTimer(ReadConfigTimeoutTime)
 
@@ -154,17 +158,17 @@ func (readConf *ReadConfig) ReadConfigReq(thing *Thing) error {
 	}
 
 	go func() {
-		logger.Debug("Start timer......")
+		thing.log.Debug("Start timer......")
 		select {
 		case <-readConf.readConfTimeoutTimer.C:
-			logger.Warn("Timeout timer coming, readConf fail!")
+			thing.log.Warn("Timeout timer coming, readConf fail!")
 			//thing.PushEventChannel(EventReadConfigRequest, nil)
 			readConf.readConfigStatus = ReadConfigStop
 		case <-readConf.closeTimeoutTimer:
-			logger.Debug("Close Timeout timer!")
+			thing.log.Debug("Close Timeout timer!")
 		}
 
-		logger.Debug("Timer Close......")
+		thing.log.Debug("Timer Close......")
 	}()
 
 	return nil
@@ -172,23 +176,23 @@ func (readConf *ReadConfig) ReadConfigReq(thing *Thing) error {
 
 func (readConf *ReadConfig) ReadConfigAck(thing *Thing, ackMsg *message.Message) error {
 	if readConf.readConfigStatus != ReadConfigReqStatus {
-		logger.Error("Need ReadConfigReq!")
+		thing.log.Error("Need ReadConfigReq!")
 		return errors.New("Need ReadConfigReq!")
 	}
 
 	readConf.readConfTimeoutTimer.Stop()
 	readConf.closeTimeoutTimer <- true
 
-	readConfAckServData := &ReadConfigAckServData{}
-	err := json.Unmarshal(ackMsg.ServData, readConfAckServData)
+	readConfAckServData := &protocol.ReadConfigAckServData{}
+	err := ackMsg.UnmarshalServData(readConfAckServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		readConf.readConfigStatus = ReadConfigStop
-		return err
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
-	logger.Info("readConfAckServData =", string(ackMsg.ServData))
-	logger.Debug("WorkConfigList =", readConfAckServData.WorkConfigList)
+	thing.log.Info("readConfAckServData =", string(ackMsg.ServData))
+	thing.log.Debug("WorkConfigList =", readConfAckServData.WorkConfigList)
 
 	readConf.readConfigStatus = ReadConfigStop
 
@@ -2,11 +2,12 @@ package gateway
 
 import (
 	"encoding/hex"
-	"encoding/json"
 	"github.com/harveywangdao/road/crypto/md5"
 	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/metrics"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
@@ -18,28 +19,36 @@ import (
 )
 
 const (
-	RegisterSuccess byte = 0x78
-	RegisterFailure byte = 0xA8
-	AlreadyRegister byte = 0x79
+	RegisterReqEventMessage = protocol.MsgRegisterReq
+	RegisterAckEventMessage = protocol.MsgRegisterAck
 )
 
 type Register struct {
-	registerReqData *RegisterReqData
+	registerReqData *protocol.RegisterReqData
 }
 
-type RegisterReqData struct {
-	PerAesKey  string `json:"peraeskey"`
-	ThingId    string `json:"thingid"`
-	TBoxSN     string `json:"tboxsn"`
-	IMSI       string `json:"imsi"`
-	RollNumber string `json:"rollnumber"`
-	ICCID      string `json:"iccid"`
-}
-
-type RegisterAckMsg struct {
-	Status      byte   `json:"status"`
-	CallbackNum string `json:"callbacknumber"`
-	Bid         uint32 `json:"bid"`
+func init() {
+	RegisterApp(App{
+		Name:     "register",
+		NewState: func() interface{} { return &Register{} },
+		Events: []AppEvent{
+			{
+				Event: RegisterReqEventMessage,
+				Name:  "RegisterReqEventMessage",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					thing.PushEventChannel(RegisterAckEventMessage, thingMsg.Msg)
+					return nil
+				},
+			},
+			{
+				Event: RegisterAckEventMessage,
+				Name:  "RegisterAckEventMessage",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Register).RegisterACK(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (re *Register) genCallbackNum() string {
@@ -53,7 +62,7 @@ func (re *Register) genCallbackNum() string {
 }
 
 func (re *Register) checkRegisterData() byte {
-	result := RegisterFailure //fail
+	result := protocol.RegisterFailure //fail
 
 	db, err := database.GetDB(DBName)
 	if err != nil {
@@ -63,6 +72,7 @@ func (re *Register) checkRegisterData() byte {
 
 	var thingserialno, prethingaes128key, thingid, iccid, imsi, thingaes128key string
 	var id, status, bid, eventCreationTime int
+	start := time.Now()
 	err = db.QueryRow("SELECT * FROM thingbaseinfodata_tbl WHERE thingid = ?", re.registerReqData.ThingId).Scan(
 		&id,
 		&thingserialno,
@@ -74,6 +84,7 @@ func (re *Register) checkRegisterData() byte {
 		&bid,
 		&thingaes128key,
 		&eventCreationTime)
+	metrics.ObserveDBQuery("checkRegisterData", start, err)
 
 	if err != nil {
 		logger.Error(err)
@@ -93,10 +104,10 @@ func (re *Register) checkRegisterData() byte {
 	}
 
 	if status == ThingRegisteredUnLogin || status == ThingRegisteredLogined {
-		result = AlreadyRegister //already register
+		result = protocol.AlreadyRegister //already register
 		return result
 	} else {
-		result = RegisterSuccess //success
+		result = protocol.RegisterSuccess //success
 	}
 
 	return result
@@ -116,7 +127,9 @@ func (re *Register) registerThing(bid, eventCreationTime uint32, newAesKey strin
 	}
 	defer stmtUpd.Close()
 
+	start := time.Now()
 	_, err = stmtUpd.Exec(ThingRegisteredUnLogin, int(bid), newAesKey, eventCreationTime, re.registerReqData.ThingId)
+	metrics.ObserveDBQuery("registerThing", start, err)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -128,8 +141,8 @@ func (re *Register) registerThing(bid, eventCreationTime uint32, newAesKey strin
 func (re *Register) getDispatchData(regReqMsg *message.Message, encryptServData []byte, result byte) ([]byte, error) {
 	var dd message.DispatchData
 	dd.EventCreationTime = regReqMsg.DisPatch.EventCreationTime
-	dd.Aid = 0x1
-	dd.Mid = 0x2
+	dd.Aid = protocol.RegisterAckAid
+	dd.Mid = protocol.RegisterAckMid
 	dd.MessageCounter = regReqMsg.DisPatch.MessageCounter + 1
 	dd.ServiceDataLength = uint16(len(encryptServData))
 	dd.Result = result
@@ -145,13 +158,13 @@ func (re *Register) getDispatchData(regReqMsg *message.Message, encryptServData
 	return dispatchData, nil
 }
 
-func (re *Register) getMessageHeaderData(serviceData []byte, bid uint32) ([]byte, error) {
+func (re *Register) getMessageHeaderData(serviceData []byte, bid uint32, encoding int) ([]byte, error) {
 	var mh message.MessageHeader
 	mh.FixHeader = message.MessageHeaderID
 	mh.ServiceDataCheck = util.DataXOR(serviceData)
-	mh.ServiceVersion = 0x0 //not sure
+	mh.ServiceVersion = protocol.ServiceVersion
 	mh.Bid = bid
-	mh.MessageFlag = 0x0
+	mh.MessageFlag = protocol.FlagWithEncoding(protocol.RegisterMessageFlag, encoding)
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
@@ -170,7 +183,9 @@ func (re *Register) genBid() uint32 {
 	}
 
 	var id int
+	start := time.Now()
 	err = db.QueryRow("SELECT id FROM thingbaseinfodata_tbl WHERE thingid = ?", re.registerReqData.ThingId).Scan(&id)
+	metrics.ObserveDBQuery("genBid", start, err)
 	if err != nil {
 		logger.Error(err)
 		return 0
@@ -179,9 +194,9 @@ func (re *Register) genBid() uint32 {
 	return uint32(id)
 }
 
-func (re *Register) RegisterACK(conn message.MessageConn, regReqMsg *message.Message) error {
+func (re *Register) RegisterACK(thing *Thing, regReqMsg *message.Message) error {
 	msg := message.Message{
-		Connection: conn,
+		Connection: thing.Conn,
 	}
 
 	var result byte
@@ -189,31 +204,31 @@ func (re *Register) RegisterACK(conn message.MessageConn, regReqMsg *message.Mes
 	var callbackNum string = ""
 
 	//Check data validity
-	re.registerReqData = &RegisterReqData{}
-	err := json.Unmarshal(regReqMsg.ServData, re.registerReqData)
+	re.registerReqData = &protocol.RegisterReqData{}
+	err := regReqMsg.UnmarshalServData(re.registerReqData)
 	if err != nil {
-		logger.Error(err)
-		return err
+		thing.log.Error(err)
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
-	logger.Debug("re.registerReqData =", string(regReqMsg.ServData))
+	thing.log.Debug("re.registerReqData =", string(regReqMsg.ServData))
 
 	result = re.checkRegisterData()
-	if result == RegisterSuccess || result == AlreadyRegister {
+	if result == protocol.RegisterSuccess || result == protocol.AlreadyRegister {
 		callbackNum = re.genCallbackNum()
 		bid = re.genBid()
 
 		err := re.registerThing(bid, regReqMsg.DisPatch.EventCreationTime, callbackNum)
 		if err != nil {
-			logger.Error(err)
-			return err
+			thing.log.Error(err)
+			return message.NewResultError(message.ResultInternalError, err)
 		}
 	}
 
 	//Service data
-	registerAckMsg := &RegisterAckMsg{}
+	registerAckMsg := &protocol.RegisterAckMsg{}
 
-	if result == RegisterSuccess {
+	if result == protocol.RegisterSuccess {
 		registerAckMsg.Status = 0
 	} else {
 		registerAckMsg.Status = 1
@@ -222,41 +237,49 @@ func (re *Register) RegisterACK(conn message.MessageConn, regReqMsg *message.Mes
 	registerAckMsg.CallbackNum = callbackNum
 	registerAckMsg.Bid = bid
 
-	serviceData, err := json.Marshal(registerAckMsg)
+	serviceData, err := protocol.Marshal(regReqMsg.Encoding(), registerAckMsg)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Debug("serviceData =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceData =", string(serviceData))
 
 	//Encrypy serviceData
 	encryptServData := serviceData
 
 	dispatchData, err := re.getDispatchData(regReqMsg, encryptServData, result)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	messageHeaderData, err := re.getMessageHeaderData(serviceData, bid)
+	messageHeaderData, err := re.getMessageHeaderData(serviceData, bid, regReqMsg.Encoding())
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Info(re.registerReqData.ThingId, "Register success!")
+	thing.log.Info(re.registerReqData.ThingId, "Register success!")
+
+	if result == protocol.RegisterSuccess || result == protocol.AlreadyRegister {
+		thing.emitLifecycle(&LifecycleEvent{
+			Event:   LifecycleRegistered,
+			ThingId: re.registerReqData.ThingId,
+			Bid:     bid,
+		})
+	}
 
 	err = re.testgrpc()
 	if err != nil {
-		logger.Error("GRPC ERROR!")
+		thing.log.Error("GRPC ERROR!")
 		return err
 	}
 
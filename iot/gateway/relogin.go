@@ -1,10 +1,9 @@
 package gateway
 
 import (
-	"encoding/json"
 	"errors"
-	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
@@ -12,14 +11,6 @@ import (
 const (
 	ReloginTimeoutTime = 10 * time.Second
 	ReLoginAgainTime   = 10 * time.Second
-
-	ReLoginMessageFlag = 0x0
-
-	ReLoginReqAid = 0x4
-	ReLoginReqMid = 0x1
-
-	ReLoginAckAid = 0x4
-	ReLoginAckMid = 0x2
 )
 
 const (
@@ -27,6 +18,11 @@ const (
 	ReLoginReqStatus
 )
 
+const (
+	EventReLoginRequest = protocol.MsgReLoginReq
+	EventReLoginAck     = protocol.MsgReLoginAck
+)
+
 type ReLogin struct {
 	timeoutTimer      *time.Timer
 	closeTimeoutTimer chan bool
@@ -35,8 +31,27 @@ type ReLogin struct {
 	reLoginEventCreatTime uint32
 }
 
-type ReLoginReqServData struct {
-	NewTime byte `json:"newtime"` //NewTime*10 minute
+func init() {
+	RegisterApp(App{
+		Name:     "relogin",
+		NewState: func() interface{} { return &ReLogin{} },
+		Events: []AppEvent{
+			{
+				Event: EventReLoginRequest,
+				Name:  "EventReLoginRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReLogin).ReLoginReq(thing)
+				},
+			},
+			{
+				Event: EventReLoginAck,
+				Name:  "EventReLoginAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReLogin).ReLoginAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (relogin *ReLogin) reLoginReqSendData(thing *Thing) error {
@@ -45,36 +60,36 @@ func (relogin *ReLogin) reLoginReqSendData(thing *Thing) error {
 	}
 
 	//Service data
-	reLoginReqServData := ReLoginReqServData{
+	reLoginReqServData := protocol.ReLoginReqServData{
 		NewTime: 1,
 	}
 
-	serviceData, err := json.Marshal(&reLoginReqServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &reLoginReqServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Debug("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceDataJson =", string(serviceData))
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  ReLoginReqAid,
-		Mid:                  ReLoginReqMid,
+		Aid:                  protocol.ReLoginReqAid,
+		Mid:                  protocol.ReLoginReqMid,
 		MessageCounter:       0,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -84,7 +99,7 @@ func (relogin *ReLogin) reLoginReqSendData(thing *Thing) error {
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -94,21 +109,21 @@ func (relogin *ReLogin) reLoginReqSendData(thing *Thing) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              thing.GetBid(),
-		MessageFlag:      ReLoginMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ReLoginMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -117,12 +132,12 @@ func (relogin *ReLogin) reLoginReqSendData(thing *Thing) error {
 
 func (relogin *ReLogin) ReLoginReq(thing *Thing) error {
 	if relogin.reloginStatus != ReLoginStop {
-		logger.Error("Relogin already start!")
+		thing.log.Error("Relogin already start!")
 		return errors.New("Relogin already start!")
 	}
 
 	/*	if thing.TboxStatus != TboxRegisteredLogined{
-		logger.Error("Not login or register")
+		thing.log.Error("Not login or register")
 		return errors.New("Not login or register")
 	}*/
 
@@ -135,7 +150,7 @@ func (relogin *ReLogin) ReLoginReq(thing *Thing) error {
 		go func() {
 			select {
 			case <-t.C:
-				logger.Info("ReLogin fail, ReLogin again!")
+				thing.log.Info("ReLogin fail, ReLogin again!")
 				thing.PushEventChannel(EventReLoginRequest, nil)
 			}
 		}()
@@ -145,7 +160,7 @@ func (relogin *ReLogin) ReLoginReq(thing *Thing) error {
 		return err
 	}
 
-	logger.Debug("Send ReLoginReq Success---")
+	thing.log.Debug("Send ReLoginReq Success---")
 
 	relogin.timeoutTimer = time.NewTimer(ReloginTimeoutTime)
 
@@ -154,17 +169,17 @@ func (relogin *ReLogin) ReLoginReq(thing *Thing) error {
 	}
 
 	go func() {
-		logger.Debug("Start timer......")
+		thing.log.Debug("Start timer......")
 		select {
 		case <-relogin.timeoutTimer.C:
-			logger.Warn("Timeout timer coming, relogin fail!")
+			thing.log.Warn("Timeout timer coming, relogin fail!")
 			thing.PushEventChannel(EventReLoginRequest, nil)
 			relogin.reloginStatus = ReLoginStop
 		case <-relogin.closeTimeoutTimer:
-			logger.Debug("Close Timeout timer!")
+			thing.log.Debug("Close Timeout timer!")
 		}
 
-		logger.Debug("Timer Close......")
+		thing.log.Debug("Timer Close......")
 	}()
 
 	return nil
@@ -172,12 +187,12 @@ func (relogin *ReLogin) ReLoginReq(thing *Thing) error {
 
 func (relogin *ReLogin) ReLoginAck(thing *Thing, respMsg *message.Message) error {
 	if relogin.reloginStatus != ReLoginReqStatus {
-		logger.Error("Need ReLoginReq!")
+		thing.log.Error("Need ReLoginReq!")
 		return errors.New("Need ReLoginReq!")
 	}
 
 	if relogin.reLoginEventCreatTime != respMsg.DisPatch.EventCreationTime {
-		logger.Error("Package out of date!")
+		thing.log.Error("Package out of date!")
 		return errors.New("Package out of date!")
 	}
 
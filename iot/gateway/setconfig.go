@@ -1,24 +1,15 @@
 package gateway
 
 import (
-	"encoding/json"
 	"errors"
-	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	SetConfigTimeoutTime = 5 * time.Second
-
-	SetConfigMessageFlag = 0x0
-
-	SetConfigReqAid = 0x7
-	SetConfigReqMid = 0x1
-
-	SetConfigAckAid = 0x7
-	SetConfigAckMid = 0x2
 )
 
 const (
@@ -27,36 +18,50 @@ const (
 )
 
 /*const (
-	ThingSN  = "thingsn"
-	Version  = "version"
-	WorkAddr = "workaddr"
-	WorkPort = "workport"
 )*/
 
+const (
+	EventSetConfigRequest = protocol.MsgSetConfigReq
+	EventSetConfigAck     = protocol.MsgSetConfigAck
+)
+
 type SetConfig struct {
 	setConfigTimeoutTimer *time.Timer
 	closeTimeoutTimer     chan bool
 
 	setConfigigStatus int
 
-	setConfigReqServData *SetConfigReqServData
+	setConfigReqServData *protocol.SetConfigReqServData
 }
 
-type SetConfigReqServData struct {
-	IndexList      []string `json:"indexlist"`
-	WorkConfigList []string `json:"workconfiglist"`
-}
-
-type SetConfigAckServData struct {
-	IndexList      []string `json:"indexlist"`
-	WorkConfigList []string `json:"workconfiglist"`
+func init() {
+	RegisterApp(App{
+		Name:     "setconfig",
+		NewState: func() interface{} { return &SetConfig{} },
+		Events: []AppEvent{
+			{
+				Event: EventSetConfigRequest,
+				Name:  "EventSetConfigRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*SetConfig).SetConfigReq(thing)
+				},
+			},
+			{
+				Event: EventSetConfigAck,
+				Name:  "EventSetConfigAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*SetConfig).SetConfigAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (setConfig *SetConfig) GetIndexList() []string {
 	indexs := make([]string, 0, 16)
-	indexs = append(indexs, ThingSN)
-	indexs = append(indexs, Version)
-	indexs = append(indexs, WorkPort)
+	indexs = append(indexs, protocol.ThingSN)
+	indexs = append(indexs, protocol.Version)
+	indexs = append(indexs, protocol.WorkPort)
 	return indexs
 }
 
@@ -70,12 +75,12 @@ func (setConfig *SetConfig) GetWorkConfigList() []string {
 
 func (setConfig *SetConfig) SetConfigReq(thing *Thing) error {
 	if setConfig.setConfigigStatus != SetConfigStop {
-		logger.Error("SetConfig already start!")
+		thing.log.Error("SetConfig already start!")
 		return errors.New("SetConfig already start!")
 	}
 
 	/*	if thing.ThingStatus != ThingRegisteredLogined{
-		logger.Error("Not login or register")
+		thing.log.Error("Not login or register")
 		return errors.New("Not login or register")
 	}*/
 
@@ -86,37 +91,37 @@ func (setConfig *SetConfig) SetConfigReq(thing *Thing) error {
 	}
 
 	//Service data
-	setConfig.setConfigReqServData = &SetConfigReqServData{
+	setConfig.setConfigReqServData = &protocol.SetConfigReqServData{
 		IndexList:      setConfig.GetIndexList(),
 		WorkConfigList: setConfig.GetWorkConfigList(),
 	}
 
-	serviceData, err := json.Marshal(setConfig.setConfigReqServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), setConfig.setConfigReqServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Info("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Info("serviceDataJson =", string(serviceData))
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  SetConfigReqAid,
-		Mid:                  SetConfigReqMid,
+		Aid:                  protocol.SetConfigReqAid,
+		Mid:                  protocol.SetConfigReqMid,
 		MessageCounter:       0,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -126,7 +131,7 @@ func (setConfig *SetConfig) SetConfigReq(thing *Thing) error {
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -134,25 +139,25 @@ func (setConfig *SetConfig) SetConfigReq(thing *Thing) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              thing.GetBid(),
-		MessageFlag:      SetConfigMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.SetConfigMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("Send SetConfigReq Success---")
+	thing.log.Debug("Send SetConfigReq Success---")
 
 	setConfig.setConfigTimeoutTimer = time.NewTimer(SetConfigTimeoutTime)
 
@@ -161,17 +166,17 @@ func (setConfig *SetConfig) SetConfigReq(thing *Thing) error {
 	}
 
 	go func() {
-		logger.Debug("Start timer......")
+		thing.log.Debug("Start timer......")
 		select {
 		case <-setConfig.setConfigTimeoutTimer.C:
-			logger.Warn("Timeout timer coming, setConfig fail!")
+			thing.log.Warn("Timeout timer coming, setConfig fail!")
 			//thing.PushEventChannel(EventSetConfigRequest, nil)
 			setConfig.setConfigigStatus = SetConfigStop
 		case <-setConfig.closeTimeoutTimer:
-			logger.Debug("Close Timeout timer!")
+			thing.log.Debug("Close Timeout timer!")
 		}
 
-		logger.Debug("Timer Close......")
+		thing.log.Debug("Timer Close......")
 	}()
 
 	return nil
@@ -179,25 +184,37 @@ func (setConfig *SetConfig) SetConfigReq(thing *Thing) error {
 
 func (setConfig *SetConfig) SetConfigAck(thing *Thing, ackMsg *message.Message) error {
 	if setConfig.setConfigigStatus != SetConfigReqStatus {
-		logger.Error("Need SetConfigReq!")
+		thing.log.Error("Need SetConfigReq!")
 		return errors.New("Need SetConfigReq!")
 	}
 
 	setConfig.setConfigTimeoutTimer.Stop()
 	setConfig.closeTimeoutTimer <- true
 
-	setConfigAckServData := &SetConfigAckServData{}
-	err := json.Unmarshal(ackMsg.ServData, setConfigAckServData)
+	setConfigAckServData := &protocol.SetConfigAckServData{}
+	err := ackMsg.UnmarshalServData(setConfigAckServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		setConfig.setConfigigStatus = SetConfigStop
-		return err
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
-	logger.Info("setConfigAckServData =", string(ackMsg.ServData))
-	logger.Debug("WorkConfigList =", setConfigAckServData.WorkConfigList)
+	thing.log.Info("setConfigAckServData =", string(ackMsg.ServData))
+	thing.log.Debug("WorkConfigList =", setConfigAckServData.WorkConfigList)
 
 	setConfig.setConfigigStatus = SetConfigStop
 
+	//The ack holds the values the thing now uses
+	config := make(map[string]string)
+	ack := setConfigAckServData
+	for i := 0; i < len(ack.IndexList) && i < len(ack.WorkConfigList); i++ {
+		config[ack.IndexList[i]] = ack.WorkConfigList[i]
+	}
+
+	thing.emitLifecycle(&LifecycleEvent{
+		Event:  LifecycleConfigChanged,
+		Config: config,
+	})
+
 	return nil
 }
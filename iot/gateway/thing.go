@@ -1,10 +1,16 @@
 package gateway
 
 import (
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/metrics"
+	"github.com/harveywangdao/road/protocol"
+	"net"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,28 +31,32 @@ type Thing struct {
 	AddThingConnChan    chan ThingConn
 	DeleteThingConnChan chan ThingConn
 
-	bid     uint32
-	thingid string
-
-	register        Register
-	login           Login
-	relogin         ReLogin
-	heartbeat       Heartbeat
-	readConfig      ReadConfig
-	setConfig       SetConfig
-	thingControl    ThingControl
-	thingInfoUpload ThingInfoUpload
+	bid      uint32
+	thingid  string
+	encoding int
+
+	telemetry TelemetryPublisher
+	lifecycle *Lifecycle
+
+	//log carries remoteaddr, and thingid and bid once they are known.
+	//Only ThingScheduler sets it, with setLog, taskReadTcp reads readLog
+	log     *logger.Logger
+	readLog atomic.Value
+
+	apps map[string]interface{}
 }
 
 type ThingMessage struct {
-	Event int
-	Msg   *message.Message
-	Param interface{}
+	Event   int
+	Msg     *message.Message
+	Param   interface{}
+	Payload interface{}
 }
 
 func (thing *Thing) SetThingIdAndBid(thingid string, bid uint32) error {
 	thing.thingid = thingid
 	thing.bid = bid
+	thing.setLog(thing.log.With("thingid", thingid, "bid", bid))
 
 	ThingConn := ThingConn{
 		ThingID:      thing.thingid,
@@ -57,22 +67,38 @@ func (thing *Thing) SetThingIdAndBid(thingid string, bid uint32) error {
 	return nil
 }
 
+func (thing *Thing) setLog(log *logger.Logger) {
+	thing.log = log
+	thing.readLog.Store(log)
+}
+
 func (thing *Thing) GetBid() uint32 {
 	return thing.bid
 }
 
+func (thing *Thing) IsLogined() bool {
+	return thing.thingid != ""
+}
+
+//Encoding is the service data encoding of the last message from the thing, replies use the same one
+func (thing *Thing) Encoding() int {
+	return thing.encoding
+}
+
 func (thing *Thing) GetAesKey() (string, error) {
 	db, err := database.GetDB(DBName)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return "", err
 	}
 
 	var thingaes128key string
+	start := time.Now()
 	err = db.QueryRow("SELECT thingaes128key FROM thingbaseinfodata_tbl WHERE thingid = ?", thing.thingid).Scan(
 		&thingaes128key)
+	metrics.ObserveDBQuery("GetAesKey", start, err)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return "", err
 	}
 
@@ -86,15 +112,17 @@ func (thing *Thing) thingInit() error {
 func (thing *Thing) CheckAesKeyOutOfDate(bid uint32) bool {
 	db, err := database.GetDB(DBName)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return false
 	}
 
 	var eventcreationtime uint32
+	start := time.Now()
 	err = db.QueryRow("SELECT eventcreationtime FROM thingbaseinfodata_tbl WHERE thingid = ?", thing.thingid).Scan(
 		&eventcreationtime)
+	metrics.ObserveDBQuery("CheckAesKeyOutOfDate", start, err)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return false
 	}
 
@@ -108,18 +136,20 @@ func (thing *Thing) CheckAesKeyOutOfDate(bid uint32) bool {
 func (thing *Thing) getAes128Key(bid uint32) string {
 	db, err := database.GetDB(DBName)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return ""
 	}
 
 	var prethingaes128key, thingaes128key string
 	var eventcreationtime uint32
+	start := time.Now()
 	err = db.QueryRow("SELECT prethingaes128key,thingaes128key,eventcreationtime FROM thingbaseinfodata_tbl WHERE bid = ?", bid).Scan(
 		&prethingaes128key,
 		&thingaes128key,
 		&eventcreationtime)
+	metrics.ObserveDBQuery("getAes128Key", start, err)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return ""
 	}
 
@@ -133,20 +163,22 @@ func (thing *Thing) getAes128Key(bid uint32) string {
 func (thing *Thing) saveTboxState(status int) error {
 	db, err := database.GetDB(DBName)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	stmtUpd, err := db.Prepare("UPDATE thingbaseinfodata_tbl SET status=? where thingid=?")
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 	defer stmtUpd.Close()
 
+	start := time.Now()
 	_, err = stmtUpd.Exec(status, thing.thingid)
+	metrics.ObserveDBQuery("saveTboxState", start, err)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -160,20 +192,43 @@ func (thing *Thing) taskReadTcp() {
 			CallbackFn: thing.getAes128Key,
 		}
 
+		log := thing.readLog.Load().(*logger.Logger)
+
 		errorCode, err := msg.RecvMessage()
 		if err != nil {
-			logger.Error(err)
+			log.Error(err)
 			if errorCode == message.ErrorCodeConnectionBreak {
 				thing.PushEventChannel(EventConnectionClosed, nil) /*need go out*/
 				return
+			} else if errorCode == message.ErrorCodeChecksum || errorCode == message.ErrorCodeDecrypt {
+				thing.rejectFrame(log, &msg, message.GetResultByErrorCode(errorCode))
+				continue
 			} else {
 				continue
 			}
 		}
 
+		atomic.AddUint64(&receivedMessages, 1)
+
+		if !protocol.IsSupportedVersion(msg.MesHeader.ServiceVersion) {
+			log.Error("Unsupported service version", msg.MesHeader.ServiceVersion)
+			thing.rejectFrame(log, &msg, message.ResultBadVersion)
+			continue
+		}
+
+		event := GetEventTypeByAidMid(msg.DisPatch.Aid, msg.DisPatch.Mid)
+		metrics.MessagesReceived.WithLabelValues(GetEventAppName(event), fmt.Sprintf("0x%02X", msg.DisPatch.Aid)).Inc()
+
+		payload, err := decodeEventPayload(event, &msg)
+		if err != nil {
+			thing.rejectFrame(log, &msg, message.ResultBadServiceData)
+			continue
+		}
+
 		tm := ThingMessage{
-			Event: GetEventTypeByAidMid(msg.DisPatch.Aid, msg.DisPatch.Mid),
-			Msg:   &msg,
+			Event:   event,
+			Msg:     &msg,
+			Payload: payload,
 		}
 
 		thing.ThingMsgChan <- tm
@@ -183,6 +238,10 @@ func (thing *Thing) taskReadTcp() {
 func (thing *Thing) destoryThing() error {
 	thing.saveTboxState(ThingRegisteredUnLogin)
 
+	if thing.thingid != "" {
+		thing.emitLifecycle(&LifecycleEvent{Event: LifecycleOffline})
+	}
+
 	ThingConn := ThingConn{
 		ThingID:      thing.thingid,
 		ThingService: thing,
@@ -192,83 +251,94 @@ func (thing *Thing) destoryThing() error {
 	return nil
 }
 
-func (thing *Thing) eventDispatcher(thingMsg ThingMessage) error {
-	logger.Debug("event =", GetEventName(thingMsg.Event))
-
-	switch thingMsg.Event {
-	case EventConnectionClosed:
-		thing.destoryThing()
-		return errors.New("Connection closed!")
-
-	case RegisterReqEventMessage:
-		thing.PushEventChannel(RegisterAckEventMessage, thingMsg.Msg)
-
-	case RegisterAckEventMessage:
-		thing.register.RegisterACK(thing.Conn, thingMsg.Msg)
-
-	case EventLoginRequest:
-		thing.login.LoginRequest(thing, thingMsg.Msg)
-
-	case EventLoginChallenge:
-		thing.login.LoginChallenge(thing, thingMsg.Msg)
-
-	case EventLoginResponse:
-		thing.login.LoginResponse(thing, thingMsg.Msg)
-
-	case EventLoginSuccess:
-		thing.login.LoginSuccess(thing, thingMsg.Msg)
-		//thing.PushEventChannel(EventReLoginRequest, nil)
-		//thing.PushEventChannel(EventReadConfigRequest, nil)
-		//thing.PushEventChannel(EventSetConfigRequest, nil)
-		//thing.PushEventChannel2(EventRemoteOperationRequest, nil, "lock")
-
-	case EventLoginFailure:
-		thing.login.LoginFailure(thing, thingMsg.Msg)
+func (thing *Thing) sendErrorReply(reqMsg *message.Message, result uint8) error {
+	msg := message.Message{
+		Connection: thing.Conn,
+	}
 
-	case EventReLoginRequest:
-		thing.relogin.ReLoginReq(thing)
+	err := msg.SendErrorReply(reqMsg, result)
+	if err != nil {
+		thing.log.Error(err)
+		return err
+	}
 
-	case EventReLoginAck:
-		thing.relogin.ReLoginAck(thing, thingMsg.Msg)
+	return nil
+}
 
-	case EventHeartbeatRequest:
-		thing.heartbeat.HeartbeatReq(thing, thingMsg.Msg)
+//rejectFrame answers a frame the gateway can not handle with an error reply
+func (thing *Thing) rejectFrame(log *logger.Logger, msg *message.Message, result uint8) {
+	if log.Tracing() {
+		traceFrame(log, GetEventTypeByAidMid(msg.DisPatch.Aid, msg.DisPatch.Mid), msg, result)
+	}
 
-	case EventHeartbeatAck:
-		thing.heartbeat.HeartbeatAck(thing, thingMsg.Msg)
+	thing.PushEventChannel2(EventErrorReply, msg, result)
+}
 
-	case EventReadConfigRequest:
-		thing.readConfig.ReadConfigReq(thing)
+//traceFrame logs a frame from the thing, so only traced things get here. The service data of a rejected frame,
+//result is no message.ResultSuccess, is logged in hex as it came, it may be encrypted or cut
+func traceFrame(log *logger.Logger, event int, msg *message.Message, result uint8) {
+	servData := hex.EncodeToString(msg.ServData)
+	if result == message.ResultSuccess && msg.Encoding() == protocol.EncodingJSON {
+		servData = string(msg.ServData)
+	}
 
-	case EventReadConfigAck:
-		thing.readConfig.ReadConfigAck(thing, thingMsg.Msg)
+	log = log.With(
+		"event", GetEventName(event),
+		"header", fmt.Sprintf("%+v", msg.MesHeader),
+		"dispatch", fmt.Sprintf("%+v", msg.DisPatch),
+		"servdata", servData,
+	)
 
-	case EventSetConfigRequest:
-		thing.setConfig.SetConfigReq(thing)
+	if result != message.ResultSuccess {
+		log.With("result", message.ResultName(result)).Debug("Frame rejected")
+		return
+	}
 
-	case EventSetConfigAck:
-		thing.setConfig.SetConfigAck(thing, thingMsg.Msg)
+	log.Debug("Frame received")
+}
 
-	case EventRemoteOperationRequest:
-		thing.thingControl.RemoteOperationReq(thing, thingMsg.Param.(string))
+func (thing *Thing) eventDispatcher(thingMsg ThingMessage) error {
+	thing.log.Debug("event =", GetEventName(thingMsg.Event))
 
-	case EventDispatcherAckMessage:
-		thing.thingControl.DispatcherAckMessage(thing, thingMsg.Msg)
+	var err error
 
-	case EventRemoteOperationEnd:
-		thing.thingControl.RemoteOperationEnd(thing, thingMsg.Msg)
+	start := time.Now()
+	metrics.ThingQueueLength.Observe(float64(len(thing.ThingMsgChan)))
+	defer func() {
+		metrics.EventsHandled.WithLabelValues(GetEventAppName(thingMsg.Event), GetEventName(thingMsg.Event), metrics.Result(err)).Observe(time.Since(start).Seconds())
+	}()
 
-	case EventRemoteOperationAck:
-		thing.thingControl.RemoteOperationAck(thing, thingMsg.Msg)
+	if thingMsg.Msg != nil && thing.log.Tracing() {
+		traceFrame(thing.log, thingMsg.Event, thingMsg.Msg, message.ResultSuccess)
+	}
 
-	case EventThingInfoUpload:
-		thing.thingInfoUpload.ThingInfoUploadReq(thing, thingMsg.Msg)
+	switch thingMsg.Event {
+	case EventConnectionClosed:
+		thing.destoryThing()
+		return errors.New("Connection closed!")
 
-	case EventThingInfoUploadAck:
-		thing.thingInfoUpload.ThingInfoUploadAck(thing, thingMsg.Msg)
+	case EventErrorReply:
+		err = message.NewResultError(thingMsg.Param.(uint8), errors.New("Can not parse message!"))
 
 	default:
-		logger.Error("Unknown event!")
+		if app, e, ok := apps.Lookup(thingMsg.Event); ok {
+			if thingMsg.Msg != nil {
+				thing.encoding = thingMsg.Msg.Encoding()
+			}
+			err = e.Handler(thing.apps[app.Name], thing, thingMsg)
+		} else {
+			thing.log.Error("Unknown event!")
+			if thingMsg.Msg != nil && thingMsg.Msg.DisPatch.Aid != protocol.ErrorReplyAid {
+				err = message.NewResultError(message.ResultUnknownAidMid, errors.New("Unknown event!"))
+			}
+		}
+	}
+
+	//Only messages from the thing get an error reply, a failed request started by us has nobody to answer
+	if err != nil && thingMsg.Msg != nil {
+		if result, ok := message.GetResult(err); ok {
+			thing.sendErrorReply(thingMsg.Msg, result)
+		}
 	}
 
 	return nil
@@ -279,7 +349,7 @@ func (thing *Thing) ThingScheduler() error {
 
 	err := thing.thingInit()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -288,15 +358,15 @@ func (thing *Thing) ThingScheduler() error {
 	for {
 		select {
 		case thingMsg := <-thing.ThingMsgChan:
-			logger.Debug("thingMsg =", thingMsg)
+			thing.log.Debug("thingMsg =", thingMsg)
 			err = thing.eventDispatcher(thingMsg)
 			if err != nil {
-				logger.Error(err)
+				thing.log.Error(err)
 				return err
 			}
 
 		case <-time.After(time.Second * 60):
-			logger.Debug("Timeout!")
+			thing.log.Debug("Timeout!")
 		}
 	}
 
@@ -322,16 +392,40 @@ func (thing *Thing) PushEventChannel2(event int, msg *message.Message, param int
 	thing.ThingMsgChan <- tm
 }
 
+//TryPushEventChannel2 is PushEventChannel2 for callers that must not wait on a busy thing
+func (thing *Thing) TryPushEventChannel2(event int, msg *message.Message, param interface{}) bool {
+	tm := ThingMessage{
+		Event: event,
+		Msg:   msg,
+		Param: param,
+	}
+
+	select {
+	case thing.ThingMsgChan <- tm:
+		return true
+	default:
+		return false
+	}
+}
+
 func (thing *Thing) thingDestory() error {
 	return nil
 }
 
-func NewThing(msgChan chan ThingMessage, conn message.MessageConn, addThingConnChan, delThingConnChan chan ThingConn) (*Thing, error) {
+func NewThing(msgChan chan ThingMessage, conn message.MessageConn, addThingConnChan, delThingConnChan chan ThingConn, telemetry TelemetryPublisher, lifecycle *Lifecycle) (*Thing, error) {
 	thing := Thing{}
 	thing.Conn = conn
 	thing.ThingMsgChan = msgChan
+	thing.telemetry = telemetry
+	thing.lifecycle = lifecycle
 	thing.AddThingConnChan = addThingConnChan
 	thing.DeleteThingConnChan = delThingConnChan
+	thing.apps = newAppStates()
+
+	thing.setLog(logger.With())
+	if c, ok := conn.(net.Conn); ok {
+		thing.setLog(logger.With("remoteaddr", c.RemoteAddr().String()))
+	}
 
 	return &thing, nil
 }
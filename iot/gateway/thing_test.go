@@ -0,0 +1,141 @@
+package gateway
+
+import (
+	"bytes"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
+	"github.com/harveywangdao/road/util"
+	"os"
+	"strings"
+	"testing"
+)
+
+//frameConn is a thing that sends frames and hangs up
+type frameConn struct {
+	frames *bytes.Reader
+	sent   bytes.Buffer
+}
+
+func (c *frameConn) Read(b []byte) (int, error) {
+	return c.frames.Read(b)
+}
+
+func (c *frameConn) Write(b []byte) (int, error) {
+	return c.sent.Write(b)
+}
+
+//frame builds a frame from the thing, with a wrong checksum when badChecksum is true
+func frame(t *testing.T, version uint8, securityVersion uint8, aid, mid uint8, servData []byte, badChecksum bool) []byte {
+	header, err := util.StructToByteSlice(message.MessageHeader{
+		FixHeader:        message.MessageHeaderID,
+		ServiceDataCheck: util.DataXOR(servData),
+		ServiceVersion:   version,
+		Bid:              testBid,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatch, err := util.StructToByteSlice(message.DispatchData{
+		EventCreationTime: 100,
+		Aid:               aid,
+		Mid:               mid,
+		ServiceDataLength: uint16(len(servData)),
+		SecurityVersion:   securityVersion,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := append(append(header, dispatch...), servData...)
+	checkSum := util.DataXOR(data)
+	if badChecksum {
+		checkSum++
+	}
+
+	return append(data, checkSum)
+}
+
+//readFrames runs taskReadTcp over frames and returns the results of the error replies and what was logged
+func readFrames(t *testing.T, traced bool, frames ...[]byte) ([]uint8, string) {
+	//SetHandlers is not safe while the timers of other tests log, the output of Console is
+	var out bytes.Buffer
+	logger.Console.SetOutput(&out)
+	defer logger.Console.SetOutput(os.Stderr)
+
+	level := logger.GetLevel()
+	logger.SetLevel(logger.ERROR)
+	defer logger.SetLevel(level)
+
+	if traced {
+		logger.TraceOn("thingid", testThingId, 0)
+		defer logger.TraceOff("thingid", testThingId)
+	}
+
+	conn := &frameConn{frames: bytes.NewReader(bytes.Join(frames, nil))}
+	thing, err := NewThing(make(chan ThingMessage, len(frames)+1), conn, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thing.setLog(logger.With("thingid", testThingId))
+
+	thing.taskReadTcp()
+	logger.Console.SetOutput(os.Stderr)
+
+	results := make([]uint8, 0)
+	for len(thing.ThingMsgChan) > 0 {
+		thingMsg := <-thing.ThingMsgChan
+		if thingMsg.Event == EventErrorReply {
+			results = append(results, thingMsg.Param.(uint8))
+		}
+	}
+
+	return results, out.String()
+}
+
+var rejectedFrameTests = []struct {
+	name   string
+	frame  func(t *testing.T) []byte
+	result uint8
+}{
+	{"checksum", func(t *testing.T) []byte {
+		return frame(t, protocol.ServiceVersion, message.Encrypt_No, protocol.HeartbeatReqAid, protocol.HeartbeatReqMid, []byte("{}"), true)
+	}, message.ResultBadChecksum},
+	{"decrypt", func(t *testing.T) []byte {
+		return frame(t, protocol.ServiceVersion, message.Encrypt_Base64, protocol.HeartbeatReqAid, protocol.HeartbeatReqMid, []byte("{}"), false)
+	}, message.ResultDecryptFailure},
+	{"version", func(t *testing.T) []byte {
+		return frame(t, 0xFF, message.Encrypt_No, protocol.HeartbeatReqAid, protocol.HeartbeatReqMid, []byte("{}"), false)
+	}, message.ResultBadVersion},
+	{"service data", func(t *testing.T) []byte {
+		return frame(t, protocol.ServiceVersion, message.Encrypt_No, protocol.ThingInfoBatchUploadAid, protocol.ThingInfoBatchUploadMid, []byte("not json"), false)
+	}, message.ResultBadServiceData},
+}
+
+//Frames rejected before eventDispatcher are traced too
+func TestTraceRejectedFrames(t *testing.T) {
+	for _, tt := range rejectedFrameTests {
+		results, out := readFrames(t, true, tt.frame(t))
+		if len(results) != 1 || results[0] != tt.result {
+			t.Errorf("%s: error replies %v, want %s", tt.name, results, message.ResultName(tt.result))
+		}
+
+		if !strings.Contains(out, "Frame rejected") || !strings.Contains(out, message.ResultName(tt.result)) {
+			t.Errorf("%s: no trace of the rejected frame in %q", tt.name, out)
+		}
+	}
+}
+
+func TestUntracedRejectedFrames(t *testing.T) {
+	for _, tt := range rejectedFrameTests {
+		results, out := readFrames(t, false, tt.frame(t))
+		if len(results) != 1 || results[0] != tt.result {
+			t.Errorf("%s: error replies %v, want %s", tt.name, results, message.ResultName(tt.result))
+		}
+
+		if strings.Contains(out, "Frame rejected") {
+			t.Errorf("%s: a frame of an untraced thing is traced: %q", tt.name, out)
+		}
+	}
+}
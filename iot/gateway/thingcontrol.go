@@ -1,32 +1,15 @@
 package gateway
 
 import (
-	"encoding/json"
 	"errors"
-	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	ThingControlTimeoutTime = 2 * time.Second
-
-	ThingControlMessageFlag = 0x1
-
-	RemoteOperationRequestAid = 0xF1
-	RemoteOperationRequestMid = 0x1
-
-	DispatcherAckMessageAid = 0xF1
-	DispatcherAckMessageMid = 0x2
-
-	RemoteOperationEndAid = 0xF1
-	RemoteOperationEndMid = 0x3
-
-	RemoteOperationAckAid = 0xF1
-	RemoteOperationAckMid = 0x4
-
-	RemoteOperationSuccess = 1
 )
 
 const (
@@ -37,6 +20,13 @@ const (
 	RemoteOperationAckStatus
 )
 
+const (
+	EventRemoteOperationRequest = protocol.MsgRemoteOperationRequest
+	EventDispatcherAckMessage   = protocol.MsgDispatcherAckMessage
+	EventRemoteOperationEnd     = protocol.MsgRemoteOperationEnd
+	EventRemoteOperationAck     = protocol.MsgRemoteOperationAck
+)
+
 type ThingControl struct {
 	timeoutTimer      *time.Timer
 	closeTimeoutTimer chan bool
@@ -45,72 +35,46 @@ type ThingControl struct {
 	operation          uint16
 }
 
-type RemoteOperationReqServData struct {
-	Operation          uint16 `json:"operation"`
-	OperationParameter int64  `json:"operationparameter"`
-}
-
-type DispatcherAckMessageServData struct {
-	Operation uint16 `json:"operation"`
-}
-
-type RemoteOperationEndServData struct {
-	Operation uint16 `json:"operation"`
-	Parameter int64  `json:"parameter"`
-}
-
-type RemoteOperationAckServData struct {
-	Operation uint16 `json:"operation"`
-	Status    byte   `json:"status"`
-	Parameter int64  `json:"parameter"`
-}
-
-const (
-	CentralLockOpen     = 0x00F1
-	CentralLockClose    = 0x00F2
-	WindowClose         = 0x00F4
-	WhistleAndFlash     = 0x00F5
-	AirConditionerOpen  = 0x00F6
-	AirConditionerClose = 0x00F7
-	EngineStart         = 0x00F8
-	EngineStop          = 0x00F9
-	SkyWindowOpen       = 0x00FA
-	SkyWindowClose      = 0x00FB
-	FrontDefrostStart   = 0x0001
-	FrontDefrostStop    = 0x0002
-	BackDefrostStart    = 0x0003
-	BackDefrostStop     = 0x0004
-	SeatheatStart       = 0x0005
-	SeatheatStop        = 0x0006
-	TwoFlashStart       = 0x0007
-	VehicleDefence      = 0x0008
-	VehicleUndefence    = 0x0009
-	EngineLock          = 0x000A
-	EngineUnlock        = 0x000B
-
-	UnknownRemoteOparetion = 0xFFFF
-)
-
-func (vc *ThingControl) convertOperation(op string) (uint16, error) {
-	switch op {
-	case "lock":
-		return EngineLock, nil
-	case "unlock":
-		return EngineUnlock, nil
-	case "defence":
-		return VehicleDefence, nil
-	case "undefence":
-		return VehicleUndefence, nil
-	default:
-		logger.Error("Unknown remote operation!")
-	}
-
-	return UnknownRemoteOparetion, errors.New("Unknown remote operation!")
+func init() {
+	RegisterApp(App{
+		Name:     "thingcontrol",
+		NewState: func() interface{} { return &ThingControl{} },
+		Events: []AppEvent{
+			{
+				Event: EventRemoteOperationRequest,
+				Name:  "EventRemoteOperationRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).RemoteOperationReq(thing, thingMsg.Param.(string))
+				},
+			},
+			{
+				Event: EventDispatcherAckMessage,
+				Name:  "EventDispatcherAckMessage",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).DispatcherAckMessage(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventRemoteOperationEnd,
+				Name:  "EventRemoteOperationEnd",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).RemoteOperationEnd(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventRemoteOperationAck,
+				Name:  "EventRemoteOperationAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).RemoteOperationAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (vc *ThingControl) RemoteOperationReq(thing *Thing, op string) error {
 	if vc.thingControlStatus != ThingControlStop {
-		logger.Error("ThingControl already start!")
+		thing.log.Error("ThingControl already start!")
 		return errors.New("ThingControl already start!")
 	}
 
@@ -121,46 +85,46 @@ func (vc *ThingControl) RemoteOperationReq(thing *Thing, op string) error {
 	}
 
 	//Service data
-	logger.Info("Operation =", op)
-	operationValue, err := vc.convertOperation(op)
+	thing.log.Info("Operation =", op)
+	operationValue, err := protocol.ConvertOperation(op)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	vc.operation = operationValue
 
-	remoteOperationReqServData := RemoteOperationReqServData{
+	remoteOperationReqServData := protocol.RemoteOperationReqServData{
 		Operation:          vc.operation,
 		OperationParameter: 0, //need fix
 	}
 
-	serviceData, err := json.Marshal(&remoteOperationReqServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &remoteOperationReqServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Debug("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceDataJson =", string(serviceData))
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  RemoteOperationRequestAid,
-		Mid:                  RemoteOperationRequestMid,
+		Aid:                  protocol.RemoteOperationRequestAid,
+		Mid:                  protocol.RemoteOperationRequestMid,
 		MessageCounter:       0,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -170,7 +134,7 @@ func (vc *ThingControl) RemoteOperationReq(thing *Thing, op string) error {
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -178,25 +142,25 @@ func (vc *ThingControl) RemoteOperationReq(thing *Thing, op string) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              thing.GetBid(),
-		MessageFlag:      ThingControlMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingControlMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("Send RemoteOperationReq Success---")
+	thing.log.Debug("Send RemoteOperationReq Success---")
 
 	vc.timeoutTimer = time.NewTimer(ThingControlTimeoutTime)
 
@@ -205,17 +169,17 @@ func (vc *ThingControl) RemoteOperationReq(thing *Thing, op string) error {
 	}
 
 	go func() {
-		logger.Debug("Start timer......")
+		thing.log.Debug("Start timer......")
 		select {
 		case <-vc.timeoutTimer.C:
-			logger.Error("Timeout timer coming, vc fail!")
+			thing.log.Error("Timeout timer coming, vc fail!")
 			//thing.PushEventChannel(EventThingControlRequest, nil)
 			vc.thingControlStatus = ThingControlStop
 		case <-vc.closeTimeoutTimer:
-			logger.Debug("Close Timeout timer!")
+			thing.log.Debug("Close Timeout timer!")
 		}
 
-		logger.Debug("Timer Close......")
+		thing.log.Debug("Timer Close......")
 	}()
 
 	return nil
@@ -223,7 +187,7 @@ func (vc *ThingControl) RemoteOperationReq(thing *Thing, op string) error {
 
 func (vc *ThingControl) DispatcherAckMessage(thing *Thing, ackMsg *message.Message) error {
 	if vc.thingControlStatus != RemoteOperationReqStatus {
-		logger.Error("Need RemoteOperationReqStatus!")
+		thing.log.Error("Need RemoteOperationReqStatus!")
 		return errors.New("Need RemoteOperationReqStatus!")
 	}
 
@@ -231,14 +195,14 @@ func (vc *ThingControl) DispatcherAckMessage(thing *Thing, ackMsg *message.Messa
 
 	vc.thingControlStatus = DispatcherAckMessageStatus
 
-	dispatcherAckMessageServData := &DispatcherAckMessageServData{}
-	err := json.Unmarshal(ackMsg.ServData, dispatcherAckMessageServData)
+	dispatcherAckMessageServData := &protocol.DispatcherAckMessageServData{}
+	err := ackMsg.UnmarshalServData(dispatcherAckMessageServData)
 	if err != nil {
-		logger.Error(err)
-		return err
+		thing.log.Error(err)
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
-	logger.Debug("dispatcherAckMessageServData =", string(ackMsg.ServData))
+	thing.log.Debug("dispatcherAckMessageServData =", string(ackMsg.ServData))
 
 	if dispatcherAckMessageServData.Operation != vc.operation {
 		vc.closeTimeoutTimer <- true
@@ -252,17 +216,17 @@ func (vc *ThingControl) DispatcherAckMessage(thing *Thing, ackMsg *message.Messa
 
 func (vc *ThingControl) RemoteOperationEnd(thing *Thing, endMsg *message.Message) error {
 	if vc.thingControlStatus != DispatcherAckMessageStatus {
-		logger.Error("Need DispatcherAckMessageStatus!")
+		thing.log.Error("Need DispatcherAckMessageStatus!")
 		return errors.New("Need DispatcherAckMessageStatus!")
 	}
 
 	vc.thingControlStatus = RemoteOperationEndStatus
 
-	remoteOperationEndServData := &RemoteOperationEndServData{}
-	err := json.Unmarshal(endMsg.ServData, remoteOperationEndServData)
+	remoteOperationEndServData := &protocol.RemoteOperationEndServData{}
+	err := endMsg.UnmarshalServData(remoteOperationEndServData)
 	if err != nil {
-		logger.Error(err)
-		return err
+		thing.log.Error(err)
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
 	if remoteOperationEndServData.Operation != vc.operation {
@@ -285,36 +249,36 @@ func (vc *ThingControl) dispatcherAckMessage1(thing *Thing, respMsg *message.Mes
 	}
 
 	//Service data
-	dispatcherAckMessageServData := DispatcherAckMessageServData{
+	dispatcherAckMessageServData := protocol.DispatcherAckMessageServData{
 		Operation: vc.operation,
 	}
 
-	serviceData, err := json.Marshal(&dispatcherAckMessageServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &dispatcherAckMessageServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("serviceData =", serviceData)
-	logger.Debug("serviceDataJson =", string(serviceData))
+	thing.log.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceDataJson =", string(serviceData))
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    respMsg.DisPatch.EventCreationTime,
-		Aid:                  DispatcherAckMessageAid,
-		Mid:                  DispatcherAckMessageMid,
+		Aid:                  protocol.DispatcherAckMessageAid,
+		Mid:                  protocol.DispatcherAckMessageMid,
 		MessageCounter:       respMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -324,7 +288,7 @@ func (vc *ThingControl) dispatcherAckMessage1(thing *Thing, respMsg *message.Mes
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -332,42 +296,42 @@ func (vc *ThingControl) dispatcherAckMessage1(thing *Thing, respMsg *message.Mes
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              respMsg.MesHeader.Bid,
-		MessageFlag:      ThingControlMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingControlMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("Send DispatcherAckMessage Success---")
+	thing.log.Debug("Send DispatcherAckMessage Success---")
 
 	return nil
 }
 
 func (vc *ThingControl) RemoteOperationAck(thing *Thing, ackMsg *message.Message) error {
 	if vc.thingControlStatus != RemoteOperationEndStatus {
-		logger.Error("Need RemoteOperationEndStatus!")
+		thing.log.Error("Need RemoteOperationEndStatus!")
 		return errors.New("Need RemoteOperationEndStatus!")
 	}
 
 	vc.thingControlStatus = RemoteOperationAckStatus
 
-	remoteOperationAckServData := &RemoteOperationAckServData{}
-	err := json.Unmarshal(ackMsg.ServData, remoteOperationAckServData)
+	remoteOperationAckServData := &protocol.RemoteOperationAckServData{}
+	err := ackMsg.UnmarshalServData(remoteOperationAckServData)
 	if err != nil {
-		logger.Error(err)
-		return err
+		thing.log.Error(err)
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
 
 	if remoteOperationAckServData.Operation != vc.operation {
@@ -377,7 +341,7 @@ func (vc *ThingControl) RemoteOperationAck(thing *Thing, ackMsg *message.Message
 		return errors.New("operation not right!")
 	}
 
-	if remoteOperationAckServData.Status != RemoteOperationSuccess {
+	if remoteOperationAckServData.Status != protocol.RemoteOperationSuccess {
 		vc.closeTimeoutTimer <- true
 		vc.thingControlStatus = ThingControlStop
 
@@ -1,24 +1,17 @@
 package gateway
 
 import (
-	"encoding/json"
 	"errors"
-	"github.com/harveywangdao/road/database/mongo"
-	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/protocol"
+	"github.com/harveywangdao/road/telemetry"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	ThingInfoUploadTimeoutTime = 5 * time.Second
-	ThingInfoUploadMessageFlag = 0x1
-
-	ThingInfoUploadAid = 0xF5
-	ThingInfoUploadMid = 0x4
-
-	ThingInfoUploadAckAid = 0xF5
-	ThingInfoUploadAckMid = 0x2
 )
 
 const (
@@ -26,6 +19,13 @@ const (
 	ThingInfoUploadStatus
 )
 
+const (
+	EventThingInfoUpload         = protocol.MsgThingInfoUpload
+	EventThingInfoUploadAck      = protocol.MsgThingInfoUploadAck
+	EventThingInfoBatchUpload    = protocol.MsgThingInfoBatchUpload
+	EventThingInfoBatchUploadAck = protocol.MsgThingInfoBatchUploadAck
+)
+
 type ThingInfoUpload struct {
 	thingInfoUploadTimer      *time.Timer
 	closeThingInfoUploadTimer chan bool
@@ -33,75 +33,150 @@ type ThingInfoUpload struct {
 	thingInfoUploadStatus int
 }
 
+func init() {
+	RegisterApp(App{
+		Name:     "thinginfoupload",
+		NewState: func() interface{} { return &ThingInfoUpload{} },
+		Events: []AppEvent{
+			{
+				Event: EventThingInfoUpload,
+				Name:  "EventThingInfoUpload",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingInfoUpload).ThingInfoUploadReq(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventThingInfoUploadAck,
+				Name:  "EventThingInfoUploadAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingInfoUpload).ThingInfoUploadAck(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event:   EventThingInfoBatchUpload,
+				Name:    "EventThingInfoBatchUpload",
+				Payload: func() interface{} { return &protocol.ThingInforBatch{} },
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingInfoUpload).ThingInfoBatchUploadReq(thing, thingMsg.Msg, thingMsg.Payload.(*protocol.ThingInforBatch))
+				},
+			},
+			{
+				Event: EventThingInfoBatchUploadAck,
+				Name:  "EventThingInfoBatchUploadAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					ack, _ := thingMsg.Param.(*protocol.ThingInfoBatchAckServData)
+					return state.(*ThingInfoUpload).ThingInfoBatchUploadAck(thing, thingMsg.Msg, ack)
+				},
+			},
+		},
+	})
+}
+
 func (upload *ThingInfoUpload) ThingInfoUploadReq(thing *Thing, reqMsg *message.Message) error {
 	if upload.thingInfoUploadStatus != ThingInfoUploadStop {
-		logger.Error("ThingInfoUpload already start!")
-		return errors.New("ThingInfoUpload already start!")
+		thing.log.Error("ThingInfoUpload already start!")
+		return message.NewResultError(message.ResultBusy, errors.New("ThingInfoUpload already start!"))
 	}
 
-	upload.thingInfoUploadStatus = ThingInfoUploadStatus
-
-	thingInfor := &ThingInfor{}
-	err := json.Unmarshal(reqMsg.ServData, thingInfor)
-	if err != nil {
-		logger.Error(err)
-		return err
+	if !thing.IsLogined() {
+		thing.log.Error("Not login or register")
+		return message.NewResultError(message.ResultNotLogin, errors.New("Not login or register"))
 	}
 
-	logger.Info("thingInfor =", string(reqMsg.ServData))
+	upload.thingInfoUploadStatus = ThingInfoUploadStatus
 
-	session, err := mongo.CloneMgoSession()
+	thingInfor := &protocol.ThingInfor{}
+	err := reqMsg.UnmarshalServData(thingInfor)
 	if err != nil {
-		logger.Error(err)
-		return err
+		thing.log.Error(err)
+		upload.thingInfoUploadStatus = ThingInfoUploadStop
+		return message.NewResultError(message.ResultBadServiceData, err)
 	}
-	defer session.Close()
 
-	c := session.DB("iotmgodb").C("ThingInforData")
-	err = c.Insert(thingInfor)
+	err = upload.publish(thing, thingInfor)
 	if err != nil {
-		logger.Error(err)
-		return err
+		thing.log.Error(err)
+		upload.thingInfoUploadStatus = ThingInfoUploadStop
+		return message.NewResultError(message.ResultInternalError, err)
 	}
 
-	logger.Info("Save to mongo!")
+	thing.log.Info("Publish telemetry!")
 
 	thing.PushEventChannel(EventThingInfoUploadAck, reqMsg)
 
 	return nil
 }
 
+//publish returns once kafka has the samples, the thing is acked only after that.
+//The samples of one upload share a correlation id
+func (upload *ThingInfoUpload) publish(thing *Thing, samples ...*protocol.ThingInfor) error {
+	if thing.telemetry == nil {
+		return errors.New("Telemetry queue unavailable!")
+	}
+
+	receiveTime := uint32(time.Now().Unix())
+	correlationId := msgqueue.NewCorrelationId()
+
+	datas := make([][]byte, 0, len(samples))
+	for _, sample := range samples {
+		record := &telemetry.Record{
+			ThingId:     thing.thingid,
+			Bid:         thing.bid,
+			ReceiveTime: receiveTime,
+			Sample:      *sample,
+		}
+
+		env, err := msgqueue.NewEnvelope(msgqueue.MessageTypeTelemetry, thing.thingid, correlationId, record)
+		if err != nil {
+			thing.log.Error(err)
+			return err
+		}
+
+		data, err := env.Marshal()
+		if err != nil {
+			thing.log.Error(err)
+			return err
+		}
+
+		datas = append(datas, data)
+	}
+
+	return thing.telemetry.Publish(thing.thingid, datas...)
+}
+
 func (upload *ThingInfoUpload) ThingInfoUploadAck(thing *Thing, reqMsg *message.Message) error {
 	if upload.thingInfoUploadStatus != ThingInfoUploadStatus {
-		logger.Error("Need ThingInfoUpload!")
+		thing.log.Error("Need ThingInfoUpload!")
 		return errors.New("Need ThingInfoUpload!")
 	}
 
+	upload.thingInfoUploadStatus = ThingInfoUploadStop
+
 	msg := message.Message{
 		Connection: thing.Conn,
 	}
 
 	//Service data
 	serviceData := make([]byte, 1)
-	logger.Debug("serviceData =", serviceData)
+	thing.log.Debug("serviceData =", serviceData)
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
-		Aid:                  ThingInfoUploadAckAid,
-		Mid:                  ThingInfoUploadAckMid,
+		Aid:                  protocol.ThingInfoUploadAckAid,
+		Mid:                  protocol.ThingInfoUploadAckMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -111,7 +186,7 @@ func (upload *ThingInfoUpload) ThingInfoUploadAck(thing *Thing, reqMsg *message.
 
 	dispatchData, err := util.StructToByteSlice(dd)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
@@ -119,27 +194,142 @@ func (upload *ThingInfoUpload) ThingInfoUploadAck(thing *Thing, reqMsg *message.
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              reqMsg.MesHeader.Bid,
-		MessageFlag:      ThingInfoUploadMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingInfoUploadMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
 	//Send message
 	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
 	if err != nil {
-		logger.Error(err)
+		thing.log.Error(err)
 		return err
 	}
 
-	logger.Debug("Send ThingInfoUploadAck Success---")
+	thing.log.Debug("Send ThingInfoUploadAck Success---")
+
+	return nil
+}
+
+//ThingInfoBatchUploadReq publishes every sample of the batch, resent samples included, the repository keeps one
+//document per thingid and sample time. The ack counts the samples published, the thing drops that many
+func (upload *ThingInfoUpload) ThingInfoBatchUploadReq(thing *Thing, reqMsg *message.Message, batch *protocol.ThingInforBatch) error {
+	if upload.thingInfoUploadStatus != ThingInfoUploadStop {
+		thing.log.Error("ThingInfoUpload already start!")
+		return message.NewResultError(message.ResultBusy, errors.New("ThingInfoUpload already start!"))
+	}
+
+	if !thing.IsLogined() {
+		thing.log.Error("Not login or register")
+		return message.NewResultError(message.ResultNotLogin, errors.New("Not login or register"))
+	}
+
+	upload.thingInfoUploadStatus = ThingInfoUploadStatus
+
+	samples := make([]*protocol.ThingInfor, 0, len(batch.Samples))
+	for i := range batch.Samples {
+		samples = append(samples, &batch.Samples[i])
+	}
+
+	ack := &protocol.ThingInfoBatchAckServData{}
+
+	if len(samples) > 0 {
+		err := upload.publish(thing, samples...)
+		if err != nil {
+			thing.log.Error(err)
+			upload.thingInfoUploadStatus = ThingInfoUploadStop
+			return message.NewResultError(message.ResultInternalError, err)
+		}
+
+		ack.LastTime = samples[len(samples)-1].Time
+		ack.Count = uint16(len(samples))
+	}
+
+	thing.log.Info("Publish", len(samples), "samples!")
+
+	thing.PushEventChannel2(EventThingInfoBatchUploadAck, reqMsg, ack)
+
+	return nil
+}
+
+func (upload *ThingInfoUpload) ThingInfoBatchUploadAck(thing *Thing, reqMsg *message.Message, ack *protocol.ThingInfoBatchAckServData) error {
+	if upload.thingInfoUploadStatus != ThingInfoUploadStatus || ack == nil {
+		thing.log.Error("Need ThingInfoBatchUpload!")
+		return errors.New("Need ThingInfoBatchUpload!")
+	}
 
 	upload.thingInfoUploadStatus = ThingInfoUploadStop
 
+	msg := message.Message{
+		Connection: thing.Conn,
+	}
+
+	//Service data
+	serviceData, err := protocol.Marshal(thing.Encoding(), ack)
+	if err != nil {
+		thing.log.Error(err)
+		return err
+	}
+
+	aesKey, err := thing.GetAesKey()
+	if err != nil {
+		thing.log.Error(err)
+		return err
+	}
+
+	encryptServData, err := msg.EncryptServiceData(message.Encrypt_AES128, aesKey, serviceData)
+	if err != nil {
+		thing.log.Error(err)
+		return err
+	}
+
+	//Dispatch data
+	dd := message.DispatchData{
+		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
+		Aid:                  protocol.ThingInfoBatchUploadAckAid,
+		Mid:                  protocol.ThingInfoBatchUploadAckMid,
+		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
+		ServiceDataLength:    uint16(len(encryptServData)),
+		Result:               0,
+		SecurityVersion:      message.Encrypt_AES128,
+		DispatchCreationTime: uint32(time.Now().Unix()),
+	}
+
+	dispatchData, err := util.StructToByteSlice(dd)
+	if err != nil {
+		thing.log.Error(err)
+		return err
+	}
+
+	//Message header data
+	mh := message.MessageHeader{
+		FixHeader:        message.MessageHeaderID,
+		ServiceDataCheck: util.DataXOR(serviceData),
+		ServiceVersion:   protocol.ServiceVersion,
+		Bid:              reqMsg.MesHeader.Bid,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingInfoUploadMessageFlag, thing.Encoding()),
+	}
+
+	messageHeaderData, err := util.StructToByteSlice(mh)
+	if err != nil {
+		thing.log.Error(err)
+		return err
+	}
+
+	//Send message
+	err = msg.SendMessage(messageHeaderData, dispatchData, encryptServData)
+	if err != nil {
+		thing.log.Error(err)
+		return err
+	}
+
+	thing.log.Debug("Send ThingInfoBatchUploadAck Success---, LastTime =", ack.LastTime)
+
 	return nil
 }
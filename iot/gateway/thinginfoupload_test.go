@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"errors"
+	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/protocol"
+	"github.com/harveywangdao/road/telemetry"
+	"testing"
+)
+
+//testPublisher keeps the sample times it published, or fails with err
+type testPublisher struct {
+	times []uint32
+	err   error
+}
+
+func (p *testPublisher) Publish(key string, datas ...[]byte) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	for _, data := range datas {
+		env, err := msgqueue.UnmarshalEnvelope(data)
+		if err != nil {
+			return err
+		}
+
+		record := &telemetry.Record{}
+		err = env.Decode(record)
+		if err != nil {
+			return err
+		}
+
+		p.times = append(p.times, record.Sample.Time)
+	}
+
+	return nil
+}
+
+func batchUpload(t *testing.T, publisher *testPublisher, upload *ThingInfoUpload, times ...uint32) (*protocol.ThingInfoBatchAckServData, error) {
+	thing, err := NewThing(make(chan ThingMessage, 1), &testConn{}, nil, nil, publisher, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thing.thingid = testThingId
+
+	batch := &protocol.ThingInforBatch{}
+	for _, time := range times {
+		batch.Samples = append(batch.Samples, protocol.ThingInfor{Version: 1, Time: time})
+	}
+
+	err = upload.ThingInfoBatchUploadReq(thing, &message.Message{}, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	thingMsg := <-thing.ThingMsgChan
+	if thingMsg.Event != EventThingInfoBatchUploadAck {
+		t.Fatalf("Pushed %s, want EventThingInfoBatchUploadAck", GetEventName(thingMsg.Event))
+	}
+
+	//The ack is sent by ThingInfoBatchUploadAck, which needs a logined connection
+	upload.thingInfoUploadStatus = ThingInfoUploadStop
+
+	return thingMsg.Param.(*protocol.ThingInfoBatchAckServData), nil
+}
+
+//Samples older than the ones already published, after a live upload or a clock step back, are published too
+func TestBatchUploadPublishesEverySample(t *testing.T) {
+	publisher := &testPublisher{}
+	upload := &ThingInfoUpload{}
+
+	tests := []struct {
+		times []uint32
+	}{
+		{[]uint32{100, 110, 120}},
+		{[]uint32{120, 90, 95}}, //resent and from before a clock reset
+		{nil},
+	}
+
+	var want []uint32
+	for _, tt := range tests {
+		ack, err := batchUpload(t, publisher, upload, tt.times...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if int(ack.Count) != len(tt.times) {
+			t.Errorf("Ack count %d for %v", ack.Count, tt.times)
+		}
+		if len(tt.times) > 0 && ack.LastTime != tt.times[len(tt.times)-1] {
+			t.Errorf("Ack last time %d for %v", ack.LastTime, tt.times)
+		}
+
+		want = append(want, tt.times...)
+	}
+
+	if len(publisher.times) != len(want) {
+		t.Fatalf("Published %v, want %v", publisher.times, want)
+	}
+	for i := range want {
+		if publisher.times[i] != want[i] {
+			t.Fatalf("Published %v, want %v", publisher.times, want)
+		}
+	}
+}
+
+//Nothing is acked when the samples could not be published
+func TestBatchUploadPublishFailure(t *testing.T) {
+	publisher := &testPublisher{err: errors.New("queue down")}
+	upload := &ThingInfoUpload{}
+
+	_, err := batchUpload(t, publisher, upload, 100)
+	if result, ok := message.GetResult(err); !ok || result != message.ResultInternalError {
+		t.Errorf("Got %v, want an internal error reply", err)
+	}
+
+	if upload.thingInfoUploadStatus != ThingInfoUploadStop {
+		t.Error("Upload still running after the failure")
+	}
+}
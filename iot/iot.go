@@ -1,22 +1,58 @@
 package main
 
 import (
+	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/iot/server"
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
 	"log"
+	"os"
 )
 
-func initIoT() {
-	//fileHandler := logger.NewFileHandler("test.log")
+const (
+	databaseConfigFile = "database.json" //optional, see database.Config
+	loggerConfigFile   = "logger.json"   //optional, see logger.Config
+	brokerConfigFile   = "broker.json"   //optional, see msgqueue.Config, kafka at msgqueue.DefaultKafkaAddr without it
+)
+
+func initIoT() msgqueue.Config {
+	//fileHandler, _ := logger.NewFileHandler("test.log")
 	//logger.SetHandlers(logger.Console, fileHandler)
 	logger.SetHandlers(logger.Console)
 	//defer logger.Close()
 	logger.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 	logger.SetLevel(logger.INFO)
+
+	if _, err := os.Stat(loggerConfigFile); err == nil {
+		err = logger.LoadConfig(loggerConfigFile)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	}
+
+	if _, err := os.Stat(databaseConfigFile); err == nil {
+		err = database.LoadConfig(databaseConfigFile)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	}
+
+	brokerConfig := msgqueue.DefaultConfig()
+	if _, err := os.Stat(brokerConfigFile); err == nil {
+		brokerConfig, err = msgqueue.LoadConfig(brokerConfigFile)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	}
+
+	return brokerConfig
 }
 
 func main() {
-	initIoT()
+	brokerConfig := initIoT()
 	logger.Debug("Start Server...")
-	server.Server()
+	server.Server(brokerConfig)
 }
@@ -1,17 +1,121 @@
 package server
 
 import (
+	"github.com/harveywangdao/road/database/mongo"
+	"github.com/harveywangdao/road/database/mysql"
 	"github.com/harveywangdao/road/iot/gateway"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/metrics"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/stat"
+	"github.com/harveywangdao/road/telemetry"
+	"net/http"
 	"sync"
 )
 
-func Server() {
+const (
+	MetricsAddr = ":9100"          //prometheus, see metrics.Path
+	AdminAddr   = "127.0.0.1:9101" //log levels and traces at logger.LevelPath and logger.TracePath, local only
+
+	MetricsDBName           = gateway.DBName
+	MetricsSavePeriodTime   = 60 * 1000 //milliseconds
+	MetricsReservingPeriod  = 7         //days
+	MetricsCleanupTimePoint = "03:00:00"
+)
+
+//Server runs the gateway and the telemetry writer on the broker of mq, msgqueue.BrokerMemory needs no kafka
+func Server(mq msgqueue.Config) {
 	var wg sync.WaitGroup
 
 	wg.Add(1)
 
-	gw := gateway.Gateway{}
+	broker, err := msgqueue.NewBroker(mq)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	defer broker.Close()
+
+	gw := gateway.Gateway{
+		MQAddr:         mq.Addr,
+		TelemetryTopic: telemetry.DefaultTopic,
+		Broker:         broker,
+	}
 	go gw.GatewayStart()
 
+	repo, err := newTelemetryRepository()
+	if err != nil {
+		logger.Error(err)
+	} else {
+		go runTelemetryWriter(broker, mq.Addr, repo)
+	}
+
+	go metrics.Serve(MetricsAddr)
+	go serveAdmin(AdminAddr)
+
+	startMetricsStore(&gw)
+
 	wg.Wait()
 }
+
+//newTelemetryRepository keeps one document per thingid and sample time, so samples published twice are stored once
+func newTelemetryRepository() (*telemetry.MongoRepository, error) {
+	db, err := mongo.GetDB()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	repo := telemetry.NewMongoRepository(db)
+
+	err = repo.EnsureIndexes()
+	if err != nil {
+		logger.Error(err)
+	}
+
+	return repo, nil
+}
+
+func runTelemetryWriter(broker msgqueue.Broker, mqAddr string, repo telemetry.Repository) {
+	writer := telemetry.Writer{
+		MQAddr:             mqAddr,
+		Topic:              telemetry.DefaultTopic,
+		ConsumerRoutineNum: 1,
+		Repo:               repo,
+		Broker:             broker,
+	}
+
+	writer.Run()
+}
+
+//startMetricsStore records host metrics and gateway counters, the gateway keeps running without it
+func startMetricsStore(gw *gateway.Gateway) {
+	ip, err := stat.GetLocalIP()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	_, err = mysql.NewStore(ip, MetricsDBName, MetricsSavePeriodTime, MetricsReservingPeriod, MetricsCleanupTimePoint, gw)
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
+//serveAdmin blocks. Levels and traces can be changed at runtime, see logger.LevelHandler and logger.TraceHandler,
+//so addr must not be reachable from other hosts
+func serveAdmin(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(logger.LevelPath, logger.LevelHandler())
+	mux.Handle(logger.TracePath, logger.TraceHandler())
+
+	logger.Info("Admin on", addr)
+
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -1,19 +1,41 @@
 package main
 
 import (
-	_ "github.com/harveywangdao/road/database"
+	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/iot_client/client"
 	"github.com/harveywangdao/road/log/logger"
 	"log"
+	"os"
+)
+
+const (
+	databaseConfigFile = "database.json" //optional, see database.Config
+	loggerConfigFile   = "logger.json"   //optional, see logger.Config
 )
 
 func initIotClient() {
-	//fileHandler := logger.NewFileHandler("test.log")
+	//fileHandler, _ := logger.NewFileHandler("test.log")
 	//logger.SetHandlers(logger.Console, fileHandler)
 	logger.SetHandlers(logger.Console)
 	//defer logger.Close()
 	logger.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 	logger.SetLevel(logger.INFO)
+
+	if _, err := os.Stat(loggerConfigFile); err == nil {
+		err = logger.LoadConfig(loggerConfigFile)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	}
+
+	if _, err := os.Stat(databaseConfigFile); err == nil {
+		err = database.LoadConfig(databaseConfigFile)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(1)
+		}
+	}
 }
 
 func main() {
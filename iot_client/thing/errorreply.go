@@ -0,0 +1,46 @@
+package thing
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
+)
+
+const (
+	EventErrorReply = protocol.MsgErrorReply
+)
+
+func init() {
+	RegisterApp(App{
+		Name: "errorreply",
+		Events: []AppEvent{
+			{
+				Event:   EventErrorReply,
+				Name:    "EventErrorReply",
+				Payload: func() interface{} { return &protocol.ErrorReplyServData{} },
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return thing.ErrorReply(thingMsg.Msg, thingMsg.Payload.(*protocol.ErrorReplyServData))
+				},
+			},
+		},
+	})
+}
+
+func (thing *Thing) ErrorReply(msg *message.Message, errorReplyServData *protocol.ErrorReplyServData) error {
+	result := msg.DisPatch.Result
+
+	logger.Warn("Recv error reply, Aid =", errorReplyServData.Aid, "Mid =", errorReplyServData.Mid, "Result =", message.ResultName(result))
+
+	if app, _, ok := apps.Lookup(protocol.MsgID(errorReplyServData.Aid, errorReplyServData.Mid)); ok && app.ErrorReply != nil {
+		app.ErrorReply(thing.apps[app.Name], thing, result)
+	}
+
+	if result == message.ResultNotLogin && thing.ThingStatus == ThingRegisteredLogined {
+		logger.Warn("Server lost login, login again!")
+		thing.ThingStatus = ThingRegisteredUnLogin
+		thing.SetThingStatusToDB(ThingRegisteredUnLogin)
+		thing.PushEventChannel(EventLoginRequest, nil)
+	}
+
+	return nil
+}
@@ -2,142 +2,48 @@ package thing
 
 import (
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 )
 
+//Event id is Aid<<8 | Mid, internal events use Aid 0x00 so they never come from the thing
 const (
-	EventCheckThingStatus = iota
-	EventServerClosed
-	EventConnectServer
-	EventSaveThingStatusUnRegister
-	EventSaveThingStatusUnLogin
-	EventSaveThingStatusLogined
+	UnknownEventMessage = protocol.MsgUnknown
 
-	EventConnectTsp
-
-	RegisterReqEventMessage
-	RegisterAckEventMessage
-
-	EventLoginRequest
-	EventLoginChallenge
-	EventLoginResponse
-	EventLoginFailure
-	EventLoginSuccess
-
-	EventReLoginRequest
-	EventReLoginAck
-
-	EventHeartbeatRequest
-	EventHeartbeatAck
-
-	EventReadConfigRequest
-	EventReadConfigAck
-
-	EventSetConfigRequest
-	EventSetConfigAck
-
-	EventRemoteOperationRequest
-	EventDispatcherAckMessage
-	EventRemoteOperationEnd
-	EventRemoteOperationAck
-
-	EventThingInfoUpload
-	EventThingInfoUploadAck
-
-	UnknownEventMessage
+	EventCheckThingStatus = 0x0001
+	EventServerClosed     = 0x0002
+	EventConnectServer    = 0x0003
 )
 
-var (
-	EventNameList = []string{
-		"EventCheckThingStatus",
-		"EventServerClosed",
-		"EventConnectServer",
-		"EventSaveThingStatusUnRegister",
-		"EventSaveThingStatusUnLogin",
-		"EventSaveThingStatusLogined",
+//state is the value returned by App.NewState for this thing
+type EventHandler func(state interface{}, thing *Thing, thingMsg ThingMessage) error
 
-		"EventConnectTsp",
+//ErrorReplyHandler is called when the server rejects a message of the app
+type ErrorReplyHandler func(state interface{}, thing *Thing, result uint8) error
 
-		"RegisterReqEventMessage",
-		"RegisterAckEventMessage",
-
-		"EventLoginRequest",
-		"EventLoginChallenge",
-		"EventLoginResponse",
-		"EventLoginFailure",
-		"EventLoginSuccess",
-
-		"EventReLoginRequest",
-		"EventReLoginAck",
-
-		"EventHeartbeatRequest",
-		"EventHeartbeatAck",
-
-		"EventReadConfigRequest",
-		"EventReadConfigAck",
-
-		"EventSetConfigRequest",
-		"EventSetConfigAck",
-
-		"EventRemoteOperationRequest",
-		"EventDispatcherAckMessage",
-		"EventRemoteOperationEnd",
-		"EventRemoteOperationAck",
-
-		"EventThingInfoUpload",
-		"EventThingInfoUploadAck",
-
-		"UnknownEventMessage",
-	}
+type (
+	AppEvent = protocol.AppEvent[EventHandler]
+	App      = protocol.App[EventHandler, ErrorReplyHandler]
 )
 
-type EventTypeMapAidMid struct {
-	EventType int
-	Aid       uint8
-	Mid       uint8
-}
-
-var (
-	eventTypeMapAidMidTable = [...]EventTypeMapAidMid{
-		{RegisterReqEventMessage, 0x1, 0x1},
-		{RegisterAckEventMessage, 0x1, 0x2},
-
-		{EventLoginRequest, 0x2, 0x1},
-		{EventLoginChallenge, 0x2, 0x2},
-		{EventLoginResponse, 0x2, 0x3},
-		{EventLoginFailure, 0x2, 0x4},
-		{EventLoginSuccess, 0x2, 0x5},
-
-		{EventReLoginRequest, 0x4, 0x1},
-		{EventReLoginAck, 0x4, 0x2},
-
-		{EventReadConfigRequest, 0x5, 0x1},
-		{EventReadConfigAck, 0x5, 0x2},
+var apps = protocol.NewRegistry[EventHandler, ErrorReplyHandler]("thing", map[int]string{
+	UnknownEventMessage:   "UnknownEventMessage",
+	EventCheckThingStatus: "EventCheckThingStatus",
+	EventServerClosed:     "EventServerClosed",
+	EventConnectServer:    "EventConnectServer",
+})
 
-		{EventSetConfigRequest, 0x7, 0x1},
-		{EventSetConfigAck, 0x7, 0x2},
-
-		{EventHeartbeatRequest, 0xB, 0x1},
-		{EventHeartbeatAck, 0xB, 0x2},
-
-		{EventRemoteOperationRequest, 0xF1, 0x1},
-		{EventDispatcherAckMessage, 0xF1, 0x2},
-		{EventRemoteOperationEnd, 0xF1, 0x3},
-		{EventRemoteOperationAck, 0xF1, 0x4},
+//RegisterApp is called from init, a duplicate name or event is a programming error
+func RegisterApp(app App) {
+	apps.Register(app)
+}
 
-		{EventThingInfoUpload, 0xF5, 0x4},
-		{EventThingInfoUploadAck, 0xF5, 0x2},
-	}
-)
+func newAppStates() map[string]interface{} {
+	return apps.NewStates()
+}
 
 func GetEventTypeByAidMid(aid uint8, mid uint8) int {
-	event := UnknownEventMessage
-
-	for _, e := range eventTypeMapAidMidTable {
-		if e.Aid == aid && e.Mid == mid {
-			event = e.EventType
-			break
-		}
-	}
+	event := apps.EventByAidMid(aid, mid)
 
 	logger.Debug("event =", event)
 
@@ -145,33 +51,15 @@ func GetEventTypeByAidMid(aid uint8, mid uint8) int {
 }
 
 func GetEventName(event int) string {
-	if event >= UnknownEventMessage {
-		return "UnknownEventMessage"
-	}
-
-	return EventNameList[event]
+	return apps.EventName(event)
 }
 
-/*var (
-	eventTypeTable = [][]int{
-		{RegisterReqEventMessage, RegisterAckEventMessage},                                                 //0x1
-		{EventLoginRequest, EventLoginChallenge, EventLoginResponse, EventLoginFailure, EventLoginSuccess}, //0x2
-		{}, //0x3
-		{EventReLoginRequest, EventReLoginAck}, //0x4
-	}
-)
-*/
-/*func GetEventTypeByAidMid2(aid uint8, mid uint8) int {
-	//logger.Info("eventTypeTable =", eventTypeTable)
-
-	if aid > 0 && mid > 0 {
-		if int(aid) <= len(eventTypeTable) {
-			if int(mid) <= len(eventTypeTable[aid-1]) {
-				return eventTypeTable[aid-1][mid-1]
-			}
-		}
+func decodeEventPayload(event int, msg *message.Message) (interface{}, error) {
+	payload, err := apps.DecodePayload(event, msg.Encoding(), msg.ServData)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
 	}
 
-	return UnknownEventMessage
+	return payload, nil
 }
-*/
@@ -1,23 +1,16 @@
 package thing
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	HeartbeatTimeoutTime = 5 * time.Second
-	HeartbeatMessageFlag = 0x0
-
-	HeartbeatReqAid = 0xB
-	HeartbeatReqMid = 0x1
-
-	HeartbeatAckAid = 0xB
-	HeartbeatAckMid = 0x2
 )
 
 const (
@@ -26,6 +19,11 @@ const (
 	/*HeartbeatAckStatus*/
 )
 
+const (
+	EventHeartbeatRequest = protocol.MsgHeartbeatReq
+	EventHeartbeatAck     = protocol.MsgHeartbeatAck
+)
+
 type Heartbeat struct {
 	heartbeatTimer      *time.Timer
 	closeHeartbeatTimer chan bool
@@ -33,9 +31,30 @@ type Heartbeat struct {
 	heartbeatStatus int
 }
 
-type HeartbeatReqServData struct {
-	AppointMF  byte `json:"appointmf"`
-	AppointAid byte `json:"appointaid"`
+func init() {
+	RegisterApp(App{
+		Name:     "heartbeat",
+		NewState: func() interface{} { return &Heartbeat{} },
+		Events: []AppEvent{
+			{
+				Event: EventHeartbeatRequest,
+				Name:  "EventHeartbeatRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Heartbeat).HeartbeatReq(thing)
+				},
+			},
+			{
+				Event: EventHeartbeatAck,
+				Name:  "EventHeartbeatAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Heartbeat).HeartbeatAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+		ErrorReply: func(state interface{}, thing *Thing, result uint8) error {
+			return state.(*Heartbeat).HeartbeatErrorReply(thing, result)
+		},
+	})
 }
 
 func (hb *Heartbeat) HeartbeatReq(thing *Thing) error {
@@ -56,12 +75,12 @@ func (hb *Heartbeat) HeartbeatReq(thing *Thing) error {
 	}
 
 	//Service data
-	heartbeatReqServData := HeartbeatReqServData{
+	heartbeatReqServData := protocol.HeartbeatReqServData{
 		AppointMF:  255,
 		AppointAid: 0,
 	}
 
-	serviceData, err := json.Marshal(&heartbeatReqServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &heartbeatReqServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -85,8 +104,8 @@ func (hb *Heartbeat) HeartbeatReq(thing *Thing) error {
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  HeartbeatReqAid,
-		Mid:                  HeartbeatReqMid,
+		Aid:                  protocol.HeartbeatReqAid,
+		Mid:                  protocol.HeartbeatReqMid,
 		MessageCounter:       0,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -104,9 +123,9 @@ func (hb *Heartbeat) HeartbeatReq(thing *Thing) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              thing.GetBid(),
-		MessageFlag:      HeartbeatMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.HeartbeatMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -160,3 +179,20 @@ func (hb *Heartbeat) HeartbeatAck(thing *Thing, reqMsg *message.Message) error {
 
 	return nil
 }
+
+func (hb *Heartbeat) HeartbeatErrorReply(thing *Thing, result uint8) error {
+	if hb.heartbeatStatus != HeartbeatReqStatus {
+		logger.Error("Need HeartbeatReq!")
+		return errors.New("Need HeartbeatReq!")
+	}
+
+	//other results retry when the timer coming
+	if result == message.ResultNotLogin {
+		hb.heartbeatTimer.Stop()
+		hb.closeHeartbeatTimer <- true
+
+		hb.heartbeatStatus = HeartbeatStop
+	}
+
+	return nil
+}
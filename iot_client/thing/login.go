@@ -3,12 +3,12 @@ package thing
 import (
 	"database/sql"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/crypto/md5"
 	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
@@ -16,16 +16,6 @@ import (
 const (
 	TimeoutTime    = 10 * time.Second
 	LoginAgainTime = 10 * time.Second
-
-	LoginResultCodeSuccess       = 0x00
-	LoginResultCodeSnVinErr      = 0xA8
-	LoginResultCodeBidErrOrUnreg = 0xA9
-	LoginResultCodeInterrupt     = 0xAA
-	LoginResultCodeAbstractErr   = 0xAB
-	LoginResultCodeAesOutOfDate  = 0xAC
-
-	KeyTypePreAesKey     = 0
-	KeyTypeCurrentAesKey = 1
 )
 
 const (
@@ -35,41 +25,71 @@ const (
 	LoginResponseStatus
 )
 
+const (
+	EventLoginRequest   = protocol.MsgLoginRequest
+	EventLoginChallenge = protocol.MsgLoginChallenge
+	EventLoginResponse  = protocol.MsgLoginResponse
+	EventLoginFailure   = protocol.MsgLoginFailure
+	EventLoginSuccess   = protocol.MsgLoginSuccess
+)
+
 type Login struct {
 	timeoutTimer      *time.Timer
 	closeTimeoutTimer chan bool
 
-	loginReqServData   *LoginReqServData
-	loginChallServData *LoginChallengeServData
-	loginRespServData  *LoginResponseServData
+	loginReqServData   *protocol.LoginReqServData
+	loginChallServData *protocol.LoginChallengeServData
+	loginRespServData  *protocol.LoginResponseServData
 
 	loginStatus         int
 	loginEventCreatTime uint32
 }
 
-type LoginReqServData struct {
-	KeyType     byte   `json:"keytype"`
-	ThingSN     string `json:"thingsn"`
-	ThingId     string `json:"thingid"`
-	ThingRandom string `json:"thingrandom"`
-}
-
-type LoginChallengeServData struct {
-	ThingRandomMd5 string `json:"thingrandommd5"`
-	PlatRandom     string `json:"platrandom"`
-}
-
-type LoginResponseServData struct {
-	SerialUP  string `json:"serialup"`
-	AccessKey string `json:"accesskey"`
-}
-
-type LoginSuccessServData struct {
-	AesRandom     string `json:"aesrandom"`
-	InitSerial    byte   `json:"initserial"`
-	TimeStamp     int64  `json:"timestamp"`
-	WorkWindow    int64  `json:"workwindow"`
-	LinkHeartbeat int64  `json:"linkheartbeat"`
+func init() {
+	RegisterApp(App{
+		Name:     "login",
+		NewState: func() interface{} { return &Login{} },
+		Events: []AppEvent{
+			{
+				Event: EventLoginRequest,
+				Name:  "EventLoginRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginRequest(thing)
+				},
+			},
+			{
+				Event: EventLoginChallenge,
+				Name:  "EventLoginChallenge",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginChallenge(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventLoginResponse,
+				Name:  "EventLoginResponse",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginResponse(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventLoginFailure,
+				Name:  "EventLoginFailure",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginFailure(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventLoginSuccess,
+				Name:  "EventLoginSuccess",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Login).LoginSuccess(thing, thingMsg.Msg)
+				},
+			},
+		},
+		ErrorReply: func(state interface{}, thing *Thing, result uint8) error {
+			return state.(*Login).LoginErrorReply(thing, result)
+		},
+	})
 }
 
 func (login *Login) getAesKeyByKeyType(thingNo int, keyType uint8) (string, error) {
@@ -88,7 +108,7 @@ func (login *Login) getAesKeyByKeyType(thingNo int, keyType uint8) (string, erro
 		return "", err
 	}
 
-	if keyType == KeyTypeCurrentAesKey {
+	if keyType == protocol.KeyTypeCurrentAesKey {
 		return thingaes128key, nil
 	}
 
@@ -122,7 +142,7 @@ func (login *Login) saveNewAesKey(aesRandom string, eventCreationTime uint32, th
 	}
 
 	var key string
-	if login.loginReqServData.KeyType == KeyTypePreAesKey {
+	if login.loginReqServData.KeyType == protocol.KeyTypePreAesKey {
 		key = prethingaes128key
 	} else {
 		key = thingaes128key
@@ -193,19 +213,19 @@ func (login *Login) loginRequestSendData(thing *Thing) error {
 
 	var keyType byte
 	if login.checkAesKeyOutOfDate(thing.ThingNo) {
-		keyType = KeyTypePreAesKey
+		keyType = protocol.KeyTypePreAesKey
 	} else {
-		keyType = KeyTypeCurrentAesKey
+		keyType = protocol.KeyTypeCurrentAesKey
 	}
 
-	login.loginReqServData = &LoginReqServData{
+	login.loginReqServData = &protocol.LoginReqServData{
 		KeyType:     keyType, /*0-pre key; 1-current key*/
 		ThingSN:     thingserialno,
 		ThingId:     thingid,
 		ThingRandom: util.GenRandomString(16),
 	}
 
-	serviceData, err := json.Marshal(login.loginReqServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), login.loginReqServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -229,8 +249,8 @@ func (login *Login) loginRequestSendData(thing *Thing) error {
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  0x2,
-		Mid:                  0x1,
+		Aid:                  protocol.LoginRequestAid,
+		Mid:                  protocol.LoginRequestMid,
 		MessageCounter:       0,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -250,9 +270,9 @@ func (login *Login) loginRequestSendData(thing *Thing) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              bid,
-		MessageFlag:      0x0,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.LoginMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -344,13 +364,21 @@ func (login *Login) LoginChallenge(thing *Thing, challengeMsg *message.Message)
 	var db *sql.DB
 	var err error
 
-	if challengeMsg.DisPatch.Result != LoginResultCodeSuccess {
+	if challengeMsg.DisPatch.Result == protocol.LoginResultCodeInterrupt {
+		logger.Warn("Server store unavailable, login again later!")
+		login.closeTimeoutTimer <- true
+		login.loginStatus = LoginStop
+		login.loginAgainLater(thing)
+		return nil
+	}
+
+	if challengeMsg.DisPatch.Result != protocol.LoginResultCodeSuccess {
 		logger.Debug("Result error!")
 		goto FAILURE
 	}
 
-	login.loginChallServData = &LoginChallengeServData{}
-	err = json.Unmarshal(challengeMsg.ServData, login.loginChallServData)
+	login.loginChallServData = &protocol.LoginChallengeServData{}
+	err = challengeMsg.UnmarshalServData(login.loginChallServData)
 	if err != nil {
 		logger.Error(err)
 		goto FAILURE
@@ -372,7 +400,7 @@ func (login *Login) LoginChallenge(thing *Thing, challengeMsg *message.Message)
 		goto FAILURE
 	}
 
-	if login.loginReqServData.KeyType == KeyTypePreAesKey {
+	if login.loginReqServData.KeyType == protocol.KeyTypePreAesKey {
 		key = prethingaes128key
 	} else {
 		key = thingaes128key
@@ -430,18 +458,18 @@ func (login *Login) LoginResponse(thing *Thing, challengeMsg *message.Message) e
 		goto FAILURE
 	}
 
-	if login.loginReqServData.KeyType == KeyTypePreAesKey {
+	if login.loginReqServData.KeyType == protocol.KeyTypePreAesKey {
 		key = prethingaes128key
 	} else {
 		key = thingaes128key
 	}
 
-	login.loginRespServData = &LoginResponseServData{
+	login.loginRespServData = &protocol.LoginResponseServData{
 		SerialUP:  util.GenRandomString(16),
 		AccessKey: login.genMd5Abstract16Bytes(login.loginChallServData.PlatRandom + key),
 	}
 
-	serviceData, err = json.Marshal(login.loginRespServData)
+	serviceData, err = protocol.Marshal(thing.Encoding(), login.loginRespServData)
 	if err != nil {
 		logger.Error(err)
 		goto FAILURE
@@ -465,8 +493,8 @@ func (login *Login) LoginResponse(thing *Thing, challengeMsg *message.Message) e
 	//Dispatch data
 	dd = message.DispatchData{
 		EventCreationTime:    challengeMsg.DisPatch.EventCreationTime,
-		Aid:                  0x2,
-		Mid:                  0x3,
+		Aid:                  protocol.LoginResponseAid,
+		Mid:                  protocol.LoginResponseMid,
 		MessageCounter:       challengeMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -484,9 +512,9 @@ func (login *Login) LoginResponse(thing *Thing, challengeMsg *message.Message) e
 	mh = message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              challengeMsg.MesHeader.Bid,
-		MessageFlag:      0x0,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.LoginMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err = util.StructToByteSlice(mh)
@@ -540,7 +568,7 @@ func (login *Login) LoginFailure(thing *Thing, failMsg *message.Message) error {
 	logger.Debug("Close timer")
 	login.closeTimeoutTimer <- true
 
-	if failMsg.DisPatch.Result == LoginResultCodeInterrupt {
+	if failMsg.DisPatch.Result == protocol.LoginResultCodeInterrupt {
 		logger.Error("Login again!")
 		thing.PushEventChannel(EventLoginRequest, nil)
 	} else {
@@ -568,8 +596,8 @@ func (login *Login) LoginSuccess(thing *Thing, successMsg *message.Message) erro
 	logger.Debug("Close timer")
 	login.closeTimeoutTimer <- true
 
-	loginSuccessServData := &LoginSuccessServData{}
-	err := json.Unmarshal(successMsg.ServData, loginSuccessServData)
+	loginSuccessServData := &protocol.LoginSuccessServData{}
+	err := successMsg.UnmarshalServData(loginSuccessServData)
 	if err != nil {
 		logger.Error(err)
 		login.loginStatus = LoginStop
@@ -592,5 +620,41 @@ func (login *Login) LoginSuccess(thing *Thing, successMsg *message.Message) erro
 	logger.Info(login.loginReqServData.ThingId, "Login success!")
 	login.loginStatus = LoginStop
 
+	//Upload what was sampled while offline
+	thing.PushEventChannel(EventThingInfoUpload, nil)
+
 	return nil
 }
+
+func (login *Login) LoginErrorReply(thing *Thing, result uint8) error {
+	if login.loginStatus == LoginStop {
+		logger.Error("Login not start!")
+		return errors.New("Login not start!")
+	}
+
+	login.timeoutTimer.Stop()
+	login.closeTimeoutTimer <- true
+	login.loginStatus = LoginStop
+
+	if result == message.ResultDecryptFailure {
+		logger.Warn("Aes key not match, register again!")
+		thing.PushEventChannel(RegisterReqEventMessage, nil)
+		return nil
+	}
+
+	login.loginAgainLater(thing)
+
+	return nil
+}
+
+func (login *Login) loginAgainLater(thing *Thing) {
+	t := time.NewTimer(LoginAgainTime)
+
+	go func() {
+		select {
+		case <-t.C:
+			logger.Info("Login fail, Login again!")
+			thing.PushEventChannel(EventLoginRequest, nil)
+		}
+	}()
+}
@@ -1,24 +1,16 @@
 package thing
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	ReadConfigTimeoutTime = 5 * time.Second
-
-	ReadConfigMessageFlag = 0x0
-
-	ReadConfigReqAid = 0x5
-	ReadConfigReqMid = 0x1
-
-	ReadConfigAckAid = 0x5
-	ReadConfigAckMid = 0x2
 )
 
 const (
@@ -27,10 +19,8 @@ const (
 )
 
 const (
-	ThingSN  = "thingsn"
-	Version  = "version"
-	WorkAddr = "workaddr"
-	WorkPort = "workport"
+	EventReadConfigRequest = protocol.MsgReadConfigReq
+	EventReadConfigAck     = protocol.MsgReadConfigAck
 )
 
 type ReadConfig struct {
@@ -40,12 +30,27 @@ type ReadConfig struct {
 	readConfigStatus int
 }
 
-type ReadConfigReqServData struct {
-	IndexList []string `json:"indexlist"`
-}
-
-type ReadConfigAckServData struct {
-	WorkConfigList []string `json:"workconfiglist"`
+func init() {
+	RegisterApp(App{
+		Name:     "readconfig",
+		NewState: func() interface{} { return &ReadConfig{} },
+		Events: []AppEvent{
+			{
+				Event: EventReadConfigRequest,
+				Name:  "EventReadConfigRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReadConfig).ReadConfigReq(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventReadConfigAck,
+				Name:  "EventReadConfigAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReadConfig).ReadConfigAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (readConf *ReadConfig) GetConfig(indexs []string) []string {
@@ -53,13 +58,13 @@ func (readConf *ReadConfig) GetConfig(indexs []string) []string {
 
 	for _, index := range indexs {
 		switch index {
-		case ThingSN:
+		case protocol.ThingSN:
 			configData = append(configData, "12345678901234567890123456711")
-		case Version:
+		case protocol.Version:
 			configData = append(configData, "1.0.2")
-		case WorkAddr:
+		case protocol.WorkAddr:
 			configData = append(configData, "192.168.162.120")
-		case WorkPort:
+		case protocol.WorkPort:
 			configData = append(configData, "5525")
 		}
 	}
@@ -98,8 +103,8 @@ func (readConf *ReadConfig) ReadConfigAck(thing *Thing, reqMsg *message.Message)
 	}
 
 	//Service data
-	readConfReqServData := &ReadConfigReqServData{}
-	err := json.Unmarshal(reqMsg.ServData, readConfReqServData)
+	readConfReqServData := &protocol.ReadConfigReqServData{}
+	err := reqMsg.UnmarshalServData(readConfReqServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -107,11 +112,11 @@ func (readConf *ReadConfig) ReadConfigAck(thing *Thing, reqMsg *message.Message)
 
 	logger.Info("readConfReqServData =", string(reqMsg.ServData))
 
-	readConfAckServData := ReadConfigAckServData{
+	readConfAckServData := protocol.ReadConfigAckServData{
 		WorkConfigList: readConf.GetConfig(readConfReqServData.IndexList),
 	}
 
-	serviceData, err := json.Marshal(&readConfAckServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &readConfAckServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -135,8 +140,8 @@ func (readConf *ReadConfig) ReadConfigAck(thing *Thing, reqMsg *message.Message)
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
-		Aid:                  ReadConfigAckAid,
-		Mid:                  ReadConfigAckMid,
+		Aid:                  protocol.ReadConfigAckAid,
+		Mid:                  protocol.ReadConfigAckMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -154,9 +159,9 @@ func (readConf *ReadConfig) ReadConfigAck(thing *Thing, reqMsg *message.Message)
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              reqMsg.MesHeader.Bid,
-		MessageFlag:      ReadConfigMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ReadConfigMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
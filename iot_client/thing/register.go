@@ -1,20 +1,16 @@
 package thing
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
-	RegisterSuccess byte = 0x78
-	RegisterFailure byte = 0xA8
-	AlreadyRegister byte = 0x79
-
 	CheckRegAckTimerTime   = 10 * time.Second
 	CheckRegAgainTimerTime = 10 * time.Second
 )
@@ -24,6 +20,11 @@ const (
 	RegisterStatusStart
 )
 
+const (
+	RegisterReqEventMessage = protocol.MsgRegisterReq
+	RegisterAckEventMessage = protocol.MsgRegisterAck
+)
+
 type Register struct {
 	checkRegAckTimer *time.Timer
 	regReqTimes      int
@@ -32,24 +33,34 @@ type Register struct {
 	registerStatus int
 
 	registerStart   bool
-	registerReqData *RegisterReqData
+	registerReqData *protocol.RegisterReqData
 
 	regReqEventCreatTime uint32
 }
 
-type RegisterReqData struct {
-	PerAesKey  string `json:"peraeskey"`
-	ThingId    string `json:"thingid"`
-	TBoxSN     string `json:"tboxsn"`
-	IMSI       string `json:"imsi"`
-	RollNumber string `json:"rollnumber"`
-	ICCID      string `json:"iccid"`
-}
-
-type RegisterAckMsg struct {
-	Status      byte   `json:"status"`
-	CallbackNum string `json:"callbacknumber"`
-	Bid         uint32 `json:"bid"`
+func init() {
+	RegisterApp(App{
+		Name:     "register",
+		NewState: func() interface{} { return &Register{} },
+		Events: []AppEvent{
+			{
+				Event: RegisterReqEventMessage,
+				Name:  "RegisterReqEventMessage",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					thing.ThingStatus = ThingUnRegister
+					thing.SetThingStatusToDB(ThingUnRegister)
+					return state.(*Register).RegisterReq(thing)
+				},
+			},
+			{
+				Event: RegisterAckEventMessage,
+				Name:  "RegisterAckEventMessage",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*Register).RegisterACK(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (reg *Register) saveRegisterDataToDB(bid, eventCreationTime uint32, newAesKey string, thingNo int) error {
@@ -92,7 +103,7 @@ func GetEventCreationTime(id int) (uint32, error) {
 	return eventCreatTime, nil
 }
 
-func (reg *Register) getServiceData(ThingNo int) ([]byte, error) {
+func (reg *Register) getServiceData(ThingNo int, encoding int) ([]byte, error) {
 	db, err := database.GetDB(DBName)
 	if err != nil {
 		logger.Error(err)
@@ -118,7 +129,7 @@ func (reg *Register) getServiceData(ThingNo int) ([]byte, error) {
 		return nil, err
 	}
 
-	reg.registerReqData = &RegisterReqData{
+	reg.registerReqData = &protocol.RegisterReqData{
 		PerAesKey:  prethingaes128key,
 		ThingId:    thingid,
 		TBoxSN:     thingserialno,
@@ -127,7 +138,7 @@ func (reg *Register) getServiceData(ThingNo int) ([]byte, error) {
 		ICCID:      iccid,
 	}
 
-	serviceData, err := json.Marshal(reg.registerReqData)
+	serviceData, err := protocol.Marshal(encoding, reg.registerReqData)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
@@ -142,8 +153,8 @@ func (reg *Register) getServiceData(ThingNo int) ([]byte, error) {
 func (reg *Register) getDispatchData(encryptServData []byte) ([]byte, error) {
 	var dd message.DispatchData
 	dd.EventCreationTime = uint32(time.Now().Unix())
-	dd.Aid = 0x1
-	dd.Mid = 0x1
+	dd.Aid = protocol.RegisterReqAid
+	dd.Mid = protocol.RegisterReqMid
 	dd.MessageCounter = 0
 	dd.ServiceDataLength = uint16(len(encryptServData))
 	dd.Result = 0
@@ -161,13 +172,13 @@ func (reg *Register) getDispatchData(encryptServData []byte) ([]byte, error) {
 	return dispatchData, nil
 }
 
-func (reg *Register) getMessageHeaderData(serviceData []byte) ([]byte, error) {
+func (reg *Register) getMessageHeaderData(serviceData []byte, encoding int) ([]byte, error) {
 	var mh message.MessageHeader
 	mh.FixHeader = message.MessageHeaderID
 	mh.ServiceDataCheck = util.DataXOR(serviceData)
-	mh.ServiceVersion = 0x0 //not sure
+	mh.ServiceVersion = protocol.ServiceVersion
 	mh.Bid = 0x0
-	mh.MessageFlag = 0x0
+	mh.MessageFlag = protocol.FlagWithEncoding(protocol.RegisterMessageFlag, encoding)
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
 	if err != nil {
@@ -193,7 +204,7 @@ func (reg *Register) RegisterReq(thing *Thing) error {
 		Connection: thing.Conn,
 	}
 
-	serviceData, err := reg.getServiceData(thing.ThingNo)
+	serviceData, err := reg.getServiceData(thing.ThingNo, thing.Encoding())
 	if err != nil {
 		logger.Error(err)
 		reg.registerStart = false
@@ -209,7 +220,7 @@ func (reg *Register) RegisterReq(thing *Thing) error {
 		return err
 	}
 
-	messageHeaderData, err := reg.getMessageHeaderData(serviceData)
+	messageHeaderData, err := reg.getMessageHeaderData(serviceData, thing.Encoding())
 	if err != nil {
 		logger.Error(err)
 		reg.registerStart = false
@@ -277,8 +288,8 @@ func (reg *Register) RegisterACK(thing *Thing, msg *message.Message) error {
 	reg.closeRegAckTimer <- true
 	reg.regReqTimes = 0
 
-	registerAckMsg := RegisterAckMsg{}
-	err := json.Unmarshal(msg.ServData, &registerAckMsg)
+	registerAckMsg := protocol.RegisterAckMsg{}
+	err := msg.UnmarshalServData(&registerAckMsg)
 	if err != nil {
 		logger.Error(err)
 		goto FAILURE
@@ -289,7 +300,7 @@ func (reg *Register) RegisterACK(thing *Thing, msg *message.Message) error {
 	logger.Debug("service =", string(msg.ServData))
 
 	//Store DB
-	if registerAckMsg.Status == 1 && msg.DisPatch.Result != AlreadyRegister {
+	if registerAckMsg.Status == 1 && msg.DisPatch.Result != protocol.AlreadyRegister {
 		logger.Error("Register fail!")
 		goto FAILURE
 	} else {
@@ -1,27 +1,22 @@
 package thing
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
-	ReLoginMessageFlag = 0x0
-
-	ReLoginReqAid = 0x4
-	ReLoginReqMid = 0x1
-
-	ReLoginAckAid = 0x4
-	ReLoginAckMid = 0x2
+	ReLoginStop = iota
+	ReLoginReqStatus
 )
 
 const (
-	ReLoginStop = iota
-	ReLoginReqStatus
+	EventReLoginRequest = protocol.MsgReLoginReq
+	EventReLoginAck     = protocol.MsgReLoginAck
 )
 
 type ReLogin struct {
@@ -31,8 +26,27 @@ type ReLogin struct {
 	reloginStatus int
 }
 
-type ReLoginReqServData struct {
-	NewTime byte `json:"newtime"` //NewTime*10 minute
+func init() {
+	RegisterApp(App{
+		Name:     "relogin",
+		NewState: func() interface{} { return &ReLogin{} },
+		Events: []AppEvent{
+			{
+				Event: EventReLoginRequest,
+				Name:  "EventReLoginRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReLogin).ReLoginReq(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventReLoginAck,
+				Name:  "EventReLoginAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ReLogin).ReLoginAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (relogin *ReLogin) ReLoginReq(thing *Thing, reqMsg *message.Message) error {
@@ -48,8 +62,8 @@ func (relogin *ReLogin) ReLoginReq(thing *Thing, reqMsg *message.Message) error
 		return errors.New("Not login or register")
 	}*/
 
-	reLoginReqServData := &ReLoginReqServData{}
-	err := json.Unmarshal(reqMsg.ServData, reLoginReqServData)
+	reLoginReqServData := &protocol.ReLoginReqServData{}
+	err := reqMsg.UnmarshalServData(reLoginReqServData)
 	if err != nil {
 		logger.Error(err)
 		relogin.reloginStatus = ReLoginStop
@@ -114,8 +128,8 @@ func (relogin *ReLogin) ReLoginAck(thing *Thing, reqMsg *message.Message) error
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
-		Aid:                  ReLoginAckAid,
-		Mid:                  ReLoginAckMid,
+		Aid:                  protocol.ReLoginAckAid,
+		Mid:                  protocol.ReLoginAckMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -134,9 +148,9 @@ func (relogin *ReLogin) ReLoginAck(thing *Thing, reqMsg *message.Message) error
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              reqMsg.MesHeader.Bid,
-		MessageFlag:      ReLoginMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ReLoginMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -1,24 +1,16 @@
 package thing
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	SetConfigTimeoutTime = 5 * time.Second
-
-	SetConfigMessageFlag = 0x0
-
-	SetConfigReqAid = 0x7
-	SetConfigReqMid = 0x1
-
-	SetConfigAckAid = 0x7
-	SetConfigAckMid = 0x2
 )
 
 const (
@@ -27,36 +19,50 @@ const (
 )
 
 /*const (
-	ThingSN  = "thingsn"
-	Version  = "version"
-	WorkAddr = "workaddr"
-	WorkPort = "workport"
 )*/
 
+const (
+	EventSetConfigRequest = protocol.MsgSetConfigReq
+	EventSetConfigAck     = protocol.MsgSetConfigAck
+)
+
 type SetConfig struct {
 	setConfigTimeoutTimer *time.Timer
 	closeTimeoutTimer     chan bool
 
 	setConfigigStatus int
 
-	setConfigReqServData *SetConfigReqServData
-}
-
-type SetConfigReqServData struct {
-	IndexList      []string `json:"indexlist"`
-	WorkConfigList []string `json:"workconfiglist"`
+	setConfigReqServData *protocol.SetConfigReqServData
 }
 
-type SetConfigAckServData struct {
-	IndexList      []string `json:"indexlist"`
-	WorkConfigList []string `json:"workconfiglist"`
+func init() {
+	RegisterApp(App{
+		Name:     "setconfig",
+		NewState: func() interface{} { return &SetConfig{} },
+		Events: []AppEvent{
+			{
+				Event: EventSetConfigRequest,
+				Name:  "EventSetConfigRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*SetConfig).SetConfigReq(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventSetConfigAck,
+				Name:  "EventSetConfigAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*SetConfig).SetConfigAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+	})
 }
 
 func (setConfig *SetConfig) GetIndexList() []string {
 	indexs := make([]string, 0, 16)
-	indexs = append(indexs, ThingSN)
-	indexs = append(indexs, Version)
-	indexs = append(indexs, WorkPort)
+	indexs = append(indexs, protocol.ThingSN)
+	indexs = append(indexs, protocol.Version)
+	indexs = append(indexs, protocol.WorkPort)
 	return indexs
 }
 
@@ -92,8 +98,8 @@ func (setConfig *SetConfig) SetConfigAck(thing *Thing, reqMsg *message.Message)
 		return errors.New("Need SetConfigReq!")
 	}
 
-	setConfigReqServData := &SetConfigReqServData{}
-	err := json.Unmarshal(reqMsg.ServData, setConfigReqServData)
+	setConfigReqServData := &protocol.SetConfigReqServData{}
+	err := reqMsg.UnmarshalServData(setConfigReqServData)
 	if err != nil {
 		logger.Error(err)
 		setConfig.setConfigigStatus = SetConfigStop
@@ -108,12 +114,12 @@ func (setConfig *SetConfig) SetConfigAck(thing *Thing, reqMsg *message.Message)
 	}
 
 	//Service data
-	setConfigAckServData := &SetConfigAckServData{
+	setConfigAckServData := &protocol.SetConfigAckServData{
 		IndexList:      setConfigReqServData.IndexList,
 		WorkConfigList: setConfigReqServData.WorkConfigList,
 	}
 
-	serviceData, err := json.Marshal(setConfigAckServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), setConfigAckServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -137,8 +143,8 @@ func (setConfig *SetConfig) SetConfigAck(thing *Thing, reqMsg *message.Message)
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  SetConfigAckAid,
-		Mid:                  SetConfigAckMid,
+		Aid:                  protocol.SetConfigAckAid,
+		Mid:                  protocol.SetConfigAckMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -156,9 +162,9 @@ func (setConfig *SetConfig) SetConfigAck(thing *Thing, reqMsg *message.Message)
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              thing.GetBid(),
-		MessageFlag:      SetConfigMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.SetConfigMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -5,6 +5,7 @@ import (
 	"github.com/harveywangdao/road/database"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"net"
 	"time"
 )
@@ -21,6 +22,7 @@ const (
 	AesValidityDurationTime       = 24*60*60 - 10*60 /*24hours - 10minute*/
 	AesKeyOutOfDateTime           = 24 * 60 * 60
 	CheckAesKeyValidityTickerTime = 5 * time.Minute
+	ThingInfoSampleTickerTime     = 10 * time.Second
 	ConnectServerDelayTime        = 5 * time.Second
 )
 
@@ -33,20 +35,17 @@ type Thing struct {
 	Conn net.Conn
 
 	checkAesKeyValidityTicker *time.Ticker
+	thingInfoSampleTicker     *time.Ticker
 
-	register        Register
-	login           Login
-	relogin         ReLogin
-	heartbeat       Heartbeat
-	readConfig      ReadConfig
-	setConfig       SetConfig
-	thingControl    ThingControl
-	thingInfoUpload ThingInfoUpload
+	encoding int
+
+	apps map[string]interface{}
 }
 
 type ThingMessage struct {
-	Event int
-	Msg   *message.Message
+	Event   int
+	Msg     *message.Message
+	Payload interface{}
 }
 
 func (thing *Thing) GetAesKey() (string, error) {
@@ -67,6 +66,15 @@ func (thing *Thing) GetAesKey() (string, error) {
 	return thingaes128key, nil
 }
 
+func (thing *Thing) Encoding() int {
+	return thing.encoding
+}
+
+//SetEncoding chooses json or protobuf for the service data sent by this thing
+func (thing *Thing) SetEncoding(encoding int) {
+	thing.encoding = encoding
+}
+
 func (thing *Thing) GetBid() uint32 {
 	thingDB, err := database.GetDB(DBName)
 	if err != nil {
@@ -185,6 +193,7 @@ func (thing *Thing) tboxInit() error {
 	thing.ThingStatus = status
 
 	thing.checkAesKeyValidityTicker = time.NewTicker(CheckAesKeyValidityTickerTime)
+	thing.thingInfoSampleTicker = time.NewTicker(ThingInfoSampleTickerTime)
 
 	return nil
 }
@@ -247,9 +256,22 @@ func (thing *Thing) taskReadTcp() {
 			}
 		}
 
+		if !protocol.IsSupportedVersion(msg.MesHeader.ServiceVersion) {
+			logger.Error("Unsupported service version", msg.MesHeader.ServiceVersion)
+			continue
+		}
+
+		event := GetEventTypeByAidMid(msg.DisPatch.Aid, msg.DisPatch.Mid)
+
+		payload, err := decodeEventPayload(event, &msg)
+		if err != nil {
+			continue
+		}
+
 		e := ThingMessage{
-			Event: GetEventTypeByAidMid(msg.DisPatch.Aid, msg.DisPatch.Mid),
-			Msg:   &msg,
+			Event:   event,
+			Msg:     &msg,
+			Payload: payload,
 		}
 
 		thing.ThingMsgChan <- e
@@ -277,75 +299,12 @@ func (thing *Thing) eventDispatcher(thingMsg ThingMessage) error {
 	case EventServerClosed:
 		return errors.New("Server closed!")
 
-	case RegisterReqEventMessage:
-		thing.ThingStatus = ThingUnRegister
-		thing.SetThingStatusToDB(ThingUnRegister)
-		thing.register.RegisterReq(thing)
-
-	case RegisterAckEventMessage:
-		thing.register.RegisterACK(thing, thingMsg.Msg)
-
-	case EventLoginRequest:
-		thing.login.LoginRequest(thing)
-
-	case EventLoginChallenge:
-		thing.login.LoginChallenge(thing, thingMsg.Msg)
-
-	case EventLoginResponse:
-		thing.login.LoginResponse(thing, thingMsg.Msg)
-
-	case EventLoginSuccess:
-		thing.login.LoginSuccess(thing, thingMsg.Msg)
-		//thing.PushEventChannel(EventHeartbeatRequest, nil)
-		//thing.PushEventChannel(EventThingInfoUpload, nil)
-
-	case EventLoginFailure:
-		thing.login.LoginFailure(thing, thingMsg.Msg)
-
-	case EventReLoginRequest:
-		thing.relogin.ReLoginReq(thing, thingMsg.Msg)
-
-	case EventReLoginAck:
-		thing.relogin.ReLoginAck(thing, thingMsg.Msg)
-
-	case EventHeartbeatRequest:
-		thing.heartbeat.HeartbeatReq(thing)
-
-	case EventHeartbeatAck:
-		thing.heartbeat.HeartbeatAck(thing, thingMsg.Msg)
-
-	case EventReadConfigRequest:
-		thing.readConfig.ReadConfigReq(thing, thingMsg.Msg)
-
-	case EventReadConfigAck:
-		thing.readConfig.ReadConfigAck(thing, thingMsg.Msg)
-
-	case EventSetConfigRequest:
-		thing.setConfig.SetConfigReq(thing, thingMsg.Msg)
-
-	case EventSetConfigAck:
-		thing.setConfig.SetConfigAck(thing, thingMsg.Msg)
-
-	case EventRemoteOperationRequest:
-		thing.thingControl.RemoteOperationReq(thing, thingMsg.Msg)
-
-	case EventDispatcherAckMessage:
-		thing.thingControl.DispatcherAckMessage2(thing, thingMsg.Msg)
-
-	case EventRemoteOperationEnd:
-		thing.thingControl.RemoteOperationEnd(thing, thingMsg.Msg)
-
-	case EventRemoteOperationAck:
-		thing.thingControl.RemoteOperationAck(thing, thingMsg.Msg)
-
-	case EventThingInfoUpload:
-		thing.thingInfoUpload.ThingInfoUploadReq(thing)
-
-	case EventThingInfoUploadAck:
-		thing.thingInfoUpload.ThingInfoUploadAck(thing, thingMsg.Msg)
-
 	default:
-		logger.Error("Unknown event!")
+		if app, e, ok := apps.Lookup(thingMsg.Event); ok {
+			e.Handler(thing.apps[app.Name], thing, thingMsg)
+		} else {
+			logger.Error("Unknown event!")
+		}
 	}
 
 	return nil
@@ -378,6 +337,9 @@ func (thing *Thing) ThingScheduler() error {
 		case <-thing.checkAesKeyValidityTicker.C:
 			logger.Debug("----checkAesKeyValidityTicker----")
 			thing.checkAesKeyValidity()
+
+		case <-thing.thingInfoSampleTicker.C:
+			thing.PushEventChannel(EventThingInfoSample, nil)
 		}
 	}
 
@@ -403,6 +365,8 @@ func NewThing(thingMsgChan chan ThingMessage, ipport string, thingNo int) (*Thin
 	thing.IPPort = ipport
 	thing.ThingMsgChan = thingMsgChan
 	thing.ThingNo = thingNo
+	thing.encoding = protocol.EncodingJSON
+	thing.apps = newAppStates()
 
 	return &thing, nil
 }
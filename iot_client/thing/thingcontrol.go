@@ -1,32 +1,16 @@
 package thing
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	ThingControlTimeoutTime = 2 * time.Second
-
-	ThingControlMessageFlag = 0x1
-
-	RemoteOperationRequestAid = 0xF1
-	RemoteOperationRequestMid = 0x1
-
-	DispatcherAckMessageAid = 0xF1
-	DispatcherAckMessageMid = 0x2
-
-	RemoteOperationEndAid = 0xF1
-	RemoteOperationEndMid = 0x3
-
-	RemoteOperationAckAid = 0xF1
-	RemoteOperationAckMid = 0x4
-
-	RemoteOperationSuccess = 1
 )
 
 const (
@@ -37,6 +21,13 @@ const (
 	RemoteOperationAckStatus
 )
 
+const (
+	EventRemoteOperationRequest = protocol.MsgRemoteOperationRequest
+	EventDispatcherAckMessage   = protocol.MsgDispatcherAckMessage
+	EventRemoteOperationEnd     = protocol.MsgRemoteOperationEnd
+	EventRemoteOperationAck     = protocol.MsgRemoteOperationAck
+)
+
 type ThingControl struct {
 	timeoutTimer      *time.Timer
 	closeTimeoutTimer chan bool
@@ -45,67 +36,44 @@ type ThingControl struct {
 	operation          uint16
 }
 
-type RemoteOperationReqServData struct {
-	Operation          uint16 `json:"operation"`
-	OperationParameter int64  `json:"operationparameter"`
-}
-
-type DispatcherAckMessageServData struct {
-	Operation uint16 `json:"operation"`
-}
-
-type RemoteOperationEndServData struct {
-	Operation uint16 `json:"operation"`
-	Parameter int64  `json:"parameter"`
-}
-
-type RemoteOperationAckServData struct {
-	Operation uint16 `json:"operation"`
-	Status    byte   `json:"status"`
-	Parameter int64  `json:"parameter"`
-}
-
-const (
-	CentralLockOpen     = 0x00F1
-	CentralLockClose    = 0x00F2
-	WindowClose         = 0x00F4
-	WhistleAndFlash     = 0x00F5
-	AirConditionerOpen  = 0x00F6
-	AirConditionerClose = 0x00F7
-	EngineStart         = 0x00F8
-	EngineStop          = 0x00F9
-	SkyWindowOpen       = 0x00FA
-	SkyWindowClose      = 0x00FB
-	FrontDefrostStart   = 0x0001
-	FrontDefrostStop    = 0x0002
-	BackDefrostStart    = 0x0003
-	BackDefrostStop     = 0x0004
-	SeatheatStart       = 0x0005
-	SeatheatStop        = 0x0006
-	TwoFlashStart       = 0x0007
-	ThingDefence        = 0x0008
-	ThingUndefence      = 0x0009
-	EngineLock          = 0x000A
-	EngineUnlock        = 0x000B
-
-	UnknownRemoteOparetion = 0xFFFF
-)
-
-func (vc *ThingControl) convertOperation(op string) (uint16, error) {
-	switch op {
-	case "lock":
-		return EngineLock, nil
-	case "unlock":
-		return EngineUnlock, nil
-	case "defence":
-		return ThingDefence, nil
-	case "undefence":
-		return ThingUndefence, nil
-	default:
-		logger.Error("Unknown remote operation!")
-	}
-
-	return UnknownRemoteOparetion, errors.New("Unknown remote operation!")
+func init() {
+	RegisterApp(App{
+		Name:     "thingcontrol",
+		NewState: func() interface{} { return &ThingControl{} },
+		Events: []AppEvent{
+			{
+				Event: EventRemoteOperationRequest,
+				Name:  "EventRemoteOperationRequest",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).RemoteOperationReq(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventDispatcherAckMessage,
+				Name:  "EventDispatcherAckMessage",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).DispatcherAckMessage(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventRemoteOperationEnd,
+				Name:  "EventRemoteOperationEnd",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).RemoteOperationEnd(thing, thingMsg.Msg)
+				},
+			},
+			{
+				Event: EventRemoteOperationAck,
+				Name:  "EventRemoteOperationAck",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingControl).RemoteOperationAck(thing, thingMsg.Msg)
+				},
+			},
+		},
+		ErrorReply: func(state interface{}, thing *Thing, result uint8) error {
+			return state.(*ThingControl).ThingControlErrorReply(thing, result)
+		},
+	})
 }
 
 func (vc *ThingControl) RemoteOperationReq(thing *Thing, reqMsg *message.Message) error {
@@ -116,8 +84,8 @@ func (vc *ThingControl) RemoteOperationReq(thing *Thing, reqMsg *message.Message
 
 	vc.thingControlStatus = RemoteOperationReqStatus
 
-	remoteOperationReqServData := &RemoteOperationReqServData{}
-	err := json.Unmarshal(reqMsg.ServData, remoteOperationReqServData)
+	remoteOperationReqServData := &protocol.RemoteOperationReqServData{}
+	err := reqMsg.UnmarshalServData(remoteOperationReqServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -152,11 +120,11 @@ func (vc *ThingControl) dispatcherAckMessage1(thing *Thing, reqMsg *message.Mess
 	}
 
 	//Service data
-	dispatcherAckMessageServData := DispatcherAckMessageServData{
+	dispatcherAckMessageServData := protocol.DispatcherAckMessageServData{
 		Operation: vc.operation,
 	}
 
-	serviceData, err := json.Marshal(&dispatcherAckMessageServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &dispatcherAckMessageServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -180,8 +148,8 @@ func (vc *ThingControl) dispatcherAckMessage1(thing *Thing, reqMsg *message.Mess
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
-		Aid:                  DispatcherAckMessageAid,
-		Mid:                  DispatcherAckMessageMid,
+		Aid:                  protocol.DispatcherAckMessageAid,
+		Mid:                  protocol.DispatcherAckMessageMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -199,9 +167,9 @@ func (vc *ThingControl) dispatcherAckMessage1(thing *Thing, reqMsg *message.Mess
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              reqMsg.MesHeader.Bid,
-		MessageFlag:      ThingControlMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingControlMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -235,12 +203,12 @@ func (vc *ThingControl) RemoteOperationEnd(thing *Thing, reqMsg *message.Message
 	}
 
 	//Service data
-	remoteOperationEndServData := RemoteOperationEndServData{
+	remoteOperationEndServData := protocol.RemoteOperationEndServData{
 		Operation: vc.operation,
 		Parameter: 0, //need fix
 	}
 
-	serviceData, err := json.Marshal(&remoteOperationEndServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &remoteOperationEndServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -264,8 +232,8 @@ func (vc *ThingControl) RemoteOperationEnd(thing *Thing, reqMsg *message.Message
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
-		Aid:                  RemoteOperationEndAid,
-		Mid:                  RemoteOperationEndMid,
+		Aid:                  protocol.RemoteOperationEndAid,
+		Mid:                  protocol.RemoteOperationEndMid,
 		MessageCounter:       reqMsg.DisPatch.MessageCounter + 2,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -283,9 +251,9 @@ func (vc *ThingControl) RemoteOperationEnd(thing *Thing, reqMsg *message.Message
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              reqMsg.MesHeader.Bid,
-		MessageFlag:      ThingControlMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingControlMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -326,14 +294,14 @@ func (vc *ThingControl) RemoteOperationEnd(thing *Thing, reqMsg *message.Message
 	return nil
 }
 
-func (vc *ThingControl) DispatcherAckMessage2(thing *Thing, ackMsg *message.Message) error {
+func (vc *ThingControl) DispatcherAckMessage(thing *Thing, ackMsg *message.Message) error {
 	if vc.thingControlStatus == RemoteOperationEndStatus {
 		vc.timeoutTimer.Stop()
 
 		//vc.thingControlStatus = DispatcherAckMessageStatus
 
-		dispatcherAckMessageServData := &DispatcherAckMessageServData{}
-		err := json.Unmarshal(ackMsg.ServData, dispatcherAckMessageServData)
+		dispatcherAckMessageServData := &protocol.DispatcherAckMessageServData{}
+		err := ackMsg.UnmarshalServData(dispatcherAckMessageServData)
 		if err != nil {
 			logger.Error(err)
 			return err
@@ -352,8 +320,8 @@ func (vc *ThingControl) DispatcherAckMessage2(thing *Thing, ackMsg *message.Mess
 	} else if vc.thingControlStatus == RemoteOperationAckStatus {
 		vc.timeoutTimer.Stop()
 
-		dispatcherAckMessageServData := &DispatcherAckMessageServData{}
-		err := json.Unmarshal(ackMsg.ServData, dispatcherAckMessageServData)
+		dispatcherAckMessageServData := &protocol.DispatcherAckMessageServData{}
+		err := ackMsg.UnmarshalServData(dispatcherAckMessageServData)
 		if err != nil {
 			logger.Error(err)
 			return err
@@ -384,13 +352,13 @@ func (vc *ThingControl) RemoteOperationAck(thing *Thing, ackMsg *message.Message
 	}
 
 	//Service data
-	remoteOperationAckServData := RemoteOperationAckServData{
+	remoteOperationAckServData := protocol.RemoteOperationAckServData{
 		Operation: vc.operation,
-		Status:    RemoteOperationSuccess,
+		Status:    protocol.RemoteOperationSuccess,
 		Parameter: 0,
 	}
 
-	serviceData, err := json.Marshal(&remoteOperationAckServData)
+	serviceData, err := protocol.Marshal(thing.Encoding(), &remoteOperationAckServData)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -414,8 +382,8 @@ func (vc *ThingControl) RemoteOperationAck(thing *Thing, ackMsg *message.Message
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    ackMsg.DisPatch.EventCreationTime,
-		Aid:                  RemoteOperationAckAid,
-		Mid:                  RemoteOperationAckMid,
+		Aid:                  protocol.RemoteOperationAckAid,
+		Mid:                  protocol.RemoteOperationAckMid,
 		MessageCounter:       ackMsg.DisPatch.MessageCounter + 1,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -433,9 +401,9 @@ func (vc *ThingControl) RemoteOperationAck(thing *Thing, ackMsg *message.Message
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              ackMsg.MesHeader.Bid,
-		MessageFlag:      ThingControlMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingControlMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -457,3 +425,19 @@ func (vc *ThingControl) RemoteOperationAck(thing *Thing, ackMsg *message.Message
 
 	return nil
 }
+
+func (vc *ThingControl) ThingControlErrorReply(thing *Thing, result uint8) error {
+	if vc.thingControlStatus == ThingControlStop {
+		logger.Error("ThingControl not start!")
+		return errors.New("ThingControl not start!")
+	}
+
+	if vc.thingControlStatus == RemoteOperationEndStatus || vc.thingControlStatus == RemoteOperationAckStatus {
+		vc.timeoutTimer.Stop()
+		vc.closeTimeoutTimer <- true
+	}
+
+	vc.thingControlStatus = ThingControlStop
+
+	return nil
+}
@@ -2,29 +2,51 @@ package thing
 
 import (
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/protocol"
 	//"github.com/harveywangdao/road/util"
 	"time"
 )
 
-type ThingInfor struct {
-	Version uint32
-	Time    uint32
-
-	IsLocation uint8
-	Latitude   uint32
-	Longitude  uint32
-	Heading    uint16
-	Speed      uint16
-}
-
-func (info *ThingInfor) GetThingInfor() *ThingInfor {
+func GetThingInfor() *protocol.ThingInfor {
+	info := &protocol.ThingInfor{}
 	info.Version = 1235
 	info.Time = uint32(time.Now().Unix())
-	info.IsLocation = 77
-	info.Latitude = 88
-	info.Longitude = 99
-	info.Heading = 00
-	info.Speed = 55
+	info.IsLocation = 1
+	info.Latitude = protocol.EncodeLatitude(31.230416)
+	info.Longitude = protocol.EncodeLongitude(121.473701)
+	info.Heading = 90 * protocol.HeadingScale
+	info.Speed = 55 * protocol.SpeedScale
+
+	info.TelemetryVersion = protocol.TelemetryVersion
+	info.Energy = &protocol.EnergyInfor{
+		FuelLevel:      66,
+		BatterySoc:     80,
+		Odometer:       123456,
+		BatteryVoltage: 1260,
+	}
+	info.Body = &protocol.BodyInfor{
+		Doors:   0,
+		Windows: 0,
+		Locks:   protocol.BodyFrontLeft | protocol.BodyFrontRight | protocol.BodyRearLeft | protocol.BodyRearRight,
+	}
+	info.Tyres = []protocol.TyreInfor{
+		{Position: protocol.TyreFrontLeft, Pressure: 240, Temperature: 25},
+		{Position: protocol.TyreFrontRight, Pressure: 240, Temperature: 25},
+		{Position: protocol.TyreRearLeft, Pressure: 230, Temperature: 24},
+		{Position: protocol.TyreRearRight, Pressure: 230, Temperature: 24},
+	}
+	info.Engine = &protocol.EngineInfor{
+		Status:             protocol.EngineRunning,
+		Rpm:                2000,
+		CoolantTemperature: 90,
+	}
+	info.Gnss = &protocol.GnssInfor{
+		Fix:        protocol.Gnss3DFix,
+		Satellites: 9,
+		Hdop:       12,
+		Altitude:   45 * protocol.AltitudeScale,
+	}
+
 	logger.Debug("ThingInfor =", *info)
 	return info
 }
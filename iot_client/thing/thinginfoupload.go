@@ -1,23 +1,18 @@
 package thing
 
 import (
-	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/log/logger"
 	"github.com/harveywangdao/road/message"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
 
 const (
 	ThingInfoUploadTimeoutTime = 5 * time.Second
-	ThingInfoUploadMessageFlag = 0x1
-
-	ThingInfoUploadAid = 0xF5
-	ThingInfoUploadMid = 0x4
-
-	ThingInfoUploadAckAid = 0xF5
-	ThingInfoUploadAckMid = 0x2
+	ThingInfoBufferSize        = 1000 //oldest samples are dropped when the buffer is full
+	ThingInfoBatchSize         = 50
 )
 
 const (
@@ -25,37 +20,115 @@ const (
 	ThingInfoUploadStatus
 )
 
+const (
+	EventThingInfoSample    = 0x0004 //internal, pushed by the sample ticker
+	EventThingInfoUpload    = protocol.MsgThingInfoBatchUpload
+	EventThingInfoUploadAck = protocol.MsgThingInfoBatchUploadAck
+)
+
 type ThingInfoUpload struct {
 	thingInfoUploadTimer      *time.Timer
 	closeThingInfoUploadTimer chan bool
 
 	thingInfoUploadStatus int
+
+	samples []protocol.ThingInfor //kept until acked, in the order they were taken
+	sent    int                   //samples at the front of samples in the batch not yet acked
 }
 
+func init() {
+	RegisterApp(App{
+		Name:     "thinginfoupload",
+		NewState: func() interface{} { return &ThingInfoUpload{} },
+		Events: []AppEvent{
+			{
+				Event: EventThingInfoSample,
+				Name:  "EventThingInfoSample",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingInfoUpload).ThingInfoSample(thing)
+				},
+			},
+			{
+				Event: EventThingInfoUpload,
+				Name:  "EventThingInfoUpload",
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingInfoUpload).ThingInfoUploadReq(thing)
+				},
+			},
+			{
+				Event:   EventThingInfoUploadAck,
+				Name:    "EventThingInfoUploadAck",
+				Payload: func() interface{} { return &protocol.ThingInfoBatchAckServData{} },
+				Handler: func(state interface{}, thing *Thing, thingMsg ThingMessage) error {
+					return state.(*ThingInfoUpload).ThingInfoUploadAck(thing, thingMsg.Payload.(*protocol.ThingInfoBatchAckServData))
+				},
+			},
+		},
+		ErrorReply: func(state interface{}, thing *Thing, result uint8) error {
+			return state.(*ThingInfoUpload).ThingInfoUploadErrorReply(thing, result)
+		},
+	})
+}
+
+//ThingInfoSample buffers a sample, it is uploaded right away when logined and idle
+func (upload *ThingInfoUpload) ThingInfoSample(thing *Thing) error {
+	upload.samples = append(upload.samples, *GetThingInfor())
+
+	if len(upload.samples) > ThingInfoBufferSize {
+		drop := len(upload.samples) - ThingInfoBufferSize
+		logger.Warn("ThingInfo buffer full, drop", drop, "samples")
+		upload.samples = upload.samples[drop:]
+
+		upload.sent -= drop
+		if upload.sent < 0 {
+			upload.sent = 0
+		}
+	}
+
+	if upload.thingInfoUploadStatus != ThingInfoUploadStop {
+		return nil
+	}
+
+	return upload.ThingInfoUploadReq(thing)
+}
+
+//ThingInfoUploadReq sends the oldest buffered samples as one batch
 func (upload *ThingInfoUpload) ThingInfoUploadReq(thing *Thing) error {
 	if upload.thingInfoUploadStatus != ThingInfoUploadStop {
 		logger.Error("ThingInfoUpload already start!")
 		return errors.New("ThingInfoUpload already start!")
 	}
 
-	upload.thingInfoUploadStatus = ThingInfoUploadStatus
+	if thing.ThingStatus != ThingRegisteredLogined {
+		logger.Debug("Not login, keep", len(upload.samples), "samples")
+		return nil
+	}
+
+	if len(upload.samples) == 0 {
+		return nil
+	}
+
+	n := len(upload.samples)
+	if n > ThingInfoBatchSize {
+		n = ThingInfoBatchSize
+	}
+
+	batch := &protocol.ThingInforBatch{
+		Samples: upload.samples[:n],
+	}
 
 	msg := message.Message{
 		Connection: thing.Conn,
 	}
 
 	//Service data
-	thingInfor := ThingInfor{}
-	thingInfor.GetThingInfor()
-
-	serviceData, err := json.Marshal(&thingInfor)
+	serviceData, err := protocol.Marshal(thing.Encoding(), batch)
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
 	logger.Debug("serviceData =", serviceData)
-	logger.Info("serviceDataJson =", string(serviceData))
 
 	aesKey, err := thing.GetAesKey()
 	if err != nil {
@@ -72,8 +145,8 @@ func (upload *ThingInfoUpload) ThingInfoUploadReq(thing *Thing) error {
 	//Dispatch data
 	dd := message.DispatchData{
 		EventCreationTime:    uint32(time.Now().Unix()),
-		Aid:                  ThingInfoUploadAid,
-		Mid:                  ThingInfoUploadMid,
+		Aid:                  protocol.ThingInfoBatchUploadAid,
+		Mid:                  protocol.ThingInfoBatchUploadMid,
 		MessageCounter:       0,
 		ServiceDataLength:    uint16(len(encryptServData)),
 		Result:               0,
@@ -91,9 +164,9 @@ func (upload *ThingInfoUpload) ThingInfoUploadReq(thing *Thing) error {
 	mh := message.MessageHeader{
 		FixHeader:        message.MessageHeaderID,
 		ServiceDataCheck: util.DataXOR(serviceData),
-		ServiceVersion:   0x0, //not sure
+		ServiceVersion:   protocol.ServiceVersion,
 		Bid:              thing.GetBid(),
-		MessageFlag:      ThingInfoUploadMessageFlag,
+		MessageFlag:      protocol.FlagWithEncoding(protocol.ThingInfoUploadMessageFlag, thing.Encoding()),
 	}
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -109,7 +182,11 @@ func (upload *ThingInfoUpload) ThingInfoUploadReq(thing *Thing) error {
 		return err
 	}
 
-	logger.Debug("Send ThingInfoUpload Success---")
+	logger.Debug("Send ThingInfoUpload Success---, samples =", n)
+
+	upload.sent = n
+
+	upload.thingInfoUploadStatus = ThingInfoUploadStatus
 
 	upload.thingInfoUploadTimer = time.NewTimer(ThingInfoUploadTimeoutTime)
 
@@ -136,7 +213,9 @@ func (upload *ThingInfoUpload) ThingInfoUploadReq(thing *Thing) error {
 	return nil
 }
 
-func (upload *ThingInfoUpload) ThingInfoUploadAck(thing *Thing, ackMsg *message.Message) error {
+//ThingInfoUploadAck drops the samples the server published and sends the next batch. Samples are dropped
+//by count, not by time, the clock of the thing may step back
+func (upload *ThingInfoUpload) ThingInfoUploadAck(thing *Thing, ack *protocol.ThingInfoBatchAckServData) error {
 	if upload.thingInfoUploadStatus != ThingInfoUploadStatus {
 		logger.Error("Need ThingInfoUpload!")
 		return errors.New("Need ThingInfoUpload!")
@@ -147,5 +226,35 @@ func (upload *ThingInfoUpload) ThingInfoUploadAck(thing *Thing, ackMsg *message.
 
 	upload.thingInfoUploadStatus = ThingInfoUploadStop
 
+	n := int(ack.Count)
+	if n > upload.sent {
+		n = upload.sent
+	}
+	upload.samples = upload.samples[n:]
+	upload.sent = 0
+
+	logger.Debug("ThingInfoUpload ack, LastTime =", ack.LastTime, "Count =", ack.Count, "left =", len(upload.samples))
+
+	if len(upload.samples) > 0 {
+		thing.PushEventChannel(EventThingInfoUpload, nil)
+	}
+
+	return nil
+}
+
+func (upload *ThingInfoUpload) ThingInfoUploadErrorReply(thing *Thing, result uint8) error {
+	if upload.thingInfoUploadStatus != ThingInfoUploadStatus {
+		logger.Error("Need ThingInfoUpload!")
+		return errors.New("Need ThingInfoUpload!")
+	}
+
+	//other results retry when the timer coming
+	if result == message.ResultNotLogin {
+		upload.thingInfoUploadTimer.Stop()
+		upload.closeThingInfoUploadTimer <- true
+
+		upload.thingInfoUploadStatus = ThingInfoUploadStop
+	}
+
 	return nil
 }
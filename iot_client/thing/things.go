@@ -2,12 +2,14 @@ package thing
 
 import (
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/protocol"
 	"sync"
 )
 
 const (
-	maxGoroutineNum = 1
-	IPPort          = "127.0.0.1:6024"
+	maxGoroutineNum  = 1
+	IPPort           = "127.0.0.1:6024"
+	ServDataEncoding = "json" //json or protobuf
 )
 
 func thingConnHandler(wg sync.WaitGroup, thingNo int) {
@@ -21,6 +23,13 @@ func thingConnHandler(wg sync.WaitGroup, thingNo int) {
 		return
 	}
 
+	encoding, err := protocol.ParseEncoding(ServDataEncoding)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	thing.SetEncoding(encoding)
+
 	err = thing.ThingScheduler()
 	if err != nil {
 		logger.Error(err)
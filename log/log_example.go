@@ -2,9 +2,8 @@ package main
 
 import (
 	"fmt"
-	"github.com/harveywangdao/road/log/log"
 	"github.com/harveywangdao/road/log/logger"
-	//"log"
+	"log"
 	//"os"
 )
 
@@ -12,14 +11,18 @@ func main() {
 	a := 1
 	b := 2
 	c := a + b
-	/*	log.Debug("c = %v", c)
-		log.Warn("c = %v", c)
-		log.Info("c = %v", c)
-		log.Error("c = %v", c)
-		log.Fatal("c = %v", c)
-		fmt.Println("-------End-------")*/
 
-	rotatingHandler := logger.NewRotatingHandler(".", "test.log", 4, 4*1024*1024)
+	rotatingHandler, err := logger.NewRotatingHandlerWithConfig(&logger.RotatingConfig{
+		Filename: "test.log",
+		Rotate:   logger.RotateDaily,
+		MaxSize:  4 * 1024 * 1024,
+		Compress: true,
+		MaxDays:  7,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	logger.SetHandlers(logger.Console, rotatingHandler)
 
@@ -34,6 +37,16 @@ func main() {
 	logger.Error("This is a log!", b)
 	logger.Info("This is a log!", c)
 	logger.Warn("This is a log!", a)
+
+	thingLog := logger.With("thingid", "WDDUX52684DFR4582", "bid", 3)
+	thingLog.Info("This is a log with fields!", c)
+
+	logger.SetPackageLevel("main", logger.INFO)
+	logger.Debug("This is not logged, main is INFO now", a)
+
+	logger.SetEncoder(&logger.JSONEncoder{})
+	thingLog.Warn("This is a JSON log!", b)
+
 	logger.Print("This is a log!", a)
 	logger.Printf("This is a log! b = %v", b)
 	logger.Println("This is a log!", c)
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+const (
+	EncodingConsole = "console"
+	EncodingJSON    = "json"
+)
+
+//Config is the optional logger config of the services, like
+//{"level": "info", "encoding": "json", "packages": {"iot/gateway": "debug"},
+// "file": {"dir": "/var/log/road", "filename": "iot.log", "rotate": "daily", "compress": true, "maxdays": 7}}
+type Config struct {
+	Level    string            `json:"level"`
+	Encoding string            `json:"encoding"`
+	Color    bool              `json:"color"` //console only
+	Packages map[string]string `json:"packages"`
+	File     *RotatingConfig   `json:"file"` //also log to this file, reopened on SIGHUP
+}
+
+//Apply sets the encoder of all handlers and the levels, empty fields keep the current setting.
+//Call it once, every call with File adds a handler
+func (config *Config) Apply() error {
+	var level Level
+	var err error
+	if config.Level != "" {
+		level, err = ParseLevel(config.Level)
+		if err != nil {
+			return err
+		}
+	}
+
+	packages := make(map[string]Level)
+	for pkg, s := range config.Packages {
+		packages[pkg], err = ParseLevel(s)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch config.Encoding {
+	case "", EncodingConsole, EncodingJSON:
+	default:
+		return fmt.Errorf("unknown log encoding %q", config.Encoding)
+	}
+
+	if config.File != nil {
+		h, err := NewRotatingHandlerWithConfig(config.File)
+		if err != nil {
+			return err
+		}
+		if len(logger.handlers) > 0 {
+			h.SetFlags(logger.handlers[0].Flags())
+		}
+
+		SetHandlers(append(logger.handlers, h)...)
+		ReopenOnSignal()
+	}
+
+	switch config.Encoding {
+	case EncodingConsole:
+		SetEncoder(&ConsoleEncoder{Color: config.Color})
+	case EncodingJSON:
+		SetEncoder(&JSONEncoder{})
+	}
+
+	if config.Level != "" {
+		SetLevel(level)
+	}
+	for pkg, level := range packages {
+		SetPackageLevel(pkg, level)
+	}
+
+	return nil
+}
+
+//LoadConfig reads a JSON Config and applies it
+func LoadConfig(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		Error(err)
+		return err
+	}
+
+	config := &Config{}
+	err = json.Unmarshal(data, config)
+	if err != nil {
+		Error(err)
+		return err
+	}
+
+	err = config.Apply()
+	if err != nil {
+		Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Encoder writes one entry as one line, flags are the log package flags of the handler
+type Encoder interface {
+	Encode(buf *bytes.Buffer, e *Entry, flags int)
+}
+
+var levelColors = []string{"\033[34m", "\033[32m", "\033[33m", "\033[31m"}
+
+//ConsoleEncoder keeps the format of the log package, fields follow the message as key=value
+type ConsoleEncoder struct {
+	Color bool
+}
+
+func (enc *ConsoleEncoder) Encode(buf *bytes.Buffer, e *Entry, flags int) {
+	if enc.Color && int(e.Level) < len(levelColors) {
+		buf.WriteString("[" + levelColors[e.Level] + e.Level.String() + "\033[0m] ")
+	} else {
+		buf.WriteString("[" + e.Level.String() + "] ")
+	}
+
+	t := e.Time
+	if flags&log.LUTC != 0 {
+		t = t.UTC()
+	}
+	if flags&log.Ldate != 0 {
+		buf.WriteString(t.Format("2006/01/02 "))
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		if flags&log.Lmicroseconds != 0 {
+			buf.WriteString(t.Format("15:04:05.000000 "))
+		} else {
+			buf.WriteString(t.Format("15:04:05 "))
+		}
+	}
+	if caller := callerOf(e, flags); caller != "" {
+		buf.WriteString(caller + ": ")
+	}
+
+	buf.WriteString(e.Message)
+
+	for _, f := range e.Fields {
+		buf.WriteString(" " + f.Key + "=" + consoleValue(f.Value))
+	}
+
+	buf.WriteByte('\n')
+}
+
+func consoleValue(v interface{}) string {
+	var s string
+	switch value := v.(type) {
+	case string:
+		s = value
+	case error:
+		s = value.Error()
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+//JSONEncoder writes an object per line with time, level, pkg, caller and msg first, then the fields
+type JSONEncoder struct{}
+
+func (enc *JSONEncoder) Encode(buf *bytes.Buffer, e *Entry, flags int) {
+	t := e.Time
+	if flags&log.LUTC != 0 {
+		t = t.UTC()
+	}
+
+	buf.WriteString(`{"time":`)
+	writeJSON(buf, t.Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSON(buf, e.Level.String())
+	buf.WriteString(`,"pkg":`)
+	writeJSON(buf, e.Package)
+	if caller := callerOf(e, flags); caller != "" {
+		buf.WriteString(`,"caller":`)
+		writeJSON(buf, caller)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSON(buf, e.Message)
+
+	for _, f := range e.Fields {
+		buf.WriteByte(',')
+		writeJSON(buf, f.Key)
+		buf.WriteByte(':')
+		if err, ok := f.Value.(error); ok {
+			writeJSON(buf, err.Error())
+		} else {
+			writeJSON(buf, f.Value)
+		}
+	}
+
+	buf.WriteString("}\n")
+}
+
+//writeJSON falls back to the printed value for what json can not encode
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(data)
+}
+
+//callerOf is file:line like log.Lshortfile and log.Llongfile, empty without both
+func callerOf(e *Entry, flags int) string {
+	if flags&(log.Lshortfile|log.Llongfile) == 0 || e.File == "" {
+		return ""
+	}
+
+	file := e.File
+	if flags&log.Lshortfile != 0 {
+		file = filepath.Base(file)
+	}
+	return file + ":" + strconv.Itoa(e.Line)
+}
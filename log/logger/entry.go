@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+//modulePath is cut from package names, so levels are set as "iot/gateway"
+const modulePath = "github.com/harveywangdao/road/"
+
+const badKey = "!BADKEY"
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+func (level Level) String() string {
+	if level >= DEBUG && int(level) < len(levelNames) {
+		return levelNames[level]
+	}
+	return fmt.Sprintf("Level(%d)", int32(level))
+}
+
+//ParseLevel takes the names of String, in any case
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return DEBUG, fmt.Errorf("unknown log level %q", s)
+}
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+//Entry is one log record as handed to the encoders
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Package string
+	File    string
+	Line    int
+	Message string
+	Fields  []Field
+}
+
+//appendFields adds key/value pairs, a key already in fields gets the new value
+func appendFields(fields []Field, kv []interface{}) []Field {
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields = append(fields, Field{Key: badKey, Value: kv[i]})
+			break
+		}
+
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+
+		replaced := false
+		for j := range fields {
+			if fields[j].Key == key {
+				fields[j].Value = kv[i+1]
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			fields = append(fields, Field{Key: key, Value: kv[i+1]})
+		}
+	}
+
+	return fields
+}
+
+var packageNames sync.Map
+
+//packageOf returns the package of the function at pc, without modulePath
+func packageOf(pc uintptr) string {
+	if name, ok := packageNames.Load(pc); ok {
+		return name.(string)
+	}
+
+	pkg := "?"
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		pkg = fn.Name()
+		slash := strings.LastIndex(pkg, "/")
+		if dot := strings.Index(pkg[slash+1:], "."); dot >= 0 {
+			pkg = pkg[:slash+1+dot]
+		}
+		pkg = strings.TrimPrefix(pkg, modulePath)
+	}
+
+	packageNames.Store(pc, pkg)
+	return pkg
+}
+
+//Logger adds its fields to every entry, the gateway keeps one per thing
+type Logger struct {
+	fields []Field
+}
+
+var root = &Logger{}
+
+//With returns a child logger, kv are key/value pairs like "thingid", thingid
+func With(kv ...interface{}) *Logger {
+	return root.With(kv...)
+}
+
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]Field, len(l.fields), len(l.fields)+len(kv)/2+1)
+	copy(fields, l.fields)
+
+	return &Logger{
+		fields: appendFields(fields, kv),
+	}
+}
+
+//log builds the entry for the caller skip frames up and hands it to the handlers
+func (l *Logger) log(skip int, level Level, v []interface{}) {
+	tracing := l.Tracing()
+	if level < minLevel() && !tracing {
+		return
+	}
+
+	pc, file, line, ok := runtime.Caller(skip)
+	pkg := "?"
+	if ok {
+		pkg = packageOf(pc)
+	}
+
+	if level < levelOf(pkg) && !tracing {
+		return
+	}
+
+	e := &Entry{
+		Time:    time.Now(),
+		Level:   level,
+		Package: pkg,
+		File:    file,
+		Line:    line,
+		Message: strings.TrimSuffix(fmt.Sprintln(v...), "\n"),
+		Fields:  l.fields,
+	}
+
+	for i := range logger.handlers {
+		logger.handlers[i].Log(e)
+	}
+}
+
+func (l *Logger) Debug(v ...interface{}) {
+	l.log(2, DEBUG, v)
+}
+
+func (l *Logger) Info(v ...interface{}) {
+	l.log(2, INFO, v)
+}
+
+func (l *Logger) Warn(v ...interface{}) {
+	l.log(2, WARN, v)
+}
+
+func (l *Logger) Error(v ...interface{}) {
+	l.log(2, ERROR, v)
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	LevelPath = "/debug/loglevel"
+	TracePath = "/debug/trace"
+)
+
+type levelState struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages,omitempty"`
+}
+
+func currentLevels() *levelState {
+	state := &levelState{
+		Level:    GetLevel().String(),
+		Packages: make(map[string]string),
+	}
+	for pkg, level := range PackageLevels() {
+		state.Packages[pkg] = level.String()
+	}
+
+	return state
+}
+
+//LevelHandler returns the levels on GET and changes them on POST or PUT:
+//
+//	?level=debug                  SetLevel
+//	?pkg=iot/gateway&level=debug  SetPackageLevel
+//	?pkg=iot/gateway&level=       ResetPackageLevel
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+
+		case http.MethodPost, http.MethodPut:
+			query := r.URL.Query()
+			pkg := query.Get("pkg")
+
+			if pkg != "" && query.Get("level") == "" {
+				ResetPackageLevel(pkg)
+			} else {
+				level, err := ParseLevel(query.Get("level"))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				if pkg == "" {
+					SetLevel(level)
+				} else {
+					SetPackageLevel(pkg, level)
+				}
+			}
+
+			Warn("Log level changed by", r.RemoteAddr, r.URL.RawQuery)
+
+		default:
+			http.Error(w, "GET, POST or PUT", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeResponse(w, currentLevels())
+	})
+}
+
+//traceQuery takes the one key=value of the query besides for
+func traceQuery(r *http.Request) (string, string, time.Duration, error) {
+	query := r.URL.Query()
+
+	var d time.Duration
+	if s := query.Get("for"); s != "" {
+		var err error
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return "", "", 0, err
+		}
+		query.Del("for")
+	}
+
+	if len(query) != 1 {
+		return "", "", 0, errors.New("want one field like thingid=WDDUX52684DFR4582")
+	}
+
+	for key := range query {
+		value := query.Get(key)
+		if value == "" {
+			break
+		}
+		return key, value, d, nil
+	}
+
+	return "", "", 0, errors.New("empty field value")
+}
+
+//TraceHandler returns the traces on GET, POST ?thingid=X&for=10m turns a trace on and DELETE ?thingid=X off.
+//Any field of a child logger works, like bid or remoteaddr, without for the trace stays until DELETE
+func TraceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+
+		case http.MethodPost, http.MethodPut, http.MethodDelete:
+			key, value, d, err := traceQuery(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if r.Method == http.MethodDelete {
+				TraceOff(key, value)
+			} else {
+				TraceOn(key, value, d)
+			}
+
+			Warn("Trace changed by", r.RemoteAddr, r.Method, r.URL.RawQuery)
+
+		default:
+			http.Error(w, "GET, POST, PUT or DELETE", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeResponse(w, Traces())
+	})
+}
+
+func writeResponse(w http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
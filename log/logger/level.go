@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//levels are read on every log call and may be changed while logging
+var levels = struct {
+	mu       sync.RWMutex
+	level    Level
+	packages map[string]Level
+	min      int32 //lowest of level and packages, checked before the caller is looked up
+}{
+	level:    DEBUG,
+	packages: make(map[string]Level),
+	min:      int32(DEBUG),
+}
+
+func minLevel() Level {
+	return Level(atomic.LoadInt32(&levels.min))
+}
+
+//updateMinLevel must be called with levels.mu held
+func updateMinLevel() {
+	min := levels.level
+	for _, level := range levels.packages {
+		if level < min {
+			min = level
+		}
+	}
+	atomic.StoreInt32(&levels.min, int32(min))
+}
+
+func SetLevel(level Level) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	levels.level = level
+	updateMinLevel()
+}
+
+func GetLevel() Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	return levels.level
+}
+
+//SetPackageLevel sets the level of pkg and its sub packages, pkg is like "iot/gateway" or "msgqueue"
+func SetPackageLevel(pkg string, level Level) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	levels.packages[strings.TrimPrefix(pkg, modulePath)] = level
+	updateMinLevel()
+}
+
+//ResetPackageLevel makes pkg follow its parent package or SetLevel again
+func ResetPackageLevel(pkg string) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	delete(levels.packages, strings.TrimPrefix(pkg, modulePath))
+	updateMinLevel()
+}
+
+//levelOf returns the level of the nearest package with its own level
+func levelOf(pkg string) Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	if len(levels.packages) == 0 {
+		return levels.level
+	}
+
+	for p := pkg; ; {
+		if level, ok := levels.packages[p]; ok {
+			return level
+		}
+
+		i := strings.LastIndex(p, "/")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+
+	return levels.level
+}
+
+//PackageLevels returns the levels set by SetPackageLevel
+func PackageLevels() map[string]Level {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	packages := make(map[string]Level, len(levels.packages))
+	for pkg, level := range levels.packages {
+		packages[pkg] = level
+	}
+
+	return packages
+}
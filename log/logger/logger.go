@@ -1,12 +1,12 @@
 package logger
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"sync"
-	"time"
 )
 
 type Level int32
@@ -18,26 +18,6 @@ const (
 	ERROR
 )
 
-/*
-===================
- utils functions
-===================
-*/
-func fileSize(file string) int64 {
-	fmt.Println("fileSize", file)
-	f, e := os.Stat(file)
-	if e != nil {
-		fmt.Println(e.Error())
-		return 0
-	}
-	return f.Size()
-}
-
-func isExist(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil || os.IsExist(err)
-}
-
 /*
 ===================
  log handlers
@@ -53,12 +33,8 @@ type Handler interface {
 	Fatalf(format string, v ...interface{})
 	Fatalln(v ...interface{})
 
-	Debug(v ...interface{})
-	Info(v ...interface{})
-	Warn(v ...interface{})
-	Error(v ...interface{})
-
-	ErrorD(calldepth int, v ...interface{})
+	Log(e *Entry)
+	SetEncoder(enc Encoder)
 
 	Flags() int
 	SetFlags(flag int)
@@ -67,12 +43,14 @@ type Handler interface {
 	close()
 }
 
+//LogHandler writes entries with its encoder, Print and Fatal still go through lg
 type LogHandler struct {
 	lg      *log.Logger
-	lgDebug *log.Logger
-	lgInfo  *log.Logger
-	lgWarn  *log.Logger
-	lgError *log.Logger
+	out     io.Writer
+	flags   int
+	encoder Encoder
+	buf     bytes.Buffer
+	mu      sync.Mutex
 }
 
 type ConsoleHander struct {
@@ -84,100 +62,39 @@ type FileHandler struct {
 	logfile *os.File
 }
 
-type RotatingHandler struct {
-	LogHandler
-	dir      string
-	filename string
-	maxNum   int
-	maxSize  int64
-	suffix   int
-	logfile  *os.File
-	mu       sync.Mutex
-}
-
 var Console = NewConsoleHandler()
 
+func (l *LogHandler) init(w io.Writer, enc Encoder) {
+	l.lg = log.New(w, "", log.LstdFlags)
+	l.out = w
+	l.flags = log.LstdFlags
+	l.encoder = enc
+}
+
 func NewConsoleHandler() *ConsoleHander {
-	lg := log.New(os.Stderr, "", log.LstdFlags)
-	lgDebug := log.New(os.Stderr, "[DEBUG] ", log.LstdFlags)
-	lgInfo := log.New(os.Stderr, "[INFO] ", log.LstdFlags)
-	lgWarn := log.New(os.Stderr, "[WARN] ", log.LstdFlags)
-	lgError := log.New(os.Stderr, "[ERROR] ", log.LstdFlags)
-	return &ConsoleHander{
-		LogHandler: LogHandler{
-			lg:      lg,
-			lgDebug: lgDebug,
-			lgInfo:  lgInfo,
-			lgWarn:  lgWarn,
-			lgError: lgError,
-		}}
-}
-
-func NewFileHandler(filepath string) *FileHandler {
-	logfile, _ := os.OpenFile(filepath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
-	lg := log.New(logfile, "", log.LstdFlags)
-	lgDebug := log.New(logfile, "[DEBUG] ", log.LstdFlags)
-	lgInfo := log.New(logfile, "[INFO] ", log.LstdFlags)
-	lgWarn := log.New(logfile, "[WARN] ", log.LstdFlags)
-	lgError := log.New(logfile, "[ERROR] ", log.LstdFlags)
-	return &FileHandler{
-		LogHandler: LogHandler{
-			lg:      lg,
-			lgDebug: lgDebug,
-			lgInfo:  lgInfo,
-			lgWarn:  lgWarn,
-			lgError: lgError,
-		},
-		logfile: logfile,
-	}
+	h := &ConsoleHander{}
+	h.init(os.Stderr, &ConsoleEncoder{})
+	return h
 }
 
-func NewRotatingHandler(dir string, filename string, maxNum int, maxSize int64) *RotatingHandler {
-	logfile, _ := os.OpenFile(dir+"/"+filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
-	lg := log.New(logfile, "", log.LstdFlags)
-	lgDebug := log.New(logfile, "[DEBUG] ", log.LstdFlags)
-	lgInfo := log.New(logfile, "[INFO] ", log.LstdFlags)
-	lgWarn := log.New(logfile, "[WARN] ", log.LstdFlags)
-	lgError := log.New(logfile, "[ERROR] ", log.LstdFlags)
-
-	h := &RotatingHandler{
-		LogHandler: LogHandler{
-			lg:      lg,
-			lgDebug: lgDebug,
-			lgInfo:  lgInfo,
-			lgWarn:  lgWarn,
-			lgError: lgError,
-		},
-		dir:      dir,
-		filename: filename,
-		maxNum:   maxNum,
-		maxSize:  maxSize,
-		suffix:   0,
+func NewFileHandler(filepath string) (*FileHandler, error) {
+	logfile, err := os.OpenFile(filepath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
 	}
 
-	if h.isMustRename() {
-		h.rename()
-	} else {
-		h.mu.Lock()
-		defer h.mu.Unlock()
-		h.lg.SetOutput(logfile)
+	h := &FileHandler{
+		logfile: logfile,
 	}
-
-	// monitor filesize per second
-	go func() {
-		timer := time.NewTicker(1 * time.Second)
-		for {
-			select {
-			case <-timer.C:
-				h.fileCheck()
-			}
-		}
-	}()
-
-	return h
+	h.init(logfile, &ConsoleEncoder{})
+	return h, nil
 }
 
 func (l *LogHandler) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.out = w
 	l.lg.SetOutput(w)
 }
 
@@ -217,11 +134,11 @@ func (l *LogHandler) Flags() int {
 }
 
 func (l *LogHandler) SetFlags(flag int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flags = flag
 	l.lg.SetFlags(flag)
-	l.lgDebug.SetFlags(flag)
-	l.lgInfo.SetFlags(flag)
-	l.lgWarn.SetFlags(flag)
-	l.lgError.SetFlags(flag)
 }
 
 func (l *LogHandler) Prefix() string {
@@ -232,21 +149,20 @@ func (l *LogHandler) SetPrefix(prefix string) {
 	l.lg.SetPrefix(prefix)
 }
 
-func (l *LogHandler) Debug(v ...interface{}) {
-	//l.lg.Output(3, fmt.Sprintln("[DEBUG]", v))
-	l.lgDebug.Output(3, fmt.Sprintln(v))
-}
+func (l *LogHandler) SetEncoder(enc Encoder) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-func (l *LogHandler) Info(v ...interface{}) {
-	l.lgInfo.Output(3, fmt.Sprintln(v))
+	l.encoder = enc
 }
 
-func (l *LogHandler) Warn(v ...interface{}) {
-	l.lgWarn.Output(3, fmt.Sprintln(v))
-}
+func (l *LogHandler) Log(e *Entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 
-func (l *LogHandler) Error(v ...interface{}) {
-	l.lgError.Output(3, fmt.Sprintln(v))
+	l.buf.Reset()
+	l.encoder.Encode(&l.buf, e, l.flags)
+	l.out.Write(l.buf.Bytes())
 }
 
 func (l *LogHandler) close() {
@@ -259,53 +175,6 @@ func (h *FileHandler) close() {
 	}
 }
 
-func (h *RotatingHandler) close() {
-	if h.logfile != nil {
-		h.logfile.Close()
-	}
-}
-
-func (h *RotatingHandler) isMustRename() bool {
-	if h.maxNum > 1 {
-		if fileSize(h.dir+"/"+h.filename) >= h.maxSize {
-			return true
-		}
-	}
-	return false
-}
-
-func (h *RotatingHandler) rename() {
-	h.suffix = h.suffix%h.maxNum + 1
-
-	if h.logfile != nil {
-		h.logfile.Close()
-	}
-
-	newpath := fmt.Sprintf("%s/%s.%d.log", h.dir, h.filename, h.suffix)
-	if isExist(newpath) {
-		os.Remove(newpath)
-	}
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	filepath := h.dir + "/" + h.filename
-	os.Rename(filepath, newpath)
-	h.logfile, _ = os.Create(filepath)
-	h.lg.SetOutput(h.logfile)
-}
-
-func (h *RotatingHandler) fileCheck() {
-	defer func() {
-		if err := recover(); err != nil {
-			log.Println(err)
-		}
-	}()
-	if h.isMustRename() {
-		h.rename()
-	}
-}
-
 /*
 ===================
  logger
@@ -313,7 +182,6 @@ func (h *RotatingHandler) fileCheck() {
 */
 type _Logger struct {
 	handlers []Handler
-	level    Level
 	mu       sync.Mutex
 }
 
@@ -321,7 +189,6 @@ var logger = &_Logger{
 	handlers: []Handler{
 		Console,
 	},
-	level: DEBUG,
 }
 
 func SetHandlers(handlers ...Handler) {
@@ -334,8 +201,11 @@ func SetFlags(flag int) {
 	}
 }
 
-func SetLevel(level Level) {
-	logger.level = level
+//SetEncoder sets the encoder of all handlers, a handler added later keeps its own
+func SetEncoder(enc Encoder) {
+	for i := range logger.handlers {
+		logger.handlers[i].SetEncoder(enc)
+	}
 }
 
 func Print(v ...interface{}) {
@@ -402,35 +272,19 @@ func Panicln(v ...interface{}) {
 }
 
 func Debug(v ...interface{}) {
-	if logger.level <= DEBUG {
-		for i := range logger.handlers {
-			logger.handlers[i].Debug(v...)
-		}
-	}
+	root.log(2, DEBUG, v)
 }
 
 func Info(v ...interface{}) {
-	if logger.level <= INFO {
-		for i := range logger.handlers {
-			logger.handlers[i].Info(v...)
-		}
-	}
+	root.log(2, INFO, v)
 }
 
 func Warn(v ...interface{}) {
-	if logger.level <= WARN {
-		for i := range logger.handlers {
-			logger.handlers[i].Warn(v...)
-		}
-	}
+	root.log(2, WARN, v)
 }
 
 func Error(v ...interface{}) {
-	if logger.level <= ERROR {
-		for i := range logger.handlers {
-			logger.handlers[i].Error(v...)
-		}
-	}
+	root.log(2, ERROR, v)
 }
 
 func Close() {
@@ -439,16 +293,9 @@ func Close() {
 	}
 }
 
-func (l *LogHandler) ErrorD(calldepth int, v ...interface{}) {
-	l.lgError.Output(calldepth, fmt.Sprintln(v))
-}
-
+//ErrorD logs for the caller calldepth-2 frames above ErrorD, CheckError passes 4 for its own caller
 func ErrorD(calldepth int, v ...interface{}) {
-	if logger.level <= ERROR {
-		for i := range logger.handlers {
-			logger.handlers[i].ErrorD(calldepth, v...)
-		}
-	}
+	root.log(calldepth-1, ERROR, v)
 }
 
 func CheckError(err error) {
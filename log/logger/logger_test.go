@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFileHandler(t *testing.T) {
+	dir := t.TempDir()
+
+	h, err := NewFileHandler(filepath.Join(dir, "road.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.close()
+
+	_, err = NewFileHandler(filepath.Join(dir, "missing", "road.log"))
+	if err == nil {
+		t.Error("NewFileHandler opened a file in a missing directory")
+	}
+}
@@ -0,0 +1,450 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	RotateNever  = ""
+	RotateHourly = "hourly"
+	RotateDaily  = "daily"
+)
+
+const (
+	rotatedTimeFormat = "20060102-150405.000"
+	compressSuffix    = ".gz"
+)
+
+//RotatingConfig of a RotatingHandler, a zero limit is no limit.
+//Rotated files are named filename.20060102-150405.000, with .gz when compressed
+type RotatingConfig struct {
+	Dir          string `json:"dir"`
+	Filename     string `json:"filename"`
+	Rotate       string `json:"rotate"`       //RotateNever, RotateHourly or RotateDaily, at the hour of local time
+	MaxSize      int64  `json:"maxsize"`      //bytes of the current file
+	Compress     bool   `json:"compress"`     //gzip rotated files
+	MaxNum       int    `json:"maxnum"`       //rotated files kept
+	MaxDays      int    `json:"maxdays"`      //rotated files older are removed
+	MaxTotalSize int64  `json:"maxtotalsize"` //bytes of all rotated files, the oldest are removed first
+}
+
+type RotatingHandler struct {
+	LogHandler
+	file *rotatingFile
+}
+
+//NewRotatingHandler rotates by size and keeps maxNum rotated files
+func NewRotatingHandler(dir string, filename string, maxNum int, maxSize int64) (*RotatingHandler, error) {
+	return NewRotatingHandlerWithConfig(&RotatingConfig{
+		Dir:      dir,
+		Filename: filename,
+		MaxNum:   maxNum,
+		MaxSize:  maxSize,
+	})
+}
+
+func NewRotatingHandlerWithConfig(config *RotatingConfig) (*RotatingHandler, error) {
+	file, err := openRotatingFile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &RotatingHandler{
+		file: file,
+	}
+	h.init(file, &ConsoleEncoder{})
+
+	return h, nil
+}
+
+//Reopen opens the file by its name again, after logrotate moved it away
+func (h *RotatingHandler) Reopen() error {
+	return h.file.reopen()
+}
+
+//Rotate rotates now, whatever the size and time
+func (h *RotatingHandler) Rotate() error {
+	return h.file.rotate()
+}
+
+func (h *RotatingHandler) close() {
+	h.file.Close()
+}
+
+//reopener is a handler writing to a file that can be moved away
+type reopener interface {
+	Reopen() error
+}
+
+//Reopen reopens the files of the handlers
+func Reopen() {
+	for i := range logger.handlers {
+		if r, ok := logger.handlers[i].(reopener); ok {
+			err := r.Reopen()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "logger: reopen:", err)
+			}
+		}
+	}
+}
+
+//ReopenOnSignal calls Reopen on sig, SIGHUP by default, so logrotate works without copytruncate
+func ReopenOnSignal(sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGHUP}
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, sig...)
+
+	go func() {
+		for range c {
+			Reopen()
+		}
+	}()
+}
+
+//rotatingFile rotates before the write that would cross the size or the hour, so no line is split.
+//Compression and removal run in cleanupRoutine and never hold up a write
+type rotatingFile struct {
+	config RotatingConfig
+	path   string
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	period time.Time
+
+	rotated chan bool
+	done    chan bool
+}
+
+func openRotatingFile(config *RotatingConfig) (*rotatingFile, error) {
+	if config.Filename == "" {
+		return nil, fmt.Errorf("logger: no filename")
+	}
+
+	switch config.Rotate {
+	case RotateNever, RotateHourly, RotateDaily:
+	default:
+		return nil, fmt.Errorf("logger: unknown rotate %q", config.Rotate)
+	}
+
+	f := &rotatingFile{
+		config:  *config,
+		path:    filepath.Join(config.Dir, config.Filename),
+		rotated: make(chan bool, 1),
+		done:    make(chan bool),
+	}
+
+	err := f.open()
+	if err != nil {
+		return nil, err
+	}
+
+	go f.cleanupRoutine()
+
+	//Compress and remove what the last run left
+	f.rotated <- true
+
+	return f, nil
+}
+
+//open must be called with f.mu held or before f is used
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.period = f.periodOf(info.ModTime())
+
+	return nil
+}
+
+func (f *rotatingFile) periodOf(t time.Time) time.Time {
+	switch f.config.Rotate {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if f.closed() {
+			return 0, os.ErrClosed
+		}
+
+		//The last reopen or rotate failed, try again
+		err := f.open()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	if f.size == 0 {
+		f.period = f.periodOf(now)
+	}
+
+	if f.size > 0 && (f.config.MaxSize > 0 && f.size+int64(len(p)) > f.config.MaxSize ||
+		!f.periodOf(now).Equal(f.period)) {
+		err := f.rotateLocked(now)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "logger: rotate:", err)
+			if f.file == nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed() {
+		return os.ErrClosed
+	}
+
+	return f.rotateLocked(time.Now())
+}
+
+//rotateLocked keeps writing to the same file when it can not be renamed
+func (f *rotatingFile) rotateLocked(now time.Time) error {
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+
+	rotated := f.rotatedPath(now)
+	renameErr := os.Rename(f.path, rotated)
+
+	err := f.open()
+	if err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+
+	select {
+	case f.rotated <- true:
+	default:
+	}
+
+	return nil
+}
+
+func (f *rotatingFile) rotatedPath(now time.Time) string {
+	path := f.path + "." + now.Format(rotatedTimeFormat)
+	for i := 1; ; i++ {
+		_, err := os.Stat(path)
+		_, gzErr := os.Stat(path + compressSuffix)
+		if os.IsNotExist(err) && os.IsNotExist(gzErr) {
+			return path
+		}
+		path = fmt.Sprintf("%s.%s-%d", f.path, now.Format(rotatedTimeFormat), i)
+	}
+}
+
+func (f *rotatingFile) reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed() {
+		return os.ErrClosed
+	}
+
+	if f.file != nil {
+		f.file.Close()
+		f.file = nil
+	}
+
+	return f.open()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.closed() {
+		close(f.done)
+	}
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *rotatingFile) closed() bool {
+	select {
+	case <-f.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (f *rotatingFile) cleanupRoutine() {
+	for {
+		select {
+		case <-f.rotated:
+			f.cleanup()
+		case <-f.done:
+			return
+		}
+	}
+}
+
+//listRotated returns the rotated files, oldest first
+func (f *rotatingFile) listRotated() ([]os.FileInfo, error) {
+	dir := f.config.Dir
+	if dir == "" {
+		dir = "."
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := f.config.Filename + "."
+	rotated := make([]os.FileInfo, 0)
+	for _, info := range infos {
+		name := info.Name()
+		if !info.Mode().IsRegular() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(name[len(prefix):], compressSuffix)
+		if len(stamp) < len(rotatedTimeFormat) {
+			continue
+		}
+		_, err := time.Parse(rotatedTimeFormat, stamp[:len(rotatedTimeFormat)])
+		if err != nil {
+			continue
+		}
+
+		rotated = append(rotated, info)
+	}
+
+	//ReadDir sorts by name, and the names sort by time
+	return rotated, nil
+}
+
+func (f *rotatingFile) cleanup() {
+	rotated, err := f.listRotated()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "logger: cleanup:", err)
+		return
+	}
+
+	if f.config.Compress {
+		compressed := false
+		for _, info := range rotated {
+			if strings.HasSuffix(info.Name(), compressSuffix) {
+				continue
+			}
+
+			err := compressFile(filepath.Join(f.config.Dir, info.Name()), info.ModTime())
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "logger: compress:", err)
+				continue
+			}
+			compressed = true
+		}
+
+		if compressed {
+			rotated, err = f.listRotated()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "logger: cleanup:", err)
+				return
+			}
+		}
+	}
+
+	var num int
+	var total int64
+	expired := time.Now().AddDate(0, 0, -f.config.MaxDays)
+
+	for i := len(rotated) - 1; i >= 0; i-- {
+		info := rotated[i]
+		num++
+		total += info.Size()
+
+		if f.config.MaxNum > 0 && num > f.config.MaxNum ||
+			f.config.MaxDays > 0 && info.ModTime().Before(expired) ||
+			f.config.MaxTotalSize > 0 && total > f.config.MaxTotalSize {
+			err := os.Remove(filepath.Join(f.config.Dir, info.Name()))
+			if err != nil && !os.IsNotExist(err) {
+				fmt.Fprintln(os.Stderr, "logger: remove:", err)
+			}
+		}
+	}
+}
+
+//compressFile replaces path with path.gz, which keeps modTime for MaxDays
+func compressFile(path string, modTime time.Time) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	gzPath := path + compressSuffix
+	dst, err := os.OpenFile(gzPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(gzPath)
+		return err
+	}
+
+	os.Chtimes(gzPath, modTime, modTime)
+
+	return os.Remove(path)
+}
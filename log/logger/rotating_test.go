@@ -0,0 +1,257 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFilename = "road.log"
+
+func readFile(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+//rotatedNames returns the names of the rotated files, oldest first
+func rotatedNames(t *testing.T, f *rotatingFile) []string {
+	rotated, err := f.listRotated()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(rotated))
+	for _, info := range rotated {
+		names = append(names, info.Name())
+	}
+	return names
+}
+
+//writeRotated leaves a rotated file of size bytes, modified at modTime
+func writeRotated(t *testing.T, dir string, modTime time.Time, size int) string {
+	name := testFilename + "." + modTime.Format(rotatedTimeFormat)
+	path := filepath.Join(dir, name)
+
+	err := ioutil.WriteFile(path, bytes.Repeat([]byte("a"), size), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chtimes(path, modTime, modTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	f, err := openRotatingFile(&RotatingConfig{Dir: dir, Filename: testFilename, MaxSize: 25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines := []string{"line 1 ...\n", "line 2 ...\n", "line 3 ...\n", "line 4 ...\n", "line 5 ...\n"}
+	for _, line := range lines {
+		_, err = f.Write([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//11 bytes a line, two fit in 25
+	names := rotatedNames(t, f)
+	if len(names) != 2 {
+		t.Fatalf("Rotated files %v, want 2", names)
+	}
+
+	var all string
+	for _, name := range names {
+		data := readFile(t, filepath.Join(dir, name))
+		if len(data) > 25 || !strings.HasSuffix(data, "\n") {
+			t.Errorf("%s is %q, a line is split or the file too big", name, data)
+		}
+		all += data
+	}
+
+	current := readFile(t, filepath.Join(dir, testFilename))
+	if current != lines[4] {
+		t.Errorf("Current file is %q, want %q", current, lines[4])
+	}
+
+	if all+current != strings.Join(lines, "") {
+		t.Errorf("Lines lost or reordered: %q", all+current)
+	}
+}
+
+func TestRotateByPeriod(t *testing.T) {
+	dir := t.TempDir()
+	f, err := openRotatingFile(&RotatingConfig{Dir: dir, Filename: testFilename, Rotate: RotateHourly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, err = f.Write([]byte("last hour\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//As if the first line was written an hour ago
+	f.mu.Lock()
+	f.period = f.period.Add(-time.Hour)
+	f.mu.Unlock()
+
+	_, err = f.Write([]byte("this hour\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := rotatedNames(t, f)
+	if len(names) != 1 {
+		t.Fatalf("Rotated files %v, want 1", names)
+	}
+
+	if data := readFile(t, filepath.Join(dir, names[0])); data != "last hour\n" {
+		t.Errorf("Rotated file is %q", data)
+	}
+
+	if data := readFile(t, filepath.Join(dir, testFilename)); data != "this hour\n" {
+		t.Errorf("Current file is %q", data)
+	}
+
+	//Within the same hour nothing rotates
+	_, err = f.Write([]byte("this hour again\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := rotatedNames(t, f); len(names) != 1 {
+		t.Errorf("Rotated files %v after a write in the same hour", names)
+	}
+}
+
+//Retention counts the compressed sizes, so compression must come first
+func TestCompressThenRetain(t *testing.T) {
+	dir := t.TempDir()
+	f := &rotatingFile{
+		config: RotatingConfig{
+			Dir:          dir,
+			Filename:     testFilename,
+			Compress:     true,
+			MaxNum:       3,
+			MaxTotalSize: 4096,
+		},
+		path: filepath.Join(dir, testFilename),
+	}
+
+	now := time.Now()
+	names := make([]string, 0)
+	for i := 4; i > 0; i-- {
+		names = append(names, writeRotated(t, dir, now.Add(-time.Duration(i)*time.Minute), 10000))
+	}
+
+	f.cleanup()
+
+	got := rotatedNames(t, f)
+	want := []string{names[1] + compressSuffix, names[2] + compressSuffix, names[3] + compressSuffix}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("Kept %v, want %v", got, want)
+	}
+
+	//Compressed files keep the time of the rotation for MaxDays
+	info, err := os.Stat(filepath.Join(dir, got[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotatedAt := now.Add(-3 * time.Minute)
+	if diff := info.ModTime().Sub(rotatedAt); diff > time.Second || diff < -time.Second {
+		t.Errorf("%s modified at %v, want %v", got[0], info.ModTime(), rotatedAt)
+	}
+}
+
+func TestRetainByDays(t *testing.T) {
+	dir := t.TempDir()
+	f := &rotatingFile{
+		config: RotatingConfig{Dir: dir, Filename: testFilename, MaxDays: 1},
+		path:   filepath.Join(dir, testFilename),
+	}
+
+	now := time.Now()
+	writeRotated(t, dir, now.AddDate(0, 0, -2), 10)
+	kept := writeRotated(t, dir, now.Add(-time.Hour), 10)
+
+	f.cleanup()
+
+	if got := rotatedNames(t, f); len(got) != 1 || got[0] != kept {
+		t.Errorf("Kept %v, want %s", got, kept)
+	}
+}
+
+//Reopen writes to a new file once logrotate moved the old one away
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	h, err := NewRotatingHandlerWithConfig(&RotatingConfig{Dir: dir, Filename: testFilename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.close()
+
+	path := filepath.Join(dir, testFilename)
+	moved := path + ".1"
+
+	_, err = h.file.Write([]byte("before\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Rename(path, moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = h.Reopen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = h.file.Write([]byte("after\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data := readFile(t, moved); data != "before\n" {
+		t.Errorf("Moved file is %q", data)
+	}
+
+	if data := readFile(t, path); data != "after\n" {
+		t.Errorf("Reopened file is %q", data)
+	}
+}
+
+func TestOpenRotatingFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []*RotatingConfig{
+		{Dir: dir},
+		{Dir: dir, Filename: testFilename, Rotate: "weekly"},
+		{Dir: filepath.Join(dir, "missing"), Filename: testFilename},
+	}
+
+	for _, config := range tests {
+		f, err := openRotatingFile(config)
+		if err == nil {
+			f.Close()
+			t.Errorf("openRotatingFile(%+v) succeeded", config)
+		}
+	}
+}
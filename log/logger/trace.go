@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Trace is a field value whose loggers log at every level, whatever SetLevel and SetPackageLevel say
+type Trace struct {
+	Key   string    `json:"key"`
+	Value string    `json:"value"`
+	Until time.Time `json:"until"` //zero until TraceOff
+}
+
+var traces = struct {
+	mu     sync.RWMutex
+	values map[Field]time.Time
+	count  int32 //len(values), so loggers without a trace skip the lock
+	expiry int64 //unix nanoseconds when the first trace runs out, 0 when none does
+}{
+	values: make(map[Field]time.Time),
+}
+
+//TraceOn traces the loggers with the field key=value, like "thingid", thingid, for d or until TraceOff when d is 0
+func TraceOn(key, value string, d time.Duration) {
+	var until time.Time
+	if d > 0 {
+		until = time.Now().Add(d)
+	}
+
+	traces.mu.Lock()
+	defer traces.mu.Unlock()
+
+	traces.values[Field{Key: key, Value: value}] = until
+	pruneTraces()
+}
+
+func TraceOff(key, value string) {
+	traces.mu.Lock()
+	defer traces.mu.Unlock()
+
+	delete(traces.values, Field{Key: key, Value: value})
+	pruneTraces()
+}
+
+//pruneTraces removes the traces that ran out, it must be called with traces.mu held
+func pruneTraces() {
+	now := time.Now()
+	var expiry int64
+	for f, until := range traces.values {
+		if until.IsZero() {
+			continue
+		}
+
+		if now.After(until) {
+			delete(traces.values, f)
+		} else if expiry == 0 || until.UnixNano() < expiry {
+			expiry = until.UnixNano()
+		}
+	}
+
+	atomic.StoreInt32(&traces.count, int32(len(traces.values)))
+	atomic.StoreInt64(&traces.expiry, expiry)
+}
+
+//expireTraces prunes once a trace ran out, so count goes back to 0 without a call to TraceOff
+func expireTraces(now time.Time) {
+	expiry := atomic.LoadInt64(&traces.expiry)
+	if expiry == 0 || now.UnixNano() <= expiry {
+		return
+	}
+
+	traces.mu.Lock()
+	defer traces.mu.Unlock()
+
+	pruneTraces()
+}
+
+//Traces returns the traces that did not run out, sorted by key and value
+func Traces() []Trace {
+	traces.mu.Lock()
+	defer traces.mu.Unlock()
+
+	pruneTraces()
+
+	list := make([]Trace, 0, len(traces.values))
+	for f, until := range traces.values {
+		list = append(list, Trace{Key: f.Key, Value: f.Value.(string), Until: until})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Key != list[j].Key {
+			return list[i].Key < list[j].Key
+		}
+		return list[i].Value < list[j].Value
+	})
+
+	return list
+}
+
+//Tracing tells if a field of l is traced, use it to skip building what only a trace logs
+func (l *Logger) Tracing() bool {
+	if atomic.LoadInt32(&traces.count) == 0 || len(l.fields) == 0 {
+		return false
+	}
+
+	now := time.Now()
+	expireTraces(now)
+
+	traces.mu.RLock()
+	defer traces.mu.RUnlock()
+
+	for _, f := range l.fields {
+		value, ok := f.Value.(string)
+		if !ok {
+			value = fmt.Sprint(f.Value)
+		}
+
+		until, ok := traces.values[Field{Key: f.Key, Value: value}]
+		if ok && (until.IsZero() || now.Before(until)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTraceOnOff(t *testing.T) {
+	traced := With("thingid", "WDDUX52684DFR4582")
+	other := With("thingid", "WDDUX52684DFR4583")
+
+	TraceOn("thingid", "WDDUX52684DFR4582", 0)
+	if !traced.Tracing() || other.Tracing() {
+		t.Errorf("Tracing = %v %v after TraceOn, want true false", traced.Tracing(), other.Tracing())
+	}
+
+	TraceOff("thingid", "WDDUX52684DFR4582")
+	if traced.Tracing() {
+		t.Error("Tracing after TraceOff")
+	}
+
+	if n := atomic.LoadInt32(&traces.count); n != 0 {
+		t.Errorf("count = %d after TraceOff", n)
+	}
+}
+
+//An expired trace is pruned by the next log call, so untraced loggers skip the lock again
+func TestTraceExpires(t *testing.T) {
+	TraceOn("thingid", "WDDUX52684DFR4582", 20*time.Millisecond)
+	TraceOn("thingid", "WDDUX52684DFR4583", 0)
+	defer TraceOff("thingid", "WDDUX52684DFR4583")
+
+	l := With("thingid", "WDDUX52684DFR4582")
+	if !l.Tracing() {
+		t.Fatal("Tracing = false before the trace runs out")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	if l.Tracing() {
+		t.Error("Tracing = true after the trace ran out")
+	}
+
+	if n := atomic.LoadInt32(&traces.count); n != 1 {
+		t.Errorf("count = %d after the trace ran out, want 1", n)
+	}
+
+	if expiry := atomic.LoadInt64(&traces.expiry); expiry != 0 {
+		t.Errorf("expiry = %d with only a trace that never runs out", expiry)
+	}
+
+	if list := Traces(); len(list) != 1 || list[0].Value != "WDDUX52684DFR4583" {
+		t.Errorf("Traces = %+v", list)
+	}
+}
@@ -2,9 +2,12 @@ package message
 
 import (
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"github.com/harveywangdao/road/crypto/aes"
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/metrics"
+	"github.com/harveywangdao/road/protocol"
 	"github.com/harveywangdao/road/util"
 	"time"
 )
@@ -21,6 +24,27 @@ const (
 	RetSuccess               = 0
 	ErrorCodeGeneral         = 1
 	ErrorCodeConnectionBreak = 2
+	ErrorCodeChecksum        = 3
+	ErrorCodeDecrypt         = 4
+)
+
+//Result codes carried in DispatchData.Result of an error reply
+const (
+	ResultSuccess        uint8 = 0x00
+	ResultBadChecksum    uint8 = 0xE1
+	ResultDecryptFailure uint8 = 0xE2
+	ResultUnknownAidMid  uint8 = 0xE3
+	ResultNotLogin       uint8 = 0xE4
+	ResultBusy           uint8 = 0xE5
+	ResultInternalError  uint8 = 0xE6
+	ResultBadServiceData uint8 = 0xE7
+	ResultBadVersion     uint8 = 0xE8
+)
+
+var (
+	ErrChecksum         = errors.New("Message checksum error!")
+	ErrDecrypt          = errors.New("Decrypt service data error!")
+	ErrServiceDataCheck = errors.New("Service data check error!")
 )
 
 type MessageHeader struct {
@@ -58,6 +82,67 @@ type Message struct {
 	CallbackFn func(uint32) string
 }
 
+//ResultError is returned by handlers that want an error reply sent back to the peer
+type ResultError struct {
+	Result uint8
+	Err    error
+}
+
+func (e *ResultError) Error() string {
+	return ResultName(e.Result) + ": " + e.Err.Error()
+}
+
+func NewResultError(result uint8, err error) error {
+	return &ResultError{
+		Result: result,
+		Err:    err,
+	}
+}
+
+func GetResult(err error) (uint8, bool) {
+	if e, ok := err.(*ResultError); ok {
+		return e.Result, true
+	}
+
+	return ResultSuccess, false
+}
+
+func ResultName(result uint8) string {
+	switch result {
+	case ResultSuccess:
+		return "Success"
+	case ResultBadChecksum:
+		return "BadChecksum"
+	case ResultDecryptFailure:
+		return "DecryptFailure"
+	case ResultUnknownAidMid:
+		return "UnknownAidMid"
+	case ResultNotLogin:
+		return "NotLogin"
+	case ResultBusy:
+		return "Busy"
+	case ResultInternalError:
+		return "InternalError"
+	case ResultBadServiceData:
+		return "BadServiceData"
+	case ResultBadVersion:
+		return "BadVersion"
+	}
+
+	return "Unknown"
+}
+
+func GetResultByErrorCode(errorCode int) uint8 {
+	switch errorCode {
+	case ErrorCodeChecksum:
+		return ResultBadChecksum
+	case ErrorCodeDecrypt:
+		return ResultDecryptFailure
+	}
+
+	return ResultInternalError
+}
+
 func (msg *Message) getAES128Key(bid uint32) string {
 	//return AES128KEY
 	return msg.Aes128Key
@@ -154,7 +239,10 @@ func (msg *Message) RecvOneMessage() ([]byte, int, error) {
 
 	//Check data validity
 	if !msg.checkMessageChecksum(readData[:oneMsgLen]) {
-		return nil, ErrorCodeGeneral, errors.New("Not true message!")
+		//Keep header and dispatch data so that the error reply can refer to this message
+		util.ByteSliceToStruct(readData[:messageHeaderLen], &msg.MesHeader)
+		msg.DisPatch = dd
+		return nil, ErrorCodeChecksum, ErrChecksum
 	}
 
 	return readData[:oneMsgLen], RetSuccess, nil
@@ -166,7 +254,7 @@ func (msg *Message) ParseOneMessage(originMessageData []byte) ([]byte, error) {
 	var err error
 
 	if !msg.checkMessageChecksum(originMessageData) {
-		return nil, errors.New("Not true message!")
+		return nil, ErrChecksum
 	}
 
 	msg.CheckSum = originMessageData[len(originMessageData)-1]
@@ -201,19 +289,20 @@ func (msg *Message) ParseOneMessage(originMessageData []byte) ([]byte, error) {
 			[]byte(msg.CallbackFn(msg.MesHeader.Bid)))
 		if err != nil {
 			logger.Error(err)
-			return nil, err
+			return nil, ErrDecrypt
 		}
 	case Encrypt_Base64:
 		fallthrough
 	default:
-		return nil, errors.New("Not support this encrypt!")
+		logger.Error("Not support this encrypt!")
+		return nil, ErrDecrypt
 	}
 
 	//Check ServiceDataCheck
 	serviceDataCheck := util.DataXOR(msg.ServData)
 	if serviceDataCheck != msg.MesHeader.ServiceDataCheck {
 		logger.Error("Service data check error!")
-		return nil, errors.New("service data check error!")
+		return nil, ErrServiceDataCheck
 		//return msg.ServData, nil
 	}
 
@@ -224,6 +313,9 @@ func (msg *Message) RecvMessage() (int, error) {
 	originMessageData, errorCode, err := msg.RecvOneMessage()
 	if err != nil {
 		logger.Error(err)
+		if errorCode == ErrorCodeChecksum {
+			metrics.MessageErrors.WithLabelValues("checksum").Inc()
+		}
 		return errorCode, err
 	}
 
@@ -232,6 +324,19 @@ func (msg *Message) RecvMessage() (int, error) {
 	serviceData, err := msg.ParseOneMessage(originMessageData)
 	if err != nil {
 		logger.Error(err)
+		switch err {
+		case ErrChecksum:
+			metrics.MessageErrors.WithLabelValues("checksum").Inc()
+			return ErrorCodeChecksum, err
+		case ErrDecrypt:
+			metrics.MessageErrors.WithLabelValues("decrypt").Inc()
+			return ErrorCodeDecrypt, err
+		case ErrServiceDataCheck:
+			//A wrong key shows up as a service data check error
+			metrics.MessageErrors.WithLabelValues("service_data_check").Inc()
+			return ErrorCodeDecrypt, err
+		}
+		metrics.MessageErrors.WithLabelValues("general").Inc()
 		return ErrorCodeGeneral, err
 	}
 
@@ -281,8 +386,8 @@ func (msg *Message) GetMessageHeaderData(serviceData []byte) ([]byte, error) {
 	var mh MessageHeader
 	mh.FixHeader = MessageHeaderID
 	mh.ServiceDataCheck = util.DataXOR(serviceData)
-	mh.ServiceVersion = 0x0 //not sure
-	mh.Bid = 0x0            //not sure
+	mh.ServiceVersion = protocol.ServiceVersion
+	mh.Bid = 0x0 //not sure
 	mh.MessageFlag = 0x0
 
 	messageHeaderData, err := util.StructToByteSlice(mh)
@@ -342,6 +447,64 @@ func (msg *Message) SendOneMessage(data []byte) error {
 	return nil
 }
 
+//SendErrorReply rejects reqMsg with result, service data is not encrypted because the key may be the problem
+func (msg *Message) SendErrorReply(reqMsg *Message, result uint8) error {
+	errorReplyServData := protocol.ErrorReplyServData{
+		Aid: reqMsg.DisPatch.Aid,
+		Mid: reqMsg.DisPatch.Mid,
+	}
+
+	serviceData, err := json.Marshal(&errorReplyServData)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	dd := DispatchData{
+		EventCreationTime:    reqMsg.DisPatch.EventCreationTime,
+		Aid:                  protocol.ErrorReplyAid,
+		Mid:                  protocol.ErrorReplyMid,
+		MessageCounter:       reqMsg.DisPatch.MessageCounter + 1,
+		ServiceDataLength:    uint16(len(serviceData)),
+		Result:               result,
+		SecurityVersion:      Encrypt_No,
+		DispatchCreationTime: uint32(time.Now().Unix()),
+	}
+
+	dispatchData, err := util.StructToByteSlice(dd)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	mh := MessageHeader{
+		FixHeader:        MessageHeaderID,
+		ServiceDataCheck: util.DataXOR(serviceData),
+		ServiceVersion:   protocol.ServiceVersion,
+		Bid:              reqMsg.MesHeader.Bid,
+		MessageFlag:      protocol.ErrorReplyMessageFlag,
+	}
+
+	messageHeaderData, err := util.StructToByteSlice(mh)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	logger.Warn("Send error reply, Aid =", errorReplyServData.Aid, "Mid =", errorReplyServData.Mid, "Result =", ResultName(result))
+
+	return msg.SendMessage(messageHeaderData, dispatchData, serviceData)
+}
+
+func (msg *Message) Encoding() int {
+	return protocol.EncodingOf(msg.MesHeader.MessageFlag)
+}
+
+//UnmarshalServData decodes json or protobuf service data according to the message flag
+func (msg *Message) UnmarshalServData(v interface{}) error {
+	return protocol.Unmarshal(msg.Encoding(), msg.ServData, v)
+}
+
 func (msg *Message) SendMessage(msgHeaderData, dispatchData, encryptServData []byte) error {
 	data, err := msg.GetOneMessage(msgHeaderData, dispatchData, encryptServData)
 	if err != nil {
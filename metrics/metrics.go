@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const (
+	Namespace = "iot"
+	Path      = "/metrics"
+)
+
+//Result label values
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	ConnectedThings = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "gateway",
+		Name:      "connected_things",
+		Help:      "Things with an open connection.",
+	})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "gateway",
+		Name:      "logins_total",
+		Help:      "Finished logins by result.",
+	}, []string{"result"})
+
+	MessagesReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "gateway",
+		Name:      "messages_received_total",
+		Help:      "Messages read from things by application and Aid.",
+	}, []string{"application", "aid"})
+
+	EventsHandled = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "gateway",
+		Name:      "event_duration_seconds",
+		Help:      "Time spent in event handlers by application, event and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 4, 8),
+	}, []string{"application", "event", "result"})
+
+	ThingQueueLength = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "gateway",
+		Name:      "thing_queue_length",
+		Help:      "ThingMsgChan backlog seen by the event loop of each thing.",
+		Buckets:   []float64{0, 1, 2, 4, 8, 16, 32, 64, 128},
+	})
+
+	MessageErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "message",
+		Name:      "errors_total",
+		Help:      "Received messages that could not be parsed by reason.",
+	}, []string{"reason"})
+
+	ProduceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "msgqueue",
+		Name:      "produce_duration_seconds",
+		Help:      "Kafka produce latency by topic and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"topic", "result"})
+
+	DeadLetters = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "msgqueue",
+		Name:      "dead_letters_total",
+		Help:      "Consumed messages given up on and sent to the dead letter topic, by source topic.",
+	}, []string{"topic"})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "database",
+		Name:      "query_duration_seconds",
+		Help:      "Database latency by operation and result.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"operation", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		ConnectedThings,
+		Logins,
+		MessagesReceived,
+		EventsHandled,
+		ThingQueueLength,
+		MessageErrors,
+		ProduceDuration,
+		DeadLetters,
+		DBQueryDuration,
+	)
+}
+
+func Result(err error) string {
+	if err != nil {
+		return ResultError
+	}
+
+	return ResultSuccess
+}
+
+//ObserveProduce and ObserveDBQuery take the start time so they fit in one line after the call
+func ObserveProduce(topic string, start time.Time, err error) {
+	ProduceDuration.WithLabelValues(topic, Result(err)).Observe(time.Since(start).Seconds())
+}
+
+func ObserveDBQuery(operation string, start time.Time, err error) {
+	DBQueryDuration.WithLabelValues(operation, Result(err)).Observe(time.Since(start).Seconds())
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+//Serve blocks, addr is like ":9100"
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(Path, Handler())
+
+	logger.Info("Metrics on", addr+Path)
+
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/harveywangdao/road/alert"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/telemetry"
+)
+
+const (
+	mqAddr    = "localhost:9092"
+	rulesFile = "alertrules.json"
+)
+
+func main() {
+	logger.Info("Start alertserver...")
+
+	config, err := alert.LoadRules(rulesFile)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	s := alert.Service{
+		MQAddr:             mqAddr,
+		TelemetryTopic:     telemetry.DefaultTopic,
+		AlertTopic:         alert.DefaultTopic,
+		ConsumerRoutineNum: 1,
+		Engine:             alert.NewEngine(config),
+	}
+
+	s.Run()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/harveywangdao/road/database/mongo"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/telemetry"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	port = ":8090"
+
+	defaultHistoryDuration = 24 * 60 * 60
+)
+
+var repo telemetry.Repository
+
+//GET /things/{thingid}/latest
+//GET /things/{thingid}/history?from=&to=&interval=
+//GET /things/{thingid}/track?from=&to=&interval=
+//from and to are unix seconds, the default range is the last 24 hours, interval is in seconds
+func thingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/things/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	thingid, action := parts[0], parts[1]
+
+	if action == "latest" {
+		doc, err := repo.Latest(thingid)
+		if err == telemetry.ErrNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJson(w, "application/json", doc)
+		return
+	}
+
+	from, to, interval, err := parseRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch action {
+	case "history":
+		docs, err := telemetry.History(repo, thingid, from, to, interval)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJson(w, "application/json", docs)
+
+	case "track":
+		feature, err := telemetry.Track(repo, thingid, from, to, interval)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJson(w, "application/geo+json", feature)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func parseRange(r *http.Request) (uint32, uint32, uint32, error) {
+	query := r.URL.Query()
+
+	to := uint64(time.Now().Unix())
+	from := to - defaultHistoryDuration
+	var interval uint64
+
+	var err error
+	if s := query.Get("from"); s != "" {
+		from, err = strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	if s := query.Get("to"); s != "" {
+		to, err = strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	if s := query.Get("interval"); s != "" {
+		interval, err = strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+
+	return uint32(from), uint32(to), uint32(interval), nil
+}
+
+func writeJson(w http.ResponseWriter, contentType string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		logger.Error(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(data)
+}
+
+func main() {
+	logger.Info("Start telemetryserver...")
+
+	db, err := mongo.GetDB()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	mongoRepo := telemetry.NewMongoRepository(db)
+
+	err = mongoRepo.EnsureIndexes()
+	if err != nil {
+		logger.Error(err)
+	}
+
+	repo = mongoRepo
+
+	http.HandleFunc("/things/", thingsHandler)
+
+	err = http.ListenAndServe(port, nil)
+	if err != nil {
+		logger.Error("failed to serve:", err)
+		return
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/telemetry"
+	"github.com/harveywangdao/road/trip"
+)
+
+const (
+	mqAddr = "localhost:9092"
+)
+
+func main() {
+	logger.Info("Start tripserver...")
+
+	s := trip.Service{
+		MQAddr:             mqAddr,
+		TelemetryTopic:     telemetry.DefaultTopic,
+		ConsumerRoutineNum: 1,
+	}
+
+	s.Run()
+}
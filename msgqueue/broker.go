@@ -0,0 +1,132 @@
+package msgqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue/kafka"
+	"io/ioutil"
+)
+
+const (
+	BrokerKafka    = "kafka"
+	BrokerMemory   = "memory"
+	BrokerEmbedded = "embedded"
+
+	DefaultKafkaAddr = "localhost:9092"
+)
+
+var (
+	ErrBrokerClosed = errors.New("Broker closed!")
+)
+
+//Message is one record of a topic, messages with the same key keep their order
+type Message struct {
+	Topic     string
+	Key       string
+	Value     []byte
+	Partition int32
+	Offset    int64
+	Err       error //set when the broker could not decode the message, Value is then the raw data
+
+	ack func()
+}
+
+//Ack marks the message processed, an unacked message is delivered again to the next subscriber of the same group
+func (msg *Message) Ack() {
+	if msg.ack != nil {
+		msg.ack()
+	}
+}
+
+type Subscription interface {
+	Messages() <-chan *Message
+	Close() error
+}
+
+//Rebalance reports the partitions of a subscription after its group changed, keyed by topic
+type Rebalance struct {
+	Type     string
+	Claimed  map[string][]int32
+	Released map[string][]int32
+	Current  map[string][]int32
+}
+
+//Rebalancer is implemented by the subscriptions of brokers that share partitions within a group
+type Rebalancer interface {
+	Rebalances() <-chan *Rebalance
+}
+
+//Broker is what the services need from a message queue. Every group receives every message of a topic,
+//subscribers of one group share them
+type Broker interface {
+	Publish(topic, key string, values ...[]byte) error
+	Subscribe(topic, group string) (Subscription, error)
+	Close() error
+}
+
+//Config selects the broker, Addr is the kafka bootstrap address or the embedded broker address
+type Config struct {
+	Type     string
+	Addr     string
+	Producer *kafka.ProducerConfig //optional, kafka.DefaultProducerConfig by default
+	PackMode int                   //kafka.PackNone, kafka.PackCrc or kafka.PackAes
+	PackKey  string                //aes key of kafka.PackAes
+}
+
+//DefaultConfig is kafka at DefaultKafkaAddr
+func DefaultConfig() Config {
+	return Config{
+		Type: BrokerKafka,
+		Addr: DefaultKafkaAddr,
+	}
+}
+
+//LoadConfig reads a JSON Config, fields it leaves out keep the values of DefaultConfig
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		logger.Error(err)
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		logger.Error(err)
+		return config, err
+	}
+
+	return config, nil
+}
+
+func NewBroker(config Config) (Broker, error) {
+	switch config.Type {
+	case BrokerKafka, "":
+		packing := kafka.Packing{Mode: config.PackMode}
+		if config.PackKey != "" {
+			packing.Key = []byte(config.PackKey)
+		}
+
+		err := packing.Check()
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		kb := NewKafkaBroker(config.Addr)
+		kb.Packing = packing
+		if config.Producer != nil {
+			kb.Producer = *config.Producer
+		}
+		return kb, nil
+	case BrokerMemory:
+		return DefaultMemoryBroker, nil
+	case BrokerEmbedded:
+		return DialEmbeddedBroker(config.Addr)
+	}
+
+	logger.Error("Unknown broker type", config.Type)
+	return nil, errors.New("Unknown broker type " + config.Type)
+}
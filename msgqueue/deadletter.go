@@ -0,0 +1,142 @@
+package msgqueue
+
+import (
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/metrics"
+	"time"
+)
+
+const (
+	MessageTypeDeadLetter = "dead_letter" //any service -> operators, payload DeadLetter
+	DeadLetterTopicSuffix = "DeadLetter"
+
+	HandleMaxAttempts = 5
+)
+
+//DeadLetter is a message its consumer gave up on, Value is the message as it was received
+type DeadLetter struct {
+	Topic     string
+	Group     string
+	Key       string
+	Partition int32
+	Offset    int64
+	Reason    string
+	Attempts  int
+	FailedAt  int64 //unix milliseconds
+	Value     []byte
+}
+
+//permanentError is never retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+//Permanent marks err of a message handler as not worth a retry, the message goes to the dead letter topic at once
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+//DeadLetterTopic is where the failed messages of topic go
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterTopicSuffix
+}
+
+//nextInterval doubles interval up to max
+func nextInterval(interval, max time.Duration) time.Duration {
+	interval *= 2
+	if interval > max {
+		interval = max
+	}
+
+	return interval
+}
+
+//handle calls Handler until it succeeds, fails permanently or runs out of attempts, then the message is acked
+func (mqs *MqService) handle(msg *Message) {
+	if msg.Err != nil {
+		mqs.deadLetter(msg, msg.Err, 0)
+		msg.Ack()
+		return
+	}
+
+	interval := PublishRetryMinInterval
+	attempts := mqs.MaxAttempts
+	if attempts <= 0 {
+		attempts = HandleMaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := mqs.Handler(msg)
+		if err == nil {
+			break
+		}
+		logger.Error(err, "topic =", msg.Topic, "offset =", msg.Offset, "attempt =", attempt)
+
+		if IsPermanent(err) || attempt >= attempts {
+			mqs.deadLetter(msg, err, attempt)
+			break
+		}
+
+		time.Sleep(interval)
+		interval = nextInterval(interval, PublishRetryMaxInterval)
+	}
+
+	msg.Ack()
+}
+
+//deadLetter publishes msg with the reason, it is retried like any publish so the message is never lost
+func (mqs *MqService) deadLetter(msg *Message, reason error, attempts int) {
+	topic := mqs.DeadLetterTopic
+	if topic == "" {
+		topic = DeadLetterTopic(msg.Topic)
+	}
+
+	dl := &DeadLetter{
+		Topic:     msg.Topic,
+		Group:     mqs.ConsumerName,
+		Key:       msg.Key,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Reason:    reason.Error(),
+		Attempts:  attempts,
+		FailedAt:  time.Now().UnixNano() / int64(time.Millisecond),
+		Value:     msg.Value,
+	}
+
+	//Keep the correlation id when the message was an envelope
+	correlationId := ""
+	if env, err := UnmarshalEnvelope(msg.Value); err == nil {
+		correlationId = env.CorrelationId
+	}
+
+	env, err := NewEnvelope(MessageTypeDeadLetter, msg.Key, correlationId, dl)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	data, err := env.Marshal()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	logger.Warn("Dead letter to", topic, "reason =", dl.Reason, "offset =", msg.Offset)
+	metrics.DeadLetters.WithLabelValues(msg.Topic).Inc()
+
+	mqs.publishTo(topic, msg.Key, data)
+}
@@ -0,0 +1,487 @@
+package msgqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	EmbeddedBrokerTimeout = 10 * time.Second
+)
+
+//Frames of the embedded broker protocol, one JSON object per line in both directions.
+//The client sends pub, sub, unsub and ack, the server answers pub and sub with ok or err and pushes msg
+const (
+	frameOpPublish     = "pub"
+	frameOpSubscribe   = "sub"
+	frameOpUnsubscribe = "unsub"
+	frameOpAck         = "ack"
+	frameOpMessage     = "msg"
+	frameOpOk          = "ok"
+	frameOpError       = "err"
+)
+
+type frame struct {
+	Op     string   `json:"op"`
+	Id     uint64   `json:"id,omitempty"`  //request id, echoed by ok and err
+	Sub    uint64   `json:"sub,omitempty"` //subscription id, chosen by the client
+	Topic  string   `json:"topic,omitempty"`
+	Group  string   `json:"group,omitempty"`
+	Key    string   `json:"key,omitempty"`
+	Values [][]byte `json:"values,omitempty"`
+	Offset int64    `json:"offset,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+//EmbeddedBroker serves Broker over TCP from inside a process, so services in other processes can talk without kafka
+type EmbeddedBroker struct {
+	Addr   string
+	Broker Broker //optional, a new MemoryBroker by default
+
+	listener net.Listener
+	lock     sync.Mutex
+}
+
+func (eb *EmbeddedBroker) ListenAndServe() error {
+	listener, err := net.Listen("tcp", eb.Addr)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return eb.Serve(listener)
+}
+
+func (eb *EmbeddedBroker) Serve(listener net.Listener) error {
+	eb.lock.Lock()
+	eb.listener = listener
+	if eb.Broker == nil {
+		eb.Broker = NewMemoryBroker()
+	}
+	eb.lock.Unlock()
+
+	logger.Info("Embedded broker on", listener.Addr().String())
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		go eb.serveConn(conn)
+	}
+}
+
+func (eb *EmbeddedBroker) Close() error {
+	eb.lock.Lock()
+	defer eb.lock.Unlock()
+
+	if eb.listener == nil {
+		return nil
+	}
+
+	return eb.listener.Close()
+}
+
+type brokerConn struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	lock    sync.Mutex
+
+	subs    map[uint64]Subscription
+	pending map[uint64]map[int64]*Message //sub -> offset -> message waiting for ack
+	subLock sync.Mutex
+}
+
+func (bc *brokerConn) send(f *frame) error {
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
+	bc.conn.SetWriteDeadline(time.Now().Add(EmbeddedBrokerTimeout))
+	return bc.encoder.Encode(f)
+}
+
+func (bc *brokerConn) reply(id uint64, err error) {
+	f := &frame{Op: frameOpOk, Id: id}
+	if err != nil {
+		f.Op = frameOpError
+		f.Error = err.Error()
+	}
+
+	bc.send(f)
+}
+
+func (bc *brokerConn) forward(subId uint64, sub Subscription) {
+	for msg := range sub.Messages() {
+		bc.subLock.Lock()
+		if bc.pending[subId] == nil {
+			bc.pending[subId] = make(map[int64]*Message)
+		}
+		bc.pending[subId][msg.Offset] = msg
+		bc.subLock.Unlock()
+
+		err := bc.send(&frame{
+			Op:     frameOpMessage,
+			Sub:    subId,
+			Topic:  msg.Topic,
+			Key:    msg.Key,
+			Values: [][]byte{msg.Value},
+			Offset: msg.Offset,
+		})
+		if err != nil {
+			logger.Error(err)
+			return
+		}
+	}
+}
+
+func (eb *EmbeddedBroker) serveConn(conn net.Conn) {
+	bc := &brokerConn{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		subs:    make(map[uint64]Subscription),
+		pending: make(map[uint64]map[int64]*Message),
+	}
+
+	defer func() {
+		conn.Close()
+
+		bc.subLock.Lock()
+		for _, sub := range bc.subs {
+			sub.Close()
+		}
+		bc.subLock.Unlock()
+	}()
+
+	decoder := json.NewDecoder(conn)
+
+	for {
+		f := &frame{}
+		err := decoder.Decode(f)
+		if err != nil {
+			logger.Debug(conn.RemoteAddr().String(), err)
+			return
+		}
+
+		switch f.Op {
+		case frameOpPublish:
+			bc.reply(f.Id, eb.Broker.Publish(f.Topic, f.Key, f.Values...))
+
+		case frameOpSubscribe:
+			bc.subLock.Lock()
+			_, exist := bc.subs[f.Sub]
+			bc.subLock.Unlock()
+			if exist {
+				bc.reply(f.Id, errors.New("Subscription id in use!"))
+				continue
+			}
+
+			sub, err := eb.Broker.Subscribe(f.Topic, f.Group)
+			if err != nil {
+				bc.reply(f.Id, err)
+				continue
+			}
+
+			bc.subLock.Lock()
+			bc.subs[f.Sub] = sub
+			bc.subLock.Unlock()
+
+			bc.reply(f.Id, nil)
+			go bc.forward(f.Sub, sub)
+
+		case frameOpUnsubscribe:
+			bc.subLock.Lock()
+			if sub, ok := bc.subs[f.Sub]; ok {
+				sub.Close()
+				delete(bc.subs, f.Sub)
+				delete(bc.pending, f.Sub)
+			}
+			bc.subLock.Unlock()
+
+		case frameOpAck:
+			bc.subLock.Lock()
+			msg, ok := bc.pending[f.Sub][f.Offset]
+			if ok {
+				delete(bc.pending[f.Sub], f.Offset)
+			}
+			bc.subLock.Unlock()
+
+			if ok {
+				msg.Ack()
+			}
+
+		default:
+			bc.reply(f.Id, errors.New("Unknown op "+f.Op))
+		}
+	}
+}
+
+//EmbeddedClient is the Broker of a process talking to an EmbeddedBroker
+type EmbeddedClient struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	lock    sync.Mutex
+
+	nextId  uint64
+	calls   map[uint64]chan *frame
+	subs    map[uint64]*embeddedSubscription
+	closed  bool
+	mapLock sync.Mutex
+
+	stopped chan bool //closed when the connection is gone
+}
+
+func DialEmbeddedBroker(addr string) (*EmbeddedClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, EmbeddedBrokerTimeout)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	c := &EmbeddedClient{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		calls:   make(map[uint64]chan *frame),
+		subs:    make(map[uint64]*embeddedSubscription),
+		stopped: make(chan bool),
+	}
+
+	go c.readLoop()
+
+	return c, nil
+}
+
+func (c *EmbeddedClient) send(f *frame) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(EmbeddedBrokerTimeout))
+	return c.encoder.Encode(f)
+}
+
+func (c *EmbeddedClient) readLoop() {
+	defer c.shutdown()
+
+	decoder := json.NewDecoder(c.conn)
+
+	for {
+		f := &frame{}
+		err := decoder.Decode(f)
+		if err != nil {
+			logger.Debug(err)
+			return
+		}
+
+		c.mapLock.Lock()
+		switch f.Op {
+		case frameOpOk, frameOpError:
+			if ch, ok := c.calls[f.Id]; ok {
+				delete(c.calls, f.Id)
+				ch <- f
+			}
+			c.mapLock.Unlock()
+
+		case frameOpMessage:
+			sub, ok := c.subs[f.Sub]
+			c.mapLock.Unlock()
+			if !ok || len(f.Values) == 0 {
+				continue
+			}
+
+			subId, offset := f.Sub, f.Offset
+			msg := &Message{
+				Topic:  f.Topic,
+				Key:    f.Key,
+				Value:  f.Values[0],
+				Offset: f.Offset,
+				ack: func() {
+					c.send(&frame{Op: frameOpAck, Sub: subId, Offset: offset})
+				},
+			}
+
+			sub.push(msg)
+
+		default:
+			c.mapLock.Unlock()
+		}
+	}
+}
+
+func (c *EmbeddedClient) shutdown() {
+	c.mapLock.Lock()
+	defer c.mapLock.Unlock()
+
+	c.closed = true
+	close(c.stopped)
+
+	for id, ch := range c.calls {
+		ch <- &frame{Op: frameOpError, Id: id, Error: ErrBrokerClosed.Error()}
+		delete(c.calls, id)
+	}
+
+	for id := range c.subs {
+		delete(c.subs, id)
+	}
+}
+
+//call sends f with a new request id and waits for ok or err
+func (c *EmbeddedClient) call(f *frame) error {
+	ch := make(chan *frame, 1)
+
+	c.mapLock.Lock()
+	if c.closed {
+		c.mapLock.Unlock()
+		return ErrBrokerClosed
+	}
+	c.nextId++
+	f.Id = c.nextId
+	c.calls[f.Id] = ch
+	c.mapLock.Unlock()
+
+	err := c.send(f)
+	if err != nil {
+		logger.Error(err)
+		c.mapLock.Lock()
+		delete(c.calls, f.Id)
+		c.mapLock.Unlock()
+		return err
+	}
+
+	select {
+	case reply := <-ch:
+		if reply.Op == frameOpError {
+			return errors.New(reply.Error)
+		}
+		return nil
+	case <-time.After(EmbeddedBrokerTimeout):
+		c.mapLock.Lock()
+		delete(c.calls, f.Id)
+		c.mapLock.Unlock()
+		return errors.New("Embedded broker timeout!")
+	}
+}
+
+func (c *EmbeddedClient) Publish(topic, key string, values ...[]byte) error {
+	return c.call(&frame{
+		Op:     frameOpPublish,
+		Topic:  topic,
+		Key:    key,
+		Values: values,
+	})
+}
+
+func (c *EmbeddedClient) Subscribe(topic, group string) (Subscription, error) {
+	c.mapLock.Lock()
+	c.nextId++
+	subId := c.nextId
+	sub := &embeddedSubscription{
+		client: c,
+		id:     subId,
+		msgs:   make(chan *Message),
+		done:   make(chan bool),
+		notify: make(chan bool, 1),
+	}
+	c.subs[subId] = sub
+	c.mapLock.Unlock()
+
+	err := c.call(&frame{
+		Op:    frameOpSubscribe,
+		Sub:   subId,
+		Topic: topic,
+		Group: group,
+	})
+	if err != nil {
+		logger.Error(err)
+		c.mapLock.Lock()
+		delete(c.subs, subId)
+		c.mapLock.Unlock()
+		return nil, err
+	}
+
+	go sub.pump()
+
+	return sub, nil
+}
+
+func (c *EmbeddedClient) Close() error {
+	return c.conn.Close()
+}
+
+//embeddedSubscription queues what the server pushes, so a slow consumer never blocks the replies to Publish
+type embeddedSubscription struct {
+	client *EmbeddedClient
+	id     uint64
+	msgs   chan *Message
+	done   chan bool
+	once   sync.Once
+
+	queue  []*Message
+	notify chan bool
+	lock   sync.Mutex
+}
+
+func (sub *embeddedSubscription) push(msg *Message) {
+	sub.lock.Lock()
+	sub.queue = append(sub.queue, msg)
+	sub.lock.Unlock()
+
+	select {
+	case sub.notify <- true:
+	default:
+	}
+}
+
+//pump closes Messages when the subscription or the connection is closed, queued messages are not acked and come again
+func (sub *embeddedSubscription) pump() {
+	defer close(sub.msgs)
+
+	for {
+		sub.lock.Lock()
+		if len(sub.queue) > 0 {
+			msg := sub.queue[0]
+			sub.queue = sub.queue[1:]
+			sub.lock.Unlock()
+
+			select {
+			case sub.msgs <- msg:
+			case <-sub.done:
+				return
+			case <-sub.client.stopped:
+				return
+			}
+			continue
+		}
+		sub.lock.Unlock()
+
+		select {
+		case <-sub.notify:
+		case <-sub.done:
+			return
+		case <-sub.client.stopped:
+			return
+		}
+	}
+}
+
+func (sub *embeddedSubscription) Messages() <-chan *Message {
+	return sub.msgs
+}
+
+func (sub *embeddedSubscription) Close() error {
+	sub.once.Do(func() {
+		close(sub.done)
+
+		sub.client.mapLock.Lock()
+		delete(sub.client.subs, sub.id)
+		sub.client.mapLock.Unlock()
+
+		sub.client.send(&frame{Op: frameOpUnsubscribe, Sub: sub.id})
+	})
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package msgqueue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"time"
+)
+
+const (
+	EnvelopeVersion = 1
+)
+
+//Message types of the envelope
+const (
+	MessageTypeTelemetry   = "telemetry"    //gateway -> backend, payload telemetry.Record
+	MessageTypeAlert       = "alert"        //alert service -> backend, payload alert.Alert
+	MessageTypeWebCommand  = "web_command"  //web -> gateway, payload gateway.WebRequest
+	MessageTypeWebResponse = "web_response" //gateway -> web, payload gateway.WebResponse
+	MessageTypeLifecycle   = "lifecycle"    //gateway -> backend, payload gateway.LifecycleEvent
+)
+
+//Envelope wraps every message between the gateway and the backend services, one JSON object per message:
+//
+//	{"type":"telemetry","version":1,"correlationid":"9f0c...","thingid":"WDDUX52684DFR4582","timestamp":1700000000000,"payload":{...}}
+//
+//thingid is also the message key, so the messages of one thing keep their order.
+//A reply carries the correlationid of its request, derived messages the one of the message they come from.
+//Readers ignore unknown fields and refuse versions newer than EnvelopeVersion.
+//Integrity and encryption of the whole message are left to the broker, see kafka.Packing
+type Envelope struct {
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	CorrelationId string          `json:"correlationid,omitempty"`
+	ThingId       string          `json:"thingid,omitempty"`
+	Timestamp     int64           `json:"timestamp"` //unix milliseconds, set by the producer
+	Payload       json.RawMessage `json:"payload"`
+}
+
+//NewCorrelationId is a random 128 bit id in hex
+func NewCorrelationId() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+//NewEnvelope marshals payload to JSON, a new correlation id is made when correlationId is empty
+func NewEnvelope(msgType, thingId, correlationId string, payload interface{}) (*Envelope, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if correlationId == "" {
+		correlationId = NewCorrelationId()
+	}
+
+	env := &Envelope{
+		Type:          msgType,
+		Version:       EnvelopeVersion,
+		CorrelationId: correlationId,
+		ThingId:       thingId,
+		Timestamp:     time.Now().UnixNano() / int64(time.Millisecond),
+		Payload:       data,
+	}
+
+	return env, nil
+}
+
+//Reply is an envelope of msgType for the same thing and correlation id
+func (env *Envelope) Reply(msgType string, payload interface{}) (*Envelope, error) {
+	return NewEnvelope(msgType, env.ThingId, env.CorrelationId, payload)
+}
+
+func (env *Envelope) Marshal() ([]byte, error) {
+	data, err := json.Marshal(env)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+//Decode unmarshals the payload into v
+func (env *Envelope) Decode(v interface{}) error {
+	err := json.Unmarshal(env.Payload, v)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+//UnmarshalEnvelope also takes the bare JSON sent before the envelope existed, it comes back as version 0 without type
+func UnmarshalEnvelope(data []byte) (*Envelope, error) {
+	env := &Envelope{}
+	err := json.Unmarshal(data, env)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if env.Type == "" && env.Version == 0 {
+		return &Envelope{Payload: data}, nil
+	}
+
+	if env.Version > EnvelopeVersion {
+		logger.Error("Envelope version =", env.Version)
+		return nil, errors.New("Envelope version not supported!")
+	}
+
+	return env, nil
+}
+
+//EnvelopeKey is the message key of data, empty when data is no envelope
+func EnvelopeKey(data []byte) string {
+	env := &Envelope{}
+	if json.Unmarshal(data, env) != nil {
+		return ""
+	}
+
+	return env.ThingId
+}
@@ -5,9 +5,9 @@ import (
 	"errors"
 	"github.com/Shopify/sarama"
 	"github.com/bsm/sarama-cluster"
-	"github.com/harveywangdao/road/cache/redis"
 	"github.com/harveywangdao/road/crypto/aes"
 	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/metrics"
 	"github.com/harveywangdao/road/util"
 	/*"strconv"*/
 	"sync"
@@ -56,43 +56,6 @@ func Consumer1(addrs []string) error {
 	return nil
 }
 
-func Consumer2(addrs []string, groupID string, topics []string) error {
-	config := cluster.NewConfig()
-	config.Consumer.Return.Errors = true
-	config.Group.Return.Notifications = true
-	config.Consumer.Offsets.CommitInterval = 1 * time.Second
-	config.Consumer.Offsets.Initial = sarama.OffsetNewest
-
-	consumer, err := cluster.NewConsumer(addrs, groupID, topics, config)
-	if err != nil {
-		logger.Error(err)
-		return err
-	}
-	defer consumer.Close()
-
-	go func(consumer *cluster.Consumer) {
-		errors := consumer.Errors()
-		notifications := consumer.Notifications()
-		for {
-			select {
-			case err := <-errors:
-				if err != nil {
-					logger.Error(err)
-				}
-			case ntf := <-notifications:
-				logger.Info("ntf =", ntf)
-			}
-		}
-	}(consumer)
-
-	for msg := range consumer.Messages() {
-		logger.Info(msg.Topic, msg.Partition, msg.Offset, msg.Key, msg.Value)
-		consumer.MarkOffset(msg, "")
-	}
-
-	return nil
-}
-
 func SyncProducer(addrs []string, topic string) error {
 	config := sarama.NewConfig()
 	//  config.Producer.RequiredAcks = sarama.WaitForAll
@@ -166,11 +129,18 @@ type KafkaMq struct {
 }
 
 const (
-	MqMessageHeaderId      = 0x12345678
-	MqMessageAesEncryptKey = "1234567890123456"
+	MqMessageHeaderId      = 0x12345678 //aes encrypted data
+	MqMessageHeaderIdPlain = 0x12345679 //data as it is
 	CrcLen                 = 4
 )
 
+//Modes of Packing
+const (
+	PackNone = iota //data as it is
+	PackCrc         //header, data and crc32
+	PackAes         //header, aes encrypted data and crc32
+)
+
 type MqMessageHeader struct {
 	MqMessageHeaderID uint32
 	DataLength        uint32
@@ -180,21 +150,57 @@ func (ka *KafkaMq) Register() error {
 	return nil
 }
 
-func PackData(data []byte) ([]byte, error) {
-	return data, nil
+//Packing selects what Pack does, Key is needed by PackAes and must be 16, 24 or 32 bytes.
+//Every producer and consumer has its own, the zero Packing is PackNone
+type Packing struct {
+	Mode int
+	Key  []byte
+}
+
+func (pk Packing) Check() error {
+	switch pk.Mode {
+	case PackNone, PackCrc:
+	case PackAes:
+		switch len(pk.Key) {
+		case 16, 24, 32:
+		default:
+			logger.Error("Aes key length =", len(pk.Key))
+			return errors.New("Aes key must be 16, 24 or 32 bytes!")
+		}
+	default:
+		logger.Error("Packing mode =", pk.Mode)
+		return errors.New("Unknown packing mode!")
+	}
+
+	return nil
+}
+
+func (pk Packing) Pack(data []byte) ([]byte, error) {
+	if pk.Mode == PackNone {
+		return data, nil
+	}
+
 	packedData := make([]byte, 0, 2048)
 
+	headerId := uint32(MqMessageHeaderIdPlain)
+	body := data
+
 	//Encrypt data
-	encryptData, err := aes.AesEncrypt(data, []byte(MqMessageAesEncryptKey))
-	if err != nil {
-		logger.Error(err)
-		return nil, err
+	if pk.Mode == PackAes {
+		encryptData, err := aes.AesEncrypt(data, pk.Key)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		headerId = MqMessageHeaderId
+		body = encryptData
 	}
 
 	//Header
 	mqHeader := &MqMessageHeader{
-		MqMessageHeaderID: MqMessageHeaderId,
-		DataLength:        uint32(len(encryptData)),
+		MqMessageHeaderID: headerId,
+		DataLength:        uint32(len(body)),
 	}
 
 	mqHeaderData, err := util.StructToByteSlice(mqHeader)
@@ -206,7 +212,7 @@ func PackData(data []byte) ([]byte, error) {
 	packedData = append(packedData, mqHeaderData...)
 
 	//data
-	packedData = append(packedData, encryptData...)
+	packedData = append(packedData, body...)
 
 	//crc
 	crc, err := util.Crc32(packedData)
@@ -226,8 +232,42 @@ func PackData(data []byte) ([]byte, error) {
 	return packedData, nil
 }
 
-func UnpackData(packedData []byte) ([]byte, error) {
-	return packedData, nil
+//packedHeader returns the header when packedData looks like the output of Packing.Pack
+func packedHeader(packedData []byte) (*MqMessageHeader, bool) {
+	mqHeader := &MqMessageHeader{}
+	mqHeaderLen := binary.Size(mqHeader)
+
+	if len(packedData) < mqHeaderLen+CrcLen {
+		return nil, false
+	}
+
+	if err := util.ByteSliceToStruct(packedData[0:mqHeaderLen], mqHeader); err != nil {
+		return nil, false
+	}
+
+	if mqHeader.MqMessageHeaderID != MqMessageHeaderId && mqHeader.MqMessageHeaderID != MqMessageHeaderIdPlain {
+		return nil, false
+	}
+
+	if int(mqHeader.DataLength) != len(packedData)-mqHeaderLen-CrcLen {
+		return nil, false
+	}
+
+	return mqHeader, true
+}
+
+//Unpack accepts packed data of any mode, unpacked data only with PackNone
+func (pk Packing) Unpack(packedData []byte) ([]byte, error) {
+	//Check Header
+	mqHeader, ok := packedHeader(packedData)
+	if !ok {
+		if pk.Mode == PackNone {
+			return packedData, nil
+		}
+		return nil, errors.New("Data Message Header Fail!")
+	}
+	mqHeaderLen := binary.Size(mqHeader)
+
 	//Check Crc
 	crc, err := util.Crc32(packedData[0 : len(packedData)-CrcLen])
 	if err != nil {
@@ -245,20 +285,17 @@ func UnpackData(packedData []byte) ([]byte, error) {
 		return nil, errors.New("Data Crc Fail!")
 	}
 
-	//Check Header
-	mqHeader := MqMessageHeader{}
-	MqMessageHeaderLen := binary.Size(mqHeader)
-	if err = util.ByteSliceToStruct(packedData[0:MqMessageHeaderLen], &mqHeader); err != nil {
-		logger.Error(err)
-		return nil, err
+	body := packedData[mqHeaderLen : len(packedData)-CrcLen]
+	if mqHeader.MqMessageHeaderID == MqMessageHeaderIdPlain {
+		return body, nil
 	}
 
-	if mqHeader.MqMessageHeaderID != MqMessageHeaderId {
-		return nil, errors.New("Data Message Header Fail!")
+	//Decrypt data
+	if pk.Key == nil {
+		return nil, errors.New("Aes key not set!")
 	}
 
-	//Decrypt data
-	data, err := aes.AesDecrypt(packedData[MqMessageHeaderLen:len(packedData)-CrcLen], []byte(MqMessageAesEncryptKey))
+	data, err := aes.AesDecrypt(body, pk.Key)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
@@ -267,73 +304,233 @@ func UnpackData(packedData []byte) ([]byte, error) {
 	return data, nil
 }
 
+//////////////////////////////////////////////////ProducerConfig/////////////////////////////////////////////////
+
+//ProducerConfig is shared by Producer and KeyedProducer
+type ProducerConfig struct {
+	RequiredAcks sarama.RequiredAcks
+	MaxRetries   int           //retries inside sarama before a message fails
+	RetryBackoff time.Duration //also the wait before a failed producer is created again
+	Idempotent   bool          //needs WaitForAll, kafka 0.11 and one request in flight
+	MaxBuffered  int           //Producer.Send blocks while this many messages are not acked
+}
+
+func DefaultProducerConfig() ProducerConfig {
+	return ProducerConfig{
+		RequiredAcks: sarama.WaitForAll,
+		MaxRetries:   5,
+		RetryBackoff: 100 * time.Millisecond,
+		MaxBuffered:  1024,
+	}
+}
+
+func (pc ProducerConfig) saramaConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = pc.RequiredAcks
+	config.Producer.Retry.Max = pc.MaxRetries
+	config.Producer.Retry.Backoff = pc.RetryBackoff
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
+	config.Producer.Timeout = 5 * time.Second
+
+	if pc.Idempotent {
+		config.Version = sarama.V0_11_0_0
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+		if config.Producer.Retry.Max < 1 {
+			config.Producer.Retry.Max = 1
+		}
+	}
+
+	return config
+}
+
 //////////////////////////////////////////////////Producer///////////////////////////////////////////////////////
 
+var (
+	ErrProducerClosed = errors.New("Producer closed!")
+)
+
+//Delivery is the result of Producer.Send, it is done when kafka acked the message or gave up on it
+type Delivery struct {
+	Partition int32
+	Offset    int64
+
+	err      error
+	start    time.Time
+	done     chan bool
+	callback func(*Delivery)
+}
+
+func (d *Delivery) finish(err error) {
+	d.err = err
+	close(d.done)
+
+	if d.callback != nil {
+		d.callback(d)
+	}
+}
+
+//Done is closed when the delivery is finished
+func (d *Delivery) Done() <-chan bool {
+	return d.done
+}
+
+//Wait blocks until the delivery is finished
+func (d *Delivery) Wait() error {
+	<-d.done
+	return d.err
+}
+
+func (d *Delivery) Err() error {
+	return d.err
+}
+
+//Producer sends without waiting, every message is reported through its Delivery.
+//A producer that lost a message is replaced by a new one on the next Send
 type Producer struct {
 	AsyncProducer sarama.AsyncProducer
 	Addrs         []string
 	Topic         string
-	done          chan bool
+	Config        ProducerConfig
+	Packing       Packing
+
+	buffered chan bool //one token per message not yet acked
+	closed   bool
+	lock     sync.Mutex
+	wg       sync.WaitGroup
 }
 
 func NewProducer(addrs []string, topic string) (*Producer, error) {
+	return NewProducerWithConfig(addrs, topic, DefaultProducerConfig())
+}
+
+func NewProducerWithConfig(addrs []string, topic string, config ProducerConfig) (*Producer, error) {
 	p := &Producer{}
 	p.Addrs = addrs
 	p.Topic = topic
-	p.done = make(chan bool)
+	p.Config = config
 
-	config := sarama.NewConfig()
-	config.Producer.Return.Successes = true
-	/*config.Producer.Timeout = 5 * time.Second*/
+	if p.Config.MaxBuffered <= 0 {
+		p.Config.MaxBuffered = DefaultProducerConfig().MaxBuffered
+	}
+	p.buffered = make(chan bool, p.Config.MaxBuffered)
 
-	var err error
-	p.AsyncProducer, err = sarama.NewAsyncProducer(p.Addrs, config)
+	err := p.connect()
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
-	/*defer producer.Close()*/
 
-	go func(producer sarama.AsyncProducer, done chan bool) {
-		errors := producer.Errors()
-		success := producer.Successes()
-		var errnum, successnum int = 0, 0
+	return p, nil
+}
 
-		for {
-			select {
-			case err, ok := <-errors:
-				if !ok {
-					logger.Info("Exit from errors and success")
-					return
-				}
-				errnum++
-				logger.Info("err =", err, "errnum =", errnum)
+//connect needs p.lock or a producer nobody else knows yet
+func (p *Producer) connect() error {
+	asyncProducer, err := sarama.NewAsyncProducer(p.Addrs, p.Config.saramaConfig())
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
 
-			case suc, ok := <-success:
-				if !ok {
-					logger.Info("Exit from errors and success")
-					return
-				}
-				successnum++
-				logger.Debug("Partition =", suc.Partition, "Offset =", suc.Offset) //, "Key =", suc.Key, "Value =", suc.Value)
+	p.AsyncProducer = asyncProducer
+	p.wg.Add(1)
+	go p.deliver(asyncProducer)
+
+	return nil
+}
+
+//deliver finishes the deliveries of one sarama producer until both its channels are closed
+func (p *Producer) deliver(producer sarama.AsyncProducer) {
+	defer p.wg.Done()
+
+	errors := producer.Errors()
+	success := producer.Successes()
+	var errnum, successnum int = 0, 0
+
+	for errors != nil || success != nil {
+		select {
+		case err, ok := <-errors:
+			if !ok {
+				errors = nil
+				continue
+			}
+			errnum++
+			logger.Error("err =", err, "errnum =", errnum)
+
+			//reset needs the lock that a blocked Send may hold until these errors are read
+			if producerBroken(err.Err) {
+				go p.reset(producer)
+			}
+
+			if d, ok := err.Msg.Metadata.(*Delivery); ok {
+				metrics.ObserveProduce(err.Msg.Topic, d.start, err)
+				d.finish(err.Err)
+				<-p.buffered
+			}
 
-			case <-done:
-				logger.Info("Exit from errors and success")
-				return
+		case suc, ok := <-success:
+			if !ok {
+				success = nil
+				continue
+			}
+			successnum++
+			logger.Debug("Partition =", suc.Partition, "Offset =", suc.Offset) //, "Key =", suc.Key, "Value =", suc.Value)
+
+			if d, ok := suc.Metadata.(*Delivery); ok {
+				metrics.ObserveProduce(suc.Topic, d.start, nil)
+				d.Partition = suc.Partition
+				d.Offset = suc.Offset
+				d.finish(nil)
+				<-p.buffered
 			}
 		}
-	}(p.AsyncProducer, p.done)
+	}
 
-	return p, nil
+	logger.Info("Exit from errors and success")
+}
+
+//producerBroken tells broker trouble from a single bad message
+func producerBroken(err error) bool {
+	switch err {
+	case sarama.ErrMessageSizeTooLarge, sarama.ErrInvalidMessage, sarama.ErrShuttingDown:
+		return false
+	}
+
+	return true
 }
 
-func (p *Producer) Send(partition int32, data []byte) error {
+//reset retires producer after it gave up on a message, the messages it still holds are finished by deliver
+func (p *Producer) reset(producer sarama.AsyncProducer) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.AsyncProducer != producer {
+		return
+	}
+
+	logger.Warn("Kafka producer reset, topic =", p.Topic)
+	p.AsyncProducer = nil
+	producer.AsyncClose()
+}
+
+//Send blocks while MaxBuffered messages are not acked yet, callback is optional and called from the delivery routine
+func (p *Producer) Send(partition int32, data []byte, callback func(*Delivery)) (*Delivery, error) {
 	logger.Debug("Send data =", data)
 
-	packedData, err := PackData(data)
+	packedData, err := p.Packing.Pack(data)
 	if err != nil {
 		logger.Error(err)
-		return err
+		return nil, err
+	}
+
+	d := &Delivery{
+		Partition: partition,
+		Offset:    -1,
+		start:     time.Now(), //produce latency
+		done:      make(chan bool),
+		callback:  callback,
 	}
 
 	msg := &sarama.ProducerMessage{
@@ -341,144 +538,217 @@ func (p *Producer) Send(partition int32, data []byte) error {
 		Value: sarama.ByteEncoder(packedData),
 		//Key:       sarama.StringEncoder("AsyncProducer from TSP Key" + strconv.Itoa(int(partition))),
 		Partition: partition,
+		Metadata:  d,
+	}
+
+	p.buffered <- true
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		<-p.buffered
+		return nil, ErrProducerClosed
+	}
+
+	if p.AsyncProducer == nil {
+		time.Sleep(p.Config.RetryBackoff)
+
+		err = p.connect()
+		if err != nil {
+			logger.Error(err)
+			<-p.buffered
+			return nil, err
+		}
 	}
 
 	p.AsyncProducer.Input() <- msg
 
-	return nil
+	return d, nil
 }
 
+//Close waits until every message sent is finished
 func (p *Producer) Close() {
-	close(p.done)
-	p.AsyncProducer.Close()
+	p.lock.Lock()
+	p.closed = true
+	if p.AsyncProducer != nil {
+		p.AsyncProducer.AsyncClose()
+		p.AsyncProducer = nil
+	}
+	p.lock.Unlock()
+
+	p.wg.Wait()
 }
 
-//////////////////////////////////////////////////Consumer///////////////////////////////////////////////////////
+//////////////////////////////////////////////////KeyedProducer///////////////////////////////////////////////////
 
-type Consumer struct {
-	ConsumerHigh sarama.Consumer
+//KeyedProducer waits for the acks of RequiredAcks, messages with the same key go to the same partition in order
+type KeyedProducer struct {
+	SyncProducer sarama.SyncProducer
 	Addrs        []string
 	Topic        string
+	Packing      Packing
+}
 
-	partitionList            []int32
-	partitionConsumer        []sarama.PartitionConsumer
-	partitionsOffsetRedisKey string
+func NewKeyedProducer(addrs []string, topic string) (*KeyedProducer, error) {
+	return NewKeyedProducerWithConfig(addrs, topic, DefaultProducerConfig())
 }
 
-const (
-	RedisIpPort = "localhost:6379"
-)
+func NewKeyedProducerWithConfig(addrs []string, topic string, producerConfig ProducerConfig) (*KeyedProducer, error) {
+	p := &KeyedProducer{}
+	p.Addrs = addrs
+	p.Topic = topic
 
-func NewConsumer(addrs []string, topic string) (*Consumer, error) {
-	consumer := &Consumer{}
-	consumer.Addrs = addrs
-	consumer.Topic = topic
-	consumer.partitionConsumer = make([]sarama.PartitionConsumer, 0)
-	consumer.partitionsOffsetRedisKey = topic + "OffsetRedisKey"
+	config := producerConfig.saramaConfig()
+	config.Producer.Partitioner = sarama.NewHashPartitioner
 
 	var err error
-	consumer.ConsumerHigh, err = sarama.NewConsumer(consumer.Addrs, nil)
+	p.SyncProducer, err = sarama.NewSyncProducer(p.Addrs, config)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
-	/*defer consumer.Close()*/
 
-	consumer.partitionList, err = consumer.ConsumerHigh.Partitions(consumer.Topic)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
-	}
+	return p, nil
+}
 
-	logger.Debug("partitionList =", consumer.partitionList)
+//Send returns after every message is acked by kafka
+func (p *KeyedProducer) Send(key string, datas ...[]byte) error {
+	msgs := make([]*sarama.ProducerMessage, 0, len(datas))
 
-	red, err := redis.NewRedis(RedisIpPort)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
-	}
-	defer red.Close()
-
-	var offsets []int64 = nil
-	//red.DeleteKey(consumer.partitionsOffsetRedisKey)
-	if red.IsKeyExist(consumer.partitionsOffsetRedisKey) {
-		offsets, err = red.GetInt64SliceList(consumer.partitionsOffsetRedisKey)
+	for _, data := range datas {
+		packedData, err := p.Packing.Pack(data)
 		if err != nil {
 			logger.Error(err)
-			return nil, err
-		}
-	} else {
-		offsets = make([]int64, len(consumer.partitionList))
-		for i := 0; i < len(offsets); i++ {
-			offsets[i] = -1
+			return err
 		}
 
-		err = red.CreateListByInt64Slice(consumer.partitionsOffsetRedisKey, offsets)
-		if err != nil {
-			logger.Error(err)
-			return nil, err
-		}
+		msgs = append(msgs, &sarama.ProducerMessage{
+			Topic: p.Topic,
+			Key:   sarama.StringEncoder(key),
+			Value: sarama.ByteEncoder(packedData),
+		})
 	}
 
-	for i := 0; i < len(consumer.partitionList); i++ {
-		logger.Debug("Offset =", offsets[i])
-
-		pc, err := consumer.ConsumerHigh.ConsumePartition(consumer.Topic, consumer.partitionList[i], offsets[i]+1) //sarama.OffsetNewest)//
-		if err != nil {
-			logger.Error(err)
-			return nil, err
-		}
-		//defer pc.Close()
-
-		consumer.partitionConsumer = append(consumer.partitionConsumer, pc)
+	start := time.Now()
+	err := p.SyncProducer.SendMessages(msgs)
+	metrics.ObserveProduce(p.Topic, start, err)
+	if err != nil {
+		logger.Error(err)
+		return err
 	}
 
-	return consumer, nil
+	return nil
+}
+
+func (p *KeyedProducer) Close() {
+	p.SyncProducer.Close()
 }
 
-func (c *Consumer) Partitions() ([]int32, error) {
-	partList := make([]int32, len(c.partitionList))
-	copy(partList, c.partitionList)
+//////////////////////////////////////////////////GroupConsumer//////////////////////////////////////////////////
+
+const (
+	GroupCommitInterval = 1 * time.Second
+	RebalanceBufferSize = 16
+)
 
-	return partList, nil
+//GroupConsumer joins a kafka consumer group, the partitions are shared with the other members
+//and offsets marked by Commit are committed to kafka every GroupCommitInterval and on Close
+type GroupConsumer struct {
+	ClusterConsumer *cluster.Consumer
+	Addrs           []string
+	GroupID         string
+	Topics          []string
+	Packing         Packing //set before the first ReadMessage
+
+	rebalances chan *cluster.Notification
 }
 
-func (c *Consumer) Read(partition int32) ([]byte, error) {
-	if partition < 0 || partition >= int32(len(c.partitionConsumer)) {
-		return nil, errors.New("Partition error.")
-	}
+func NewGroupConsumer(addrs []string, groupID string, topics []string) (*GroupConsumer, error) {
+	c := &GroupConsumer{}
+	c.Addrs = addrs
+	c.GroupID = groupID
+	c.Topics = topics
+	c.rebalances = make(chan *cluster.Notification, RebalanceBufferSize)
 
-	msg := <-c.partitionConsumer[partition].Messages()
+	config := cluster.NewConfig()
+	config.Consumer.Return.Errors = true
+	config.Group.Return.Notifications = true
+	config.Consumer.Offsets.CommitInterval = GroupCommitInterval
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest //a new group reads what is already queued
 
-	data, err := UnpackData(msg.Value)
+	var err error
+	c.ClusterConsumer, err = cluster.NewConsumer(c.Addrs, c.GroupID, c.Topics, config)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
-	logger.Debug("Partition =", msg.Partition, "Offset =", msg.Offset) //, "Key =", string(msg.Key), string(msg.Value))
-	logger.Debug("Read data =", string(data))
+	go c.watch()
 
-	red, err := redis.NewRedis(RedisIpPort)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
+	return c, nil
+}
+
+//watch drains errors and notifications, the cluster consumer stops rebalancing while a notification is unread
+func (c *GroupConsumer) watch() {
+	defer close(c.rebalances)
+
+	errors := c.ClusterConsumer.Errors()
+	notifications := c.ClusterConsumer.Notifications()
+
+	for errors != nil || notifications != nil {
+		select {
+		case err, ok := <-errors:
+			if !ok {
+				errors = nil
+				continue
+			}
+			logger.Error(err)
+
+		case ntf, ok := <-notifications:
+			if !ok {
+				notifications = nil
+				continue
+			}
+			logger.Info("Group =", c.GroupID, ntf.Type, "claimed =", ntf.Claimed, "released =", ntf.Released, "current =", ntf.Current)
+
+			select {
+			case c.rebalances <- ntf:
+			default:
+				logger.Warn("Rebalance notification dropped, group =", c.GroupID)
+			}
+		}
+	}
+}
+
+//Rebalances is closed by Close, notifications are dropped while it is full
+func (c *GroupConsumer) Rebalances() <-chan *cluster.Notification {
+	return c.rebalances
+}
+
+//ReadMessage returns the next message of any claimed partition, data is unpacked
+func (c *GroupConsumer) ReadMessage() (*sarama.ConsumerMessage, []byte, error) {
+	msg, ok := <-c.ClusterConsumer.Messages()
+	if !ok {
+		return nil, nil, errors.New("Group consumer closed.")
 	}
-	defer red.Close()
 
-	err = red.SetListValueByIndex(c.partitionsOffsetRedisKey, int(msg.Partition), msg.Offset)
+	logger.Debug("Topic =", msg.Topic, "Partition =", msg.Partition, "Offset =", msg.Offset)
+
+	data, err := c.Packing.Unpack(msg.Value)
 	if err != nil {
 		logger.Error(err)
-		return nil, err
+		return msg, nil, err
 	}
 
-	return data, nil
+	return msg, data, nil
 }
 
-func (c *Consumer) Close() {
-	for i := 0; i < len(c.partitionConsumer); i++ {
-		c.partitionConsumer[i].Close()
-	}
+//Commit marks msg processed, the offset reaches kafka with the next periodic commit
+func (c *GroupConsumer) Commit(msg *sarama.ConsumerMessage) {
+	c.ClusterConsumer.MarkOffset(msg, "")
+}
 
-	c.ConsumerHigh.Close()
+func (c *GroupConsumer) Close() error {
+	return c.ClusterConsumer.Close()
 }
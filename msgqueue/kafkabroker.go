@@ -0,0 +1,195 @@
+package msgqueue
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue/kafka"
+	"sync"
+)
+
+const (
+	DefaultGroupID = "road" //kafka group of subscribers without a group
+)
+
+//KafkaBroker keeps one producer per topic, subscriptions are members of kafka consumer groups
+type KafkaBroker struct {
+	Addrs    []string
+	Producer kafka.ProducerConfig
+	Packing  kafka.Packing //of every message published and received
+
+	producers map[string]*kafka.KeyedProducer
+	lock      sync.Mutex
+}
+
+func NewKafkaBroker(addr string) *KafkaBroker {
+	return &KafkaBroker{
+		Addrs:     []string{addr},
+		Producer:  kafka.DefaultProducerConfig(),
+		producers: make(map[string]*kafka.KeyedProducer),
+	}
+}
+
+func (kb *KafkaBroker) producer(topic string) (*kafka.KeyedProducer, error) {
+	kb.lock.Lock()
+	defer kb.lock.Unlock()
+
+	if p, ok := kb.producers[topic]; ok {
+		return p, nil
+	}
+
+	p, err := kafka.NewKeyedProducerWithConfig(kb.Addrs, topic, kb.Producer)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	p.Packing = kb.Packing
+
+	kb.producers[topic] = p
+
+	return p, nil
+}
+
+func (kb *KafkaBroker) Publish(topic, key string, values ...[]byte) error {
+	p, err := kb.producer(topic)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = p.Send(key, values...)
+	if err != nil {
+		logger.Error(err)
+		kb.dropProducer(topic, p)
+		return err
+	}
+
+	return nil
+}
+
+//dropProducer closes p after a failed send, the next Publish connects again
+func (kb *KafkaBroker) dropProducer(topic string, p *kafka.KeyedProducer) {
+	kb.lock.Lock()
+	defer kb.lock.Unlock()
+
+	if kb.producers[topic] != p {
+		return
+	}
+
+	delete(kb.producers, topic)
+	p.Close()
+}
+
+//Subscribe joins the kafka consumer group, members of one group get different partitions
+func (kb *KafkaBroker) Subscribe(topic, group string) (Subscription, error) {
+	if group == "" {
+		group = DefaultGroupID
+	}
+
+	consumer, err := kafka.NewGroupConsumer(kb.Addrs, group, []string{topic})
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	consumer.Packing = kb.Packing
+
+	sub := &kafkaSubscription{
+		consumer:   consumer,
+		msgs:       make(chan *Message),
+		rebalances: make(chan *Rebalance, kafka.RebalanceBufferSize),
+		done:       make(chan bool),
+	}
+
+	go sub.read()
+	go sub.watch()
+
+	return sub, nil
+}
+
+func (kb *KafkaBroker) Close() error {
+	kb.lock.Lock()
+	defer kb.lock.Unlock()
+
+	for topic, p := range kb.producers {
+		p.Close()
+		delete(kb.producers, topic)
+	}
+
+	return nil
+}
+
+type kafkaSubscription struct {
+	consumer   *kafka.GroupConsumer
+	msgs       chan *Message
+	rebalances chan *Rebalance
+	done       chan bool
+	once       sync.Once
+}
+
+func (sub *kafkaSubscription) read() {
+	defer close(sub.msgs)
+
+	for {
+		raw, data, err := sub.consumer.ReadMessage()
+		if raw == nil {
+			logger.Debug(err)
+			return
+		}
+
+		//Undecodable messages are passed on with Err for the dead letter topic, they would come again forever
+		if err != nil {
+			logger.Error(err)
+			data = raw.Value
+		}
+
+		msg := &Message{
+			Topic:     raw.Topic,
+			Key:       string(raw.Key),
+			Value:     data,
+			Err:       err,
+			Partition: raw.Partition,
+			Offset:    raw.Offset,
+			ack: func() {
+				sub.consumer.Commit(raw)
+			},
+		}
+
+		select {
+		case sub.msgs <- msg:
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+func (sub *kafkaSubscription) watch() {
+	defer close(sub.rebalances)
+
+	for ntf := range sub.consumer.Rebalances() {
+		select {
+		case sub.rebalances <- &Rebalance{
+			Type:     ntf.Type.String(),
+			Claimed:  ntf.Claimed,
+			Released: ntf.Released,
+			Current:  ntf.Current,
+		}:
+		default:
+		}
+	}
+}
+
+func (sub *kafkaSubscription) Messages() <-chan *Message {
+	return sub.msgs
+}
+
+func (sub *kafkaSubscription) Rebalances() <-chan *Rebalance {
+	return sub.rebalances
+}
+
+func (sub *kafkaSubscription) Close() error {
+	var err error
+	sub.once.Do(func() {
+		close(sub.done)
+		err = sub.consumer.Close()
+	})
+
+	return err
+}
@@ -0,0 +1,211 @@
+package msgqueue
+
+import (
+	"sync"
+)
+
+//DefaultMemoryBroker is shared by everything in the process that selects BrokerMemory
+var DefaultMemoryBroker = NewMemoryBroker()
+
+//MemoryBroker keeps every topic as one in-process log, each group has a committed offset like a kafka consumer group.
+//Nothing is ever removed, it is meant for tests and local runs
+type MemoryBroker struct {
+	topics map[string]*memoryTopic
+	closed bool
+	lock   sync.Mutex
+	cond   *sync.Cond
+}
+
+type memoryTopic struct {
+	messages []*Message
+	groups   map[string]*memoryGroup
+}
+
+//next is the delivery cursor, it falls back to committed when the last subscriber leaves
+type memoryGroup struct {
+	next        int64
+	committed   int64
+	subscribers int
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	mb := &MemoryBroker{
+		topics: make(map[string]*memoryTopic),
+	}
+	mb.cond = sync.NewCond(&mb.lock)
+
+	return mb
+}
+
+func (mb *MemoryBroker) topic(name string) *memoryTopic {
+	t, ok := mb.topics[name]
+	if !ok {
+		t = &memoryTopic{
+			groups: make(map[string]*memoryGroup),
+		}
+		mb.topics[name] = t
+	}
+
+	return t
+}
+
+func (mb *MemoryBroker) Publish(topic, key string, values ...[]byte) error {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	if mb.closed {
+		return ErrBrokerClosed
+	}
+
+	t := mb.topic(topic)
+	for _, value := range values {
+		t.messages = append(t.messages, &Message{
+			Topic:  topic,
+			Key:    key,
+			Value:  value,
+			Offset: int64(len(t.messages)),
+		})
+	}
+
+	mb.cond.Broadcast()
+
+	return nil
+}
+
+//Subscribe starts from the committed offset of group, a new group starts from the beginning of the topic
+func (mb *MemoryBroker) Subscribe(topic, group string) (Subscription, error) {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	if mb.closed {
+		return nil, ErrBrokerClosed
+	}
+
+	t := mb.topic(topic)
+	g, ok := t.groups[group]
+	if !ok {
+		g = &memoryGroup{}
+		t.groups[group] = g
+	}
+	g.subscribers++
+
+	sub := &memorySubscription{
+		broker: mb,
+		topic:  t,
+		group:  g,
+		msgs:   make(chan *Message),
+		done:   make(chan bool),
+	}
+
+	go sub.deliver()
+
+	return sub, nil
+}
+
+func (mb *MemoryBroker) Close() error {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	mb.closed = true
+	mb.cond.Broadcast()
+
+	return nil
+}
+
+//Pending is the number of messages of topic not yet acked by group
+func (mb *MemoryBroker) Pending(topic, group string) int {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	t := mb.topic(topic)
+	committed := int64(0)
+	if g, ok := t.groups[group]; ok {
+		committed = g.committed
+	}
+
+	return len(t.messages) - int(committed)
+}
+
+type memorySubscription struct {
+	broker *MemoryBroker
+	topic  *memoryTopic
+	group  *memoryGroup
+	msgs   chan *Message
+	done   chan bool
+	closed bool //guarded by broker.lock
+}
+
+func (sub *memorySubscription) next() *Message {
+	mb := sub.broker
+
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	for !sub.closed && !mb.closed && sub.group.next >= int64(len(sub.topic.messages)) {
+		mb.cond.Wait()
+	}
+
+	if sub.closed || mb.closed {
+		return nil
+	}
+
+	m := sub.topic.messages[sub.group.next]
+	sub.group.next++
+
+	group := sub.group
+	msg := *m
+	msg.ack = func() {
+		mb.lock.Lock()
+		defer mb.lock.Unlock()
+
+		if msg.Offset+1 > group.committed {
+			group.committed = msg.Offset + 1
+		}
+	}
+
+	return &msg
+}
+
+func (sub *memorySubscription) deliver() {
+	defer close(sub.msgs)
+
+	for {
+		msg := sub.next()
+		if msg == nil {
+			return
+		}
+
+		select {
+		case sub.msgs <- msg:
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+func (sub *memorySubscription) Messages() <-chan *Message {
+	return sub.msgs
+}
+
+func (sub *memorySubscription) Close() error {
+	mb := sub.broker
+
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	if sub.closed {
+		return nil
+	}
+
+	sub.closed = true
+	close(sub.done)
+
+	sub.group.subscribers--
+	if sub.group.subscribers == 0 {
+		sub.group.next = sub.group.committed
+	}
+
+	mb.cond.Broadcast()
+
+	return nil
+}
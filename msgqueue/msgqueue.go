@@ -2,8 +2,13 @@ package msgqueue
 
 import (
 	"github.com/harveywangdao/road/log/logger"
-	"github.com/harveywangdao/road/msgqueue/kafka"
 	"sync"
+	"time"
+)
+
+const (
+	PublishRetryMinInterval = 100 * time.Millisecond
+	PublishRetryMaxInterval = 10 * time.Second
 )
 
 type MqService struct {
@@ -14,6 +19,14 @@ type MqService struct {
 	ProducerRoutineNum int
 	RecvMqChan         chan []byte
 	SendMqChan         chan []byte
+	ConsumerName       string               //optional, the consumer group, consumers of one group share the messages
+	Broker             Broker               //optional, kafka at MqAddr by default
+	RebalanceHandler   func(*Rebalance)     //optional, called when the partitions of a consumer change
+	Handler            func(*Message) error //optional, replaces RecvMqChan, see handle
+	DeadLetterTopic    string               //optional, DeadLetterTopic(RecvTopic) by default
+	MaxAttempts        int                  //optional, HandleMaxAttempts by default
+
+	ownBroker bool
 }
 
 func NewMqService(mqAddr, recvTopic, sendTopic string, consumerRoutineNum, producerRoutineNum int, recvMqChan, sendMqChan chan []byte) (*MqService, error) {
@@ -31,10 +44,17 @@ func NewMqService(mqAddr, recvTopic, sendTopic string, consumerRoutineNum, produ
 }
 
 func (mqs *MqService) Close() {
-
+	if mqs.ownBroker {
+		mqs.Broker.Close()
+	}
 }
 
 func (mqs *MqService) Start() {
+	if mqs.Broker == nil {
+		mqs.Broker = NewKafkaBroker(mqs.MqAddr)
+		mqs.ownBroker = true
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go mqs.mqRecvRoutine()
@@ -44,43 +64,41 @@ func (mqs *MqService) Start() {
 
 func (mqs *MqService) consumerRoutine(faWg sync.WaitGroup) error {
 	defer faWg.Done()
-	addrs := []string{mqs.MqAddr}
 
-	consumer, err := kafka.NewConsumer(addrs, mqs.RecvTopic)
+	sub, err := mqs.Broker.Subscribe(mqs.RecvTopic, mqs.ConsumerName)
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
-	defer consumer.Close()
+	defer sub.Close()
 
-	partitionList, err := consumer.Partitions()
-	if err != nil {
-		logger.Error(err)
-		return err
+	if r, ok := sub.(Rebalancer); ok && mqs.RebalanceHandler != nil {
+		go func() {
+			for rebalance := range r.Rebalances() {
+				mqs.RebalanceHandler(rebalance)
+			}
+		}()
 	}
 
-	var wg sync.WaitGroup
-	for i := 0; i < len(partitionList); i++ {
-		partition := partitionList[i]
-		wg.Add(1)
+	logger.Debug("Waiting for consuming message.", "topic =", mqs.RecvTopic)
 
-		go func(part int32) {
-			defer wg.Done()
-			logger.Debug("Waiting for consuming message.", "partition =", part)
-			for {
-				data, err := consumer.Read(part)
-				if err != nil {
-					logger.Error(err)
-					//return
-				}
-
-				logger.Debug("Recv MQ data =", data)
-				mqs.RecvMqChan <- data
-			}
-		}(int32(partition))
-	}
+	for msg := range sub.Messages() {
+		logger.Debug("Recv MQ data =", msg.Value)
 
-	wg.Wait()
+		if mqs.Handler != nil {
+			mqs.handle(msg)
+			continue
+		}
+
+		if msg.Err != nil {
+			mqs.deadLetter(msg, msg.Err, 0)
+			msg.Ack()
+			continue
+		}
+
+		mqs.RecvMqChan <- msg.Value
+		msg.Ack()
+	}
 
 	return nil
 }
@@ -95,16 +113,9 @@ func (mqs *MqService) mqRecvRoutine() {
 	wg.Wait()
 }
 
+//producerRoutine keeps publishing a message until the broker takes it, SendMqChan fills up meanwhile
 func (mqs *MqService) producerRoutine(faWg sync.WaitGroup) {
 	defer faWg.Done()
-	addrs := []string{mqs.MqAddr}
-
-	p, err := kafka.NewProducer(addrs, mqs.SendTopic)
-	if err != nil {
-		logger.Error(err)
-		return
-	}
-	defer p.Close()
 
 	for {
 		select {
@@ -115,12 +126,23 @@ func (mqs *MqService) producerRoutine(faWg sync.WaitGroup) {
 			}
 
 			logger.Debug("Send MQ data =", data)
-			err = p.Send(-1, data)
-			if err != nil {
-				logger.Error(err)
-				return
-			}
+			mqs.publishTo(mqs.SendTopic, EnvelopeKey(data), data)
+		}
+	}
+}
+
+func (mqs *MqService) publishTo(topic, key string, data []byte) {
+	interval := PublishRetryMinInterval
+
+	for {
+		err := mqs.Broker.Publish(topic, key, data)
+		if err == nil {
+			return
 		}
+		logger.Error(err, "retry in", interval)
+
+		time.Sleep(interval)
+		interval = nextInterval(interval, PublishRetryMaxInterval)
 	}
 }
 
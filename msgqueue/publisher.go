@@ -0,0 +1,36 @@
+package msgqueue
+
+//MqPublisher sends keyed messages synchronously, used where the caller must know the data is enqueued
+type MqPublisher struct {
+	MqAddr string
+	Topic  string
+
+	broker    Broker
+	ownBroker bool
+}
+
+func NewMqPublisher(mqAddr, topic string) (*MqPublisher, error) {
+	mqp := NewBrokerPublisher(NewKafkaBroker(mqAddr), topic)
+	mqp.MqAddr = mqAddr
+	mqp.ownBroker = true
+
+	return mqp, nil
+}
+
+//NewBrokerPublisher publishes to a broker shared with others, Close leaves it open
+func NewBrokerPublisher(broker Broker, topic string) *MqPublisher {
+	return &MqPublisher{
+		Topic:  topic,
+		broker: broker,
+	}
+}
+
+func (mqp *MqPublisher) Publish(key string, datas ...[]byte) error {
+	return mqp.broker.Publish(mqp.Topic, key, datas...)
+}
+
+func (mqp *MqPublisher) Close() {
+	if mqp.ownBroker {
+		mqp.broker.Close()
+	}
+}
@@ -0,0 +1,2229 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: servdata.proto
+
+package servdata
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Register 0x1
+type RegisterReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Peraeskey  string `protobuf:"bytes,1,opt,name=peraeskey,proto3" json:"peraeskey,omitempty"`
+	Thingid    string `protobuf:"bytes,2,opt,name=thingid,proto3" json:"thingid,omitempty"`
+	Tboxsn     string `protobuf:"bytes,3,opt,name=tboxsn,proto3" json:"tboxsn,omitempty"`
+	Imsi       string `protobuf:"bytes,4,opt,name=imsi,proto3" json:"imsi,omitempty"`
+	Rollnumber string `protobuf:"bytes,5,opt,name=rollnumber,proto3" json:"rollnumber,omitempty"`
+	Iccid      string `protobuf:"bytes,6,opt,name=iccid,proto3" json:"iccid,omitempty"`
+}
+
+func (x *RegisterReq) Reset() {
+	*x = RegisterReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterReq) ProtoMessage() {}
+
+func (x *RegisterReq) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterReq.ProtoReflect.Descriptor instead.
+func (*RegisterReq) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterReq) GetPeraeskey() string {
+	if x != nil {
+		return x.Peraeskey
+	}
+	return ""
+}
+
+func (x *RegisterReq) GetThingid() string {
+	if x != nil {
+		return x.Thingid
+	}
+	return ""
+}
+
+func (x *RegisterReq) GetTboxsn() string {
+	if x != nil {
+		return x.Tboxsn
+	}
+	return ""
+}
+
+func (x *RegisterReq) GetImsi() string {
+	if x != nil {
+		return x.Imsi
+	}
+	return ""
+}
+
+func (x *RegisterReq) GetRollnumber() string {
+	if x != nil {
+		return x.Rollnumber
+	}
+	return ""
+}
+
+func (x *RegisterReq) GetIccid() string {
+	if x != nil {
+		return x.Iccid
+	}
+	return ""
+}
+
+type RegisterAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status         uint32 `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Callbacknumber string `protobuf:"bytes,2,opt,name=callbacknumber,proto3" json:"callbacknumber,omitempty"`
+	Bid            uint32 `protobuf:"varint,3,opt,name=bid,proto3" json:"bid,omitempty"`
+}
+
+func (x *RegisterAck) Reset() {
+	*x = RegisterAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterAck) ProtoMessage() {}
+
+func (x *RegisterAck) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterAck.ProtoReflect.Descriptor instead.
+func (*RegisterAck) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegisterAck) GetStatus() uint32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *RegisterAck) GetCallbacknumber() string {
+	if x != nil {
+		return x.Callbacknumber
+	}
+	return ""
+}
+
+func (x *RegisterAck) GetBid() uint32 {
+	if x != nil {
+		return x.Bid
+	}
+	return 0
+}
+
+// Login 0x2
+type LoginRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Keytype     uint32 `protobuf:"varint,1,opt,name=keytype,proto3" json:"keytype,omitempty"`
+	Thingsn     string `protobuf:"bytes,2,opt,name=thingsn,proto3" json:"thingsn,omitempty"`
+	Thingid     string `protobuf:"bytes,3,opt,name=thingid,proto3" json:"thingid,omitempty"`
+	Thingrandom string `protobuf:"bytes,4,opt,name=thingrandom,proto3" json:"thingrandom,omitempty"`
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LoginRequest) GetKeytype() uint32 {
+	if x != nil {
+		return x.Keytype
+	}
+	return 0
+}
+
+func (x *LoginRequest) GetThingsn() string {
+	if x != nil {
+		return x.Thingsn
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetThingid() string {
+	if x != nil {
+		return x.Thingid
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetThingrandom() string {
+	if x != nil {
+		return x.Thingrandom
+	}
+	return ""
+}
+
+type LoginChallenge struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Thingrandommd5 string `protobuf:"bytes,1,opt,name=thingrandommd5,proto3" json:"thingrandommd5,omitempty"`
+	Platrandom     string `protobuf:"bytes,2,opt,name=platrandom,proto3" json:"platrandom,omitempty"`
+}
+
+func (x *LoginChallenge) Reset() {
+	*x = LoginChallenge{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoginChallenge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginChallenge) ProtoMessage() {}
+
+func (x *LoginChallenge) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginChallenge.ProtoReflect.Descriptor instead.
+func (*LoginChallenge) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LoginChallenge) GetThingrandommd5() string {
+	if x != nil {
+		return x.Thingrandommd5
+	}
+	return ""
+}
+
+func (x *LoginChallenge) GetPlatrandom() string {
+	if x != nil {
+		return x.Platrandom
+	}
+	return ""
+}
+
+type LoginResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Serialup  string `protobuf:"bytes,1,opt,name=serialup,proto3" json:"serialup,omitempty"`
+	Accesskey string `protobuf:"bytes,2,opt,name=accesskey,proto3" json:"accesskey,omitempty"`
+}
+
+func (x *LoginResponse) Reset() {
+	*x = LoginResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginResponse) ProtoMessage() {}
+
+func (x *LoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LoginResponse) GetSerialup() string {
+	if x != nil {
+		return x.Serialup
+	}
+	return ""
+}
+
+func (x *LoginResponse) GetAccesskey() string {
+	if x != nil {
+		return x.Accesskey
+	}
+	return ""
+}
+
+type LoginFailure struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Failurecode uint32 `protobuf:"varint,1,opt,name=failurecode,proto3" json:"failurecode,omitempty"`
+}
+
+func (x *LoginFailure) Reset() {
+	*x = LoginFailure{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoginFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginFailure) ProtoMessage() {}
+
+func (x *LoginFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginFailure.ProtoReflect.Descriptor instead.
+func (*LoginFailure) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LoginFailure) GetFailurecode() uint32 {
+	if x != nil {
+		return x.Failurecode
+	}
+	return 0
+}
+
+type LoginSuccess struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Aesrandom     string `protobuf:"bytes,1,opt,name=aesrandom,proto3" json:"aesrandom,omitempty"`
+	Initserial    uint32 `protobuf:"varint,2,opt,name=initserial,proto3" json:"initserial,omitempty"`
+	Timestamp     int64  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Workwindow    int64  `protobuf:"varint,4,opt,name=workwindow,proto3" json:"workwindow,omitempty"`
+	Linkheartbeat int64  `protobuf:"varint,5,opt,name=linkheartbeat,proto3" json:"linkheartbeat,omitempty"`
+}
+
+func (x *LoginSuccess) Reset() {
+	*x = LoginSuccess{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoginSuccess) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginSuccess) ProtoMessage() {}
+
+func (x *LoginSuccess) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginSuccess.ProtoReflect.Descriptor instead.
+func (*LoginSuccess) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LoginSuccess) GetAesrandom() string {
+	if x != nil {
+		return x.Aesrandom
+	}
+	return ""
+}
+
+func (x *LoginSuccess) GetInitserial() uint32 {
+	if x != nil {
+		return x.Initserial
+	}
+	return 0
+}
+
+func (x *LoginSuccess) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *LoginSuccess) GetWorkwindow() int64 {
+	if x != nil {
+		return x.Workwindow
+	}
+	return 0
+}
+
+func (x *LoginSuccess) GetLinkheartbeat() int64 {
+	if x != nil {
+		return x.Linkheartbeat
+	}
+	return 0
+}
+
+// ReLogin 0x4
+type ReLoginReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Newtime uint32 `protobuf:"varint,1,opt,name=newtime,proto3" json:"newtime,omitempty"`
+}
+
+func (x *ReLoginReq) Reset() {
+	*x = ReLoginReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReLoginReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReLoginReq) ProtoMessage() {}
+
+func (x *ReLoginReq) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReLoginReq.ProtoReflect.Descriptor instead.
+func (*ReLoginReq) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ReLoginReq) GetNewtime() uint32 {
+	if x != nil {
+		return x.Newtime
+	}
+	return 0
+}
+
+// ReadConfig 0x5
+type ReadConfigReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Indexlist []string `protobuf:"bytes,1,rep,name=indexlist,proto3" json:"indexlist,omitempty"`
+}
+
+func (x *ReadConfigReq) Reset() {
+	*x = ReadConfigReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReadConfigReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadConfigReq) ProtoMessage() {}
+
+func (x *ReadConfigReq) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadConfigReq.ProtoReflect.Descriptor instead.
+func (*ReadConfigReq) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReadConfigReq) GetIndexlist() []string {
+	if x != nil {
+		return x.Indexlist
+	}
+	return nil
+}
+
+type ReadConfigAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Workconfiglist []string `protobuf:"bytes,1,rep,name=workconfiglist,proto3" json:"workconfiglist,omitempty"`
+}
+
+func (x *ReadConfigAck) Reset() {
+	*x = ReadConfigAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReadConfigAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadConfigAck) ProtoMessage() {}
+
+func (x *ReadConfigAck) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadConfigAck.ProtoReflect.Descriptor instead.
+func (*ReadConfigAck) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ReadConfigAck) GetWorkconfiglist() []string {
+	if x != nil {
+		return x.Workconfiglist
+	}
+	return nil
+}
+
+// SetConfig 0x7
+type SetConfigReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Indexlist      []string `protobuf:"bytes,1,rep,name=indexlist,proto3" json:"indexlist,omitempty"`
+	Workconfiglist []string `protobuf:"bytes,2,rep,name=workconfiglist,proto3" json:"workconfiglist,omitempty"`
+}
+
+func (x *SetConfigReq) Reset() {
+	*x = SetConfigReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetConfigReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConfigReq) ProtoMessage() {}
+
+func (x *SetConfigReq) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConfigReq.ProtoReflect.Descriptor instead.
+func (*SetConfigReq) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SetConfigReq) GetIndexlist() []string {
+	if x != nil {
+		return x.Indexlist
+	}
+	return nil
+}
+
+func (x *SetConfigReq) GetWorkconfiglist() []string {
+	if x != nil {
+		return x.Workconfiglist
+	}
+	return nil
+}
+
+type SetConfigAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Indexlist      []string `protobuf:"bytes,1,rep,name=indexlist,proto3" json:"indexlist,omitempty"`
+	Workconfiglist []string `protobuf:"bytes,2,rep,name=workconfiglist,proto3" json:"workconfiglist,omitempty"`
+}
+
+func (x *SetConfigAck) Reset() {
+	*x = SetConfigAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SetConfigAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConfigAck) ProtoMessage() {}
+
+func (x *SetConfigAck) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConfigAck.ProtoReflect.Descriptor instead.
+func (*SetConfigAck) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SetConfigAck) GetIndexlist() []string {
+	if x != nil {
+		return x.Indexlist
+	}
+	return nil
+}
+
+func (x *SetConfigAck) GetWorkconfiglist() []string {
+	if x != nil {
+		return x.Workconfiglist
+	}
+	return nil
+}
+
+// Heartbeat 0xB
+type HeartbeatReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Appointmf  uint32 `protobuf:"varint,1,opt,name=appointmf,proto3" json:"appointmf,omitempty"`
+	Appointaid uint32 `protobuf:"varint,2,opt,name=appointaid,proto3" json:"appointaid,omitempty"`
+}
+
+func (x *HeartbeatReq) Reset() {
+	*x = HeartbeatReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeartbeatReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatReq) ProtoMessage() {}
+
+func (x *HeartbeatReq) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatReq.ProtoReflect.Descriptor instead.
+func (*HeartbeatReq) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *HeartbeatReq) GetAppointmf() uint32 {
+	if x != nil {
+		return x.Appointmf
+	}
+	return 0
+}
+
+func (x *HeartbeatReq) GetAppointaid() uint32 {
+	if x != nil {
+		return x.Appointaid
+	}
+	return 0
+}
+
+// ThingControl 0xF1
+type RemoteOperationReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Operation          uint32 `protobuf:"varint,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	Operationparameter int64  `protobuf:"varint,2,opt,name=operationparameter,proto3" json:"operationparameter,omitempty"`
+}
+
+func (x *RemoteOperationReq) Reset() {
+	*x = RemoteOperationReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoteOperationReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteOperationReq) ProtoMessage() {}
+
+func (x *RemoteOperationReq) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteOperationReq.ProtoReflect.Descriptor instead.
+func (*RemoteOperationReq) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RemoteOperationReq) GetOperation() uint32 {
+	if x != nil {
+		return x.Operation
+	}
+	return 0
+}
+
+func (x *RemoteOperationReq) GetOperationparameter() int64 {
+	if x != nil {
+		return x.Operationparameter
+	}
+	return 0
+}
+
+type DispatcherAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Operation uint32 `protobuf:"varint,1,opt,name=operation,proto3" json:"operation,omitempty"`
+}
+
+func (x *DispatcherAck) Reset() {
+	*x = DispatcherAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DispatcherAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DispatcherAck) ProtoMessage() {}
+
+func (x *DispatcherAck) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DispatcherAck.ProtoReflect.Descriptor instead.
+func (*DispatcherAck) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DispatcherAck) GetOperation() uint32 {
+	if x != nil {
+		return x.Operation
+	}
+	return 0
+}
+
+type RemoteOperationEnd struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Operation uint32 `protobuf:"varint,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	Parameter int64  `protobuf:"varint,2,opt,name=parameter,proto3" json:"parameter,omitempty"`
+}
+
+func (x *RemoteOperationEnd) Reset() {
+	*x = RemoteOperationEnd{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoteOperationEnd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteOperationEnd) ProtoMessage() {}
+
+func (x *RemoteOperationEnd) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteOperationEnd.ProtoReflect.Descriptor instead.
+func (*RemoteOperationEnd) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RemoteOperationEnd) GetOperation() uint32 {
+	if x != nil {
+		return x.Operation
+	}
+	return 0
+}
+
+func (x *RemoteOperationEnd) GetParameter() int64 {
+	if x != nil {
+		return x.Parameter
+	}
+	return 0
+}
+
+type RemoteOperationAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Operation uint32 `protobuf:"varint,1,opt,name=operation,proto3" json:"operation,omitempty"`
+	Status    uint32 `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	Parameter int64  `protobuf:"varint,3,opt,name=parameter,proto3" json:"parameter,omitempty"`
+}
+
+func (x *RemoteOperationAck) Reset() {
+	*x = RemoteOperationAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemoteOperationAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoteOperationAck) ProtoMessage() {}
+
+func (x *RemoteOperationAck) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoteOperationAck.ProtoReflect.Descriptor instead.
+func (*RemoteOperationAck) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RemoteOperationAck) GetOperation() uint32 {
+	if x != nil {
+		return x.Operation
+	}
+	return 0
+}
+
+func (x *RemoteOperationAck) GetStatus() uint32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *RemoteOperationAck) GetParameter() int64 {
+	if x != nil {
+		return x.Parameter
+	}
+	return 0
+}
+
+// ThingInfoUpload 0xF5
+type ThingInfor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version    uint32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Time       uint32 `protobuf:"varint,2,opt,name=time,proto3" json:"time,omitempty"`
+	Islocation uint32 `protobuf:"varint,3,opt,name=islocation,proto3" json:"islocation,omitempty"`
+	Latitude   uint32 `protobuf:"varint,4,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude  uint32 `protobuf:"varint,5,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Heading    uint32 `protobuf:"varint,6,opt,name=heading,proto3" json:"heading,omitempty"`
+	Speed      uint32 `protobuf:"varint,7,opt,name=speed,proto3" json:"speed,omitempty"`
+	// Since telemetry version 1, see protocol/thinginfo.go for units and scaling
+	Telemetryversion uint32       `protobuf:"varint,8,opt,name=telemetryversion,proto3" json:"telemetryversion,omitempty"`
+	Energy           *EnergyInfor `protobuf:"bytes,9,opt,name=energy,proto3" json:"energy,omitempty"`
+	Body             *BodyInfor   `protobuf:"bytes,10,opt,name=body,proto3" json:"body,omitempty"`
+	Tyres            []*TyreInfor `protobuf:"bytes,11,rep,name=tyres,proto3" json:"tyres,omitempty"`
+	Engine           *EngineInfor `protobuf:"bytes,12,opt,name=engine,proto3" json:"engine,omitempty"`
+	Gnss             *GnssInfor   `protobuf:"bytes,13,opt,name=gnss,proto3" json:"gnss,omitempty"`
+	Dtcs             []string     `protobuf:"bytes,14,rep,name=dtcs,proto3" json:"dtcs,omitempty"`
+}
+
+func (x *ThingInfor) Reset() {
+	*x = ThingInfor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ThingInfor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThingInfor) ProtoMessage() {}
+
+func (x *ThingInfor) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThingInfor.ProtoReflect.Descriptor instead.
+func (*ThingInfor) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ThingInfor) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetTime() uint32 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetIslocation() uint32 {
+	if x != nil {
+		return x.Islocation
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetLatitude() uint32 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetLongitude() uint32 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetHeading() uint32 {
+	if x != nil {
+		return x.Heading
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetSpeed() uint32 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetTelemetryversion() uint32 {
+	if x != nil {
+		return x.Telemetryversion
+	}
+	return 0
+}
+
+func (x *ThingInfor) GetEnergy() *EnergyInfor {
+	if x != nil {
+		return x.Energy
+	}
+	return nil
+}
+
+func (x *ThingInfor) GetBody() *BodyInfor {
+	if x != nil {
+		return x.Body
+	}
+	return nil
+}
+
+func (x *ThingInfor) GetTyres() []*TyreInfor {
+	if x != nil {
+		return x.Tyres
+	}
+	return nil
+}
+
+func (x *ThingInfor) GetEngine() *EngineInfor {
+	if x != nil {
+		return x.Engine
+	}
+	return nil
+}
+
+func (x *ThingInfor) GetGnss() *GnssInfor {
+	if x != nil {
+		return x.Gnss
+	}
+	return nil
+}
+
+func (x *ThingInfor) GetDtcs() []string {
+	if x != nil {
+		return x.Dtcs
+	}
+	return nil
+}
+
+type ThingInforBatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Samples []*ThingInfor `protobuf:"bytes,1,rep,name=samples,proto3" json:"samples,omitempty"`
+}
+
+func (x *ThingInforBatch) Reset() {
+	*x = ThingInforBatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ThingInforBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThingInforBatch) ProtoMessage() {}
+
+func (x *ThingInforBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThingInforBatch.ProtoReflect.Descriptor instead.
+func (*ThingInforBatch) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ThingInforBatch) GetSamples() []*ThingInfor {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+type ThingInfoBatchAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Lasttime uint32 `protobuf:"varint,1,opt,name=lasttime,proto3" json:"lasttime,omitempty"`
+	Count    uint32 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+}
+
+func (x *ThingInfoBatchAck) Reset() {
+	*x = ThingInfoBatchAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ThingInfoBatchAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThingInfoBatchAck) ProtoMessage() {}
+
+func (x *ThingInfoBatchAck) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThingInfoBatchAck.ProtoReflect.Descriptor instead.
+func (*ThingInfoBatchAck) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ThingInfoBatchAck) GetLasttime() uint32 {
+	if x != nil {
+		return x.Lasttime
+	}
+	return 0
+}
+
+func (x *ThingInfoBatchAck) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type EnergyInfor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fuellevel      uint32 `protobuf:"varint,1,opt,name=fuellevel,proto3" json:"fuellevel,omitempty"`
+	Batterysoc     uint32 `protobuf:"varint,2,opt,name=batterysoc,proto3" json:"batterysoc,omitempty"`
+	Odometer       uint32 `protobuf:"varint,3,opt,name=odometer,proto3" json:"odometer,omitempty"`
+	Batteryvoltage uint32 `protobuf:"varint,4,opt,name=batteryvoltage,proto3" json:"batteryvoltage,omitempty"`
+}
+
+func (x *EnergyInfor) Reset() {
+	*x = EnergyInfor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EnergyInfor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnergyInfor) ProtoMessage() {}
+
+func (x *EnergyInfor) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnergyInfor.ProtoReflect.Descriptor instead.
+func (*EnergyInfor) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *EnergyInfor) GetFuellevel() uint32 {
+	if x != nil {
+		return x.Fuellevel
+	}
+	return 0
+}
+
+func (x *EnergyInfor) GetBatterysoc() uint32 {
+	if x != nil {
+		return x.Batterysoc
+	}
+	return 0
+}
+
+func (x *EnergyInfor) GetOdometer() uint32 {
+	if x != nil {
+		return x.Odometer
+	}
+	return 0
+}
+
+func (x *EnergyInfor) GetBatteryvoltage() uint32 {
+	if x != nil {
+		return x.Batteryvoltage
+	}
+	return 0
+}
+
+type BodyInfor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Doors   uint32 `protobuf:"varint,1,opt,name=doors,proto3" json:"doors,omitempty"`
+	Windows uint32 `protobuf:"varint,2,opt,name=windows,proto3" json:"windows,omitempty"`
+	Locks   uint32 `protobuf:"varint,3,opt,name=locks,proto3" json:"locks,omitempty"`
+}
+
+func (x *BodyInfor) Reset() {
+	*x = BodyInfor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BodyInfor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BodyInfor) ProtoMessage() {}
+
+func (x *BodyInfor) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BodyInfor.ProtoReflect.Descriptor instead.
+func (*BodyInfor) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *BodyInfor) GetDoors() uint32 {
+	if x != nil {
+		return x.Doors
+	}
+	return 0
+}
+
+func (x *BodyInfor) GetWindows() uint32 {
+	if x != nil {
+		return x.Windows
+	}
+	return 0
+}
+
+func (x *BodyInfor) GetLocks() uint32 {
+	if x != nil {
+		return x.Locks
+	}
+	return 0
+}
+
+type TyreInfor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Position    uint32 `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	Pressure    uint32 `protobuf:"varint,2,opt,name=pressure,proto3" json:"pressure,omitempty"`
+	Temperature int32  `protobuf:"zigzag32,3,opt,name=temperature,proto3" json:"temperature,omitempty"`
+}
+
+func (x *TyreInfor) Reset() {
+	*x = TyreInfor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TyreInfor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TyreInfor) ProtoMessage() {}
+
+func (x *TyreInfor) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TyreInfor.ProtoReflect.Descriptor instead.
+func (*TyreInfor) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *TyreInfor) GetPosition() uint32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *TyreInfor) GetPressure() uint32 {
+	if x != nil {
+		return x.Pressure
+	}
+	return 0
+}
+
+func (x *TyreInfor) GetTemperature() int32 {
+	if x != nil {
+		return x.Temperature
+	}
+	return 0
+}
+
+type EngineInfor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status             uint32 `protobuf:"varint,1,opt,name=status,proto3" json:"status,omitempty"`
+	Rpm                uint32 `protobuf:"varint,2,opt,name=rpm,proto3" json:"rpm,omitempty"`
+	Coolanttemperature int32  `protobuf:"zigzag32,3,opt,name=coolanttemperature,proto3" json:"coolanttemperature,omitempty"`
+}
+
+func (x *EngineInfor) Reset() {
+	*x = EngineInfor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EngineInfor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EngineInfor) ProtoMessage() {}
+
+func (x *EngineInfor) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EngineInfor.ProtoReflect.Descriptor instead.
+func (*EngineInfor) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *EngineInfor) GetStatus() uint32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *EngineInfor) GetRpm() uint32 {
+	if x != nil {
+		return x.Rpm
+	}
+	return 0
+}
+
+func (x *EngineInfor) GetCoolanttemperature() int32 {
+	if x != nil {
+		return x.Coolanttemperature
+	}
+	return 0
+}
+
+type GnssInfor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Fix        uint32 `protobuf:"varint,1,opt,name=fix,proto3" json:"fix,omitempty"`
+	Satellites uint32 `protobuf:"varint,2,opt,name=satellites,proto3" json:"satellites,omitempty"`
+	Hdop       uint32 `protobuf:"varint,3,opt,name=hdop,proto3" json:"hdop,omitempty"`
+	Altitude   int32  `protobuf:"zigzag32,4,opt,name=altitude,proto3" json:"altitude,omitempty"`
+}
+
+func (x *GnssInfor) Reset() {
+	*x = GnssInfor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GnssInfor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GnssInfor) ProtoMessage() {}
+
+func (x *GnssInfor) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GnssInfor.ProtoReflect.Descriptor instead.
+func (*GnssInfor) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GnssInfor) GetFix() uint32 {
+	if x != nil {
+		return x.Fix
+	}
+	return 0
+}
+
+func (x *GnssInfor) GetSatellites() uint32 {
+	if x != nil {
+		return x.Satellites
+	}
+	return 0
+}
+
+func (x *GnssInfor) GetHdop() uint32 {
+	if x != nil {
+		return x.Hdop
+	}
+	return 0
+}
+
+func (x *GnssInfor) GetAltitude() int32 {
+	if x != nil {
+		return x.Altitude
+	}
+	return 0
+}
+
+// ErrorReply 0xFE
+type ErrorReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Aid uint32 `protobuf:"varint,1,opt,name=aid,proto3" json:"aid,omitempty"`
+	Mid uint32 `protobuf:"varint,2,opt,name=mid,proto3" json:"mid,omitempty"`
+}
+
+func (x *ErrorReply) Reset() {
+	*x = ErrorReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_servdata_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrorReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorReply) ProtoMessage() {}
+
+func (x *ErrorReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servdata_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorReply.ProtoReflect.Descriptor instead.
+func (*ErrorReply) Descriptor() ([]byte, []int) {
+	return file_servdata_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ErrorReply) GetAid() uint32 {
+	if x != nil {
+		return x.Aid
+	}
+	return 0
+}
+
+func (x *ErrorReply) GetMid() uint32 {
+	if x != nil {
+		return x.Mid
+	}
+	return 0
+}
+
+var File_servdata_proto protoreflect.FileDescriptor
+
+var file_servdata_proto_rawDesc = []byte{
+	0x0a, 0x0e, 0x73, 0x65, 0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x08, 0x73, 0x65, 0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x22, 0xa7, 0x01, 0x0a, 0x0b, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x65,
+	0x72, 0x61, 0x65, 0x73, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70,
+	0x65, 0x72, 0x61, 0x65, 0x73, 0x6b, 0x65, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x68, 0x69, 0x6e,
+	0x67, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x68, 0x69, 0x6e, 0x67,
+	0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x62, 0x6f, 0x78, 0x73, 0x6e, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x74, 0x62, 0x6f, 0x78, 0x73, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x6d,
+	0x73, 0x69, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x69, 0x6d, 0x73, 0x69, 0x12, 0x1e,
+	0x0a, 0x0a, 0x72, 0x6f, 0x6c, 0x6c, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x72, 0x6f, 0x6c, 0x6c, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14,
+	0x0a, 0x05, 0x69, 0x63, 0x63, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x69,
+	0x63, 0x63, 0x69, 0x64, 0x22, 0x5f, 0x0a, 0x0b, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
+	0x41, 0x63, 0x6b, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x26, 0x0a, 0x0e, 0x63,
+	0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x6e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x62, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x03, 0x62, 0x69, 0x64, 0x22, 0x7e, 0x0a, 0x0c, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6b, 0x65, 0x79, 0x74, 0x79, 0x70, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x74, 0x79, 0x70, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x73, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x73, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x68, 0x69,
+	0x6e, 0x67, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x68, 0x69, 0x6e,
+	0x67, 0x69, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x72, 0x61, 0x6e, 0x64,
+	0x6f, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x72,
+	0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x22, 0x58, 0x0a, 0x0e, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x43, 0x68,
+	0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x74, 0x68, 0x69, 0x6e, 0x67,
+	0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x6d, 0x64, 0x35, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0e, 0x74, 0x68, 0x69, 0x6e, 0x67, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x6d, 0x64, 0x35, 0x12,
+	0x1e, 0x0a, 0x0a, 0x70, 0x6c, 0x61, 0x74, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x6c, 0x61, 0x74, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x22,
+	0x49, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x75, 0x70, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x75, 0x70, 0x12, 0x1c, 0x0a, 0x09,
+	0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x6b, 0x65, 0x79, 0x22, 0x30, 0x0a, 0x0c, 0x4c, 0x6f,
+	0x67, 0x69, 0x6e, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x66, 0x61,
+	0x69, 0x6c, 0x75, 0x72, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0b, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x63, 0x6f, 0x64, 0x65, 0x22, 0xb0, 0x01, 0x0a,
+	0x0c, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x53, 0x75, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x1c, 0x0a,
+	0x09, 0x61, 0x65, 0x73, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x61, 0x65, 0x73, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x69,
+	0x6e, 0x69, 0x74, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0a, 0x69, 0x6e, 0x69, 0x74, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x77, 0x6f, 0x72,
+	0x6b, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x77,
+	0x6f, 0x72, 0x6b, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x24, 0x0a, 0x0d, 0x6c, 0x69, 0x6e,
+	0x6b, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0d, 0x6c, 0x69, 0x6e, 0x6b, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x22,
+	0x26, 0x0a, 0x0a, 0x52, 0x65, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x12, 0x18, 0x0a,
+	0x07, 0x6e, 0x65, 0x77, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07,
+	0x6e, 0x65, 0x77, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x2d, 0x0a, 0x0d, 0x52, 0x65, 0x61, 0x64, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x71, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x37, 0x0a, 0x0d, 0x52, 0x65, 0x61, 0x64, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x41, 0x63, 0x6b, 0x12, 0x26, 0x0a, 0x0e, 0x77, 0x6f, 0x72, 0x6b, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0e, 0x77, 0x6f, 0x72, 0x6b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x6c, 0x69, 0x73, 0x74, 0x22,
+	0x54, 0x0a, 0x0c, 0x53, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x71, 0x12,
+	0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x26, 0x0a,
+	0x0e, 0x77, 0x6f, 0x72, 0x6b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x6c, 0x69, 0x73, 0x74, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x77, 0x6f, 0x72, 0x6b, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x54, 0x0a, 0x0c, 0x53, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x41, 0x63, 0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x6c, 0x69,
+	0x73, 0x74, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x6c,
+	0x69, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x0e, 0x77, 0x6f, 0x72, 0x6b, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x6c, 0x69, 0x73, 0x74, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x77, 0x6f, 0x72,
+	0x6b, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x6c, 0x69, 0x73, 0x74, 0x22, 0x4c, 0x0a, 0x0c, 0x48,
+	0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x12, 0x1c, 0x0a, 0x09, 0x61,
+	0x70, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x6d, 0x66, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09,
+	0x61, 0x70, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x6d, 0x66, 0x12, 0x1e, 0x0a, 0x0a, 0x61, 0x70, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x61,
+	0x70, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x64, 0x22, 0x62, 0x0a, 0x12, 0x52, 0x65, 0x6d,
+	0x6f, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x12,
+	0x1c, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2e, 0x0a,
+	0x12, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65,
+	0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x12, 0x6f, 0x70, 0x65, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x22, 0x2d, 0x0a,
+	0x0d, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x65, 0x72, 0x41, 0x63, 0x6b, 0x12, 0x1c,
+	0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x50, 0x0a, 0x12,
+	0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45,
+	0x6e, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x1c, 0x0a, 0x09, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x22, 0x68,
+	0x0a, 0x12, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x41, 0x63, 0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x70, 0x61,
+	0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x70,
+	0x61, 0x72, 0x61, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x22, 0xdf, 0x03, 0x0a, 0x0a, 0x54, 0x68, 0x69,
+	0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x73, 0x6c, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x69, 0x73, 0x6c, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64,
+	0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x70, 0x65,
+	0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x73, 0x70, 0x65, 0x65, 0x64, 0x12,
+	0x2a, 0x0a, 0x10, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x74, 0x65, 0x6c, 0x65, 0x6d,
+	0x65, 0x74, 0x72, 0x79, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x2d, 0x0a, 0x06, 0x65,
+	0x6e, 0x65, 0x72, 0x67, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x65,
+	0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x49, 0x6e, 0x66,
+	0x6f, 0x72, 0x52, 0x06, 0x65, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x12, 0x27, 0x0a, 0x04, 0x62, 0x6f,
+	0x64, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x64,
+	0x61, 0x74, 0x61, 0x2e, 0x42, 0x6f, 0x64, 0x79, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x52, 0x04, 0x62,
+	0x6f, 0x64, 0x79, 0x12, 0x29, 0x0a, 0x05, 0x74, 0x79, 0x72, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x54, 0x79,
+	0x72, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x52, 0x05, 0x74, 0x79, 0x72, 0x65, 0x73, 0x12, 0x2d,
+	0x0a, 0x06, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x73, 0x65, 0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65,
+	0x49, 0x6e, 0x66, 0x6f, 0x72, 0x52, 0x06, 0x65, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x12, 0x27, 0x0a,
+	0x04, 0x67, 0x6e, 0x73, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x65,
+	0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x47, 0x6e, 0x73, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x72,
+	0x52, 0x04, 0x67, 0x6e, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x74, 0x63, 0x73, 0x18, 0x0e,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x64, 0x74, 0x63, 0x73, 0x22, 0x41, 0x0a, 0x0f, 0x54, 0x68,
+	0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x2e, 0x0a,
+	0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x73, 0x65, 0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x49,
+	0x6e, 0x66, 0x6f, 0x72, 0x52, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x22, 0x45, 0x0a,
+	0x11, 0x54, 0x68, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x42, 0x61, 0x74, 0x63, 0x68, 0x41,
+	0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x22, 0x8f, 0x01, 0x0a, 0x0b, 0x45, 0x6e, 0x65, 0x72, 0x67, 0x79, 0x49,
+	0x6e, 0x66, 0x6f, 0x72, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x75, 0x65, 0x6c, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x66, 0x75, 0x65, 0x6c, 0x6c, 0x65, 0x76,
+	0x65, 0x6c, 0x12, 0x1e, 0x0a, 0x0a, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x73, 0x6f, 0x63,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x73,
+	0x6f, 0x63, 0x12, 0x1a, 0x0a, 0x08, 0x6f, 0x64, 0x6f, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6f, 0x64, 0x6f, 0x6d, 0x65, 0x74, 0x65, 0x72, 0x12, 0x26,
+	0x0a, 0x0e, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x76, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x76,
+	0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x22, 0x51, 0x0a, 0x09, 0x42, 0x6f, 0x64, 0x79, 0x49, 0x6e,
+	0x66, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x6f, 0x6f, 0x72, 0x73, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x64, 0x6f, 0x6f, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x69, 0x6e,
+	0x64, 0x6f, 0x77, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x77, 0x69, 0x6e, 0x64,
+	0x6f, 0x77, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x05, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x65, 0x0a, 0x09, 0x54, 0x79, 0x72,
+	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x20,
+	0x0a, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x11, 0x52, 0x0b, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x22, 0x67, 0x0a, 0x0b, 0x45, 0x6e, 0x67, 0x69, 0x6e, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x12,
+	0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x70, 0x6d, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x72, 0x70, 0x6d, 0x12, 0x2e, 0x0a, 0x12, 0x63, 0x6f, 0x6f,
+	0x6c, 0x61, 0x6e, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x11, 0x52, 0x12, 0x63, 0x6f, 0x6f, 0x6c, 0x61, 0x6e, 0x74, 0x74, 0x65,
+	0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x22, 0x6d, 0x0a, 0x09, 0x47, 0x6e, 0x73,
+	0x73, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x03, 0x66, 0x69, 0x78, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x61, 0x74, 0x65,
+	0x6c, 0x6c, 0x69, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x73, 0x61,
+	0x74, 0x65, 0x6c, 0x6c, 0x69, 0x74, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x64, 0x6f, 0x70,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x68, 0x64, 0x6f, 0x70, 0x12, 0x1a, 0x0a, 0x08,
+	0x61, 0x6c, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x11, 0x52, 0x08,
+	0x61, 0x6c, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x22, 0x30, 0x0a, 0x0a, 0x45, 0x72, 0x72, 0x6f,
+	0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x03, 0x61, 0x69, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x69, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x6d, 0x69, 0x64, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x68, 0x61, 0x72, 0x76, 0x65, 0x79, 0x77,
+	0x61, 0x6e, 0x67, 0x64, 0x61, 0x6f, 0x2f, 0x72, 0x6f, 0x61, 0x64, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73, 0x65, 0x72, 0x76, 0x64, 0x61, 0x74, 0x61, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_servdata_proto_rawDescOnce sync.Once
+	file_servdata_proto_rawDescData = file_servdata_proto_rawDesc
+)
+
+func file_servdata_proto_rawDescGZIP() []byte {
+	file_servdata_proto_rawDescOnce.Do(func() {
+		file_servdata_proto_rawDescData = protoimpl.X.CompressGZIP(file_servdata_proto_rawDescData)
+	})
+	return file_servdata_proto_rawDescData
+}
+
+var file_servdata_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_servdata_proto_goTypes = []any{
+	(*RegisterReq)(nil),        // 0: servdata.RegisterReq
+	(*RegisterAck)(nil),        // 1: servdata.RegisterAck
+	(*LoginRequest)(nil),       // 2: servdata.LoginRequest
+	(*LoginChallenge)(nil),     // 3: servdata.LoginChallenge
+	(*LoginResponse)(nil),      // 4: servdata.LoginResponse
+	(*LoginFailure)(nil),       // 5: servdata.LoginFailure
+	(*LoginSuccess)(nil),       // 6: servdata.LoginSuccess
+	(*ReLoginReq)(nil),         // 7: servdata.ReLoginReq
+	(*ReadConfigReq)(nil),      // 8: servdata.ReadConfigReq
+	(*ReadConfigAck)(nil),      // 9: servdata.ReadConfigAck
+	(*SetConfigReq)(nil),       // 10: servdata.SetConfigReq
+	(*SetConfigAck)(nil),       // 11: servdata.SetConfigAck
+	(*HeartbeatReq)(nil),       // 12: servdata.HeartbeatReq
+	(*RemoteOperationReq)(nil), // 13: servdata.RemoteOperationReq
+	(*DispatcherAck)(nil),      // 14: servdata.DispatcherAck
+	(*RemoteOperationEnd)(nil), // 15: servdata.RemoteOperationEnd
+	(*RemoteOperationAck)(nil), // 16: servdata.RemoteOperationAck
+	(*ThingInfor)(nil),         // 17: servdata.ThingInfor
+	(*ThingInforBatch)(nil),    // 18: servdata.ThingInforBatch
+	(*ThingInfoBatchAck)(nil),  // 19: servdata.ThingInfoBatchAck
+	(*EnergyInfor)(nil),        // 20: servdata.EnergyInfor
+	(*BodyInfor)(nil),          // 21: servdata.BodyInfor
+	(*TyreInfor)(nil),          // 22: servdata.TyreInfor
+	(*EngineInfor)(nil),        // 23: servdata.EngineInfor
+	(*GnssInfor)(nil),          // 24: servdata.GnssInfor
+	(*ErrorReply)(nil),         // 25: servdata.ErrorReply
+}
+var file_servdata_proto_depIdxs = []int32{
+	20, // 0: servdata.ThingInfor.energy:type_name -> servdata.EnergyInfor
+	21, // 1: servdata.ThingInfor.body:type_name -> servdata.BodyInfor
+	22, // 2: servdata.ThingInfor.tyres:type_name -> servdata.TyreInfor
+	23, // 3: servdata.ThingInfor.engine:type_name -> servdata.EngineInfor
+	24, // 4: servdata.ThingInfor.gnss:type_name -> servdata.GnssInfor
+	17, // 5: servdata.ThingInforBatch.samples:type_name -> servdata.ThingInfor
+	6,  // [6:6] is the sub-list for method output_type
+	6,  // [6:6] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_servdata_proto_init() }
+func file_servdata_proto_init() {
+	if File_servdata_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_servdata_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*RegisterAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*LoginRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*LoginChallenge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*LoginResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*LoginFailure); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*LoginSuccess); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*ReLoginReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*ReadConfigReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*ReadConfigAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*SetConfigReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*SetConfigAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*HeartbeatReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*RemoteOperationReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*DispatcherAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*RemoteOperationEnd); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*RemoteOperationAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[17].Exporter = func(v any, i int) any {
+			switch v := v.(*ThingInfor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[18].Exporter = func(v any, i int) any {
+			switch v := v.(*ThingInforBatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[19].Exporter = func(v any, i int) any {
+			switch v := v.(*ThingInfoBatchAck); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[20].Exporter = func(v any, i int) any {
+			switch v := v.(*EnergyInfor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[21].Exporter = func(v any, i int) any {
+			switch v := v.(*BodyInfor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[22].Exporter = func(v any, i int) any {
+			switch v := v.(*TyreInfor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[23].Exporter = func(v any, i int) any {
+			switch v := v.(*EngineInfor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[24].Exporter = func(v any, i int) any {
+			switch v := v.(*GnssInfor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_servdata_proto_msgTypes[25].Exporter = func(v any, i int) any {
+			switch v := v.(*ErrorReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_servdata_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   26,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_servdata_proto_goTypes,
+		DependencyIndexes: file_servdata_proto_depIdxs,
+		MessageInfos:      file_servdata_proto_msgTypes,
+	}.Build()
+	File_servdata_proto = out.File
+	file_servdata_proto_rawDesc = nil
+	file_servdata_proto_goTypes = nil
+	file_servdata_proto_depIdxs = nil
+}
@@ -0,0 +1,81 @@
+package protocol
+
+const (
+	ReadConfigReqAid = 0x5
+	ReadConfigReqMid = 0x1
+
+	ReadConfigAckAid = 0x5
+	ReadConfigAckMid = 0x2
+
+	ReadConfigMessageFlag = 0x0
+
+	SetConfigReqAid = 0x7
+	SetConfigReqMid = 0x1
+
+	SetConfigAckAid = 0x7
+	SetConfigAckMid = 0x2
+
+	SetConfigMessageFlag = 0x0
+
+	MsgReadConfigReq = 0x0501
+	MsgReadConfigAck = 0x0502
+	MsgSetConfigReq  = 0x0701
+	MsgSetConfigAck  = 0x0702
+)
+
+//Config index
+const (
+	ThingSN  = "thingsn"
+	Version  = "version"
+	WorkAddr = "workaddr"
+	WorkPort = "workport"
+)
+
+type ReadConfigReqServData struct {
+	IndexList []string `json:"indexlist"`
+}
+
+type ReadConfigAckServData struct {
+	WorkConfigList []string `json:"workconfiglist"`
+}
+
+type SetConfigReqServData struct {
+	IndexList      []string `json:"indexlist"`
+	WorkConfigList []string `json:"workconfiglist"`
+}
+
+type SetConfigAckServData struct {
+	IndexList      []string `json:"indexlist"`
+	WorkConfigList []string `json:"workconfiglist"`
+}
+
+func init() {
+	register(MessageType{
+		Name:       "ReadConfigReq",
+		Aid:        ReadConfigReqAid,
+		Mid:        ReadConfigReqMid,
+		Flag:       ReadConfigMessageFlag,
+		NewPayload: func() interface{} { return &ReadConfigReqServData{} },
+	})
+	register(MessageType{
+		Name:       "ReadConfigAck",
+		Aid:        ReadConfigAckAid,
+		Mid:        ReadConfigAckMid,
+		Flag:       ReadConfigMessageFlag,
+		NewPayload: func() interface{} { return &ReadConfigAckServData{} },
+	})
+	register(MessageType{
+		Name:       "SetConfigReq",
+		Aid:        SetConfigReqAid,
+		Mid:        SetConfigReqMid,
+		Flag:       SetConfigMessageFlag,
+		NewPayload: func() interface{} { return &SetConfigReqServData{} },
+	})
+	register(MessageType{
+		Name:       "SetConfigAck",
+		Aid:        SetConfigAckAid,
+		Mid:        SetConfigAckMid,
+		Flag:       SetConfigMessageFlag,
+		NewPayload: func() interface{} { return &SetConfigAckServData{} },
+	})
+}
@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/golang/protobuf/proto"
+)
+
+//Service data encoding is chosen per message by the thing, bit 0x80 of MessageHeader.MessageFlag
+const (
+	EncodingJSON = iota
+	EncodingProtobuf
+)
+
+const (
+	MessageFlagProtobuf = 0x80
+)
+
+var (
+	ErrUnknownEncoding = errors.New("Unknown service data encoding!")
+)
+
+func EncodingOf(messageFlag uint8) int {
+	if messageFlag&MessageFlagProtobuf != 0 {
+		return EncodingProtobuf
+	}
+
+	return EncodingJSON
+}
+
+func FlagWithEncoding(messageFlag uint8, encoding int) uint8 {
+	if encoding == EncodingProtobuf {
+		return messageFlag | MessageFlagProtobuf
+	}
+
+	return messageFlag &^ MessageFlagProtobuf
+}
+
+func EncodingName(encoding int) string {
+	switch encoding {
+	case EncodingJSON:
+		return "json"
+	case EncodingProtobuf:
+		return "protobuf"
+	}
+
+	return "unknown"
+}
+
+func ParseEncoding(name string) (int, error) {
+	switch name {
+	case "json", "":
+		return EncodingJSON, nil
+	case "protobuf", "pb":
+		return EncodingProtobuf, nil
+	}
+
+	return EncodingJSON, ErrUnknownEncoding
+}
+
+func Marshal(encoding int, payload interface{}) ([]byte, error) {
+	switch encoding {
+	case EncodingJSON:
+		return json.Marshal(payload)
+
+	case EncodingProtobuf:
+		pb, err := toProto(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return proto.Marshal(pb)
+	}
+
+	return nil, ErrUnknownEncoding
+}
+
+func Unmarshal(encoding int, data []byte, payload interface{}) error {
+	switch encoding {
+	case EncodingJSON:
+		return json.Unmarshal(data, payload)
+
+	case EncodingProtobuf:
+		pb, err := newProto(payload)
+		if err != nil {
+			return err
+		}
+
+		err = proto.Unmarshal(data, pb)
+		if err != nil {
+			return err
+		}
+
+		return fromProto(pb, payload)
+	}
+
+	return ErrUnknownEncoding
+}
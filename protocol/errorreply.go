@@ -0,0 +1,26 @@
+package protocol
+
+const (
+	ErrorReplyAid = 0xFE
+	ErrorReplyMid = 0x1
+
+	ErrorReplyMessageFlag = 0x0
+
+	MsgErrorReply = 0xFE01
+)
+
+//Aid and Mid of the rejected message, the reason is in DispatchData.Result
+type ErrorReplyServData struct {
+	Aid uint8 `json:"aid"`
+	Mid uint8 `json:"mid"`
+}
+
+func init() {
+	register(MessageType{
+		Name:       "ErrorReply",
+		Aid:        ErrorReplyAid,
+		Mid:        ErrorReplyMid,
+		Flag:       ErrorReplyMessageFlag,
+		NewPayload: func() interface{} { return &ErrorReplyServData{} },
+	})
+}
@@ -0,0 +1,35 @@
+package protocol
+
+const (
+	HeartbeatReqAid = 0xB
+	HeartbeatReqMid = 0x1
+
+	HeartbeatAckAid = 0xB
+	HeartbeatAckMid = 0x2
+
+	HeartbeatMessageFlag = 0x0
+
+	MsgHeartbeatReq = 0x0B01
+	MsgHeartbeatAck = 0x0B02
+)
+
+type HeartbeatReqServData struct {
+	AppointMF  byte `json:"appointmf"`
+	AppointAid byte `json:"appointaid"`
+}
+
+func init() {
+	register(MessageType{
+		Name:       "HeartbeatReq",
+		Aid:        HeartbeatReqAid,
+		Mid:        HeartbeatReqMid,
+		Flag:       HeartbeatMessageFlag,
+		NewPayload: func() interface{} { return &HeartbeatReqServData{} },
+	})
+	register(MessageType{
+		Name: "HeartbeatAck",
+		Aid:  HeartbeatAckAid,
+		Mid:  HeartbeatAckMid,
+		Flag: HeartbeatMessageFlag,
+	})
+}
@@ -0,0 +1,128 @@
+package protocol
+
+const (
+	LoginRequestAid = 0x2
+	LoginRequestMid = 0x1
+
+	LoginChallengeAid = 0x2
+	LoginChallengeMid = 0x2
+
+	LoginResponseAid = 0x2
+	LoginResponseMid = 0x3
+
+	LoginFailureAid = 0x2
+	LoginFailureMid = 0x4
+
+	LoginSuccessAid = 0x2
+	LoginSuccessMid = 0x5
+
+	LoginMessageFlag = 0x0
+
+	MsgLoginRequest   = 0x0201
+	MsgLoginChallenge = 0x0202
+	MsgLoginResponse  = 0x0203
+	MsgLoginFailure   = 0x0204
+	MsgLoginSuccess   = 0x0205
+)
+
+const (
+	LoginResultCodeSuccess       = 0x00
+	LoginResultCodeSnVinErr      = 0xA8
+	LoginResultCodeBidErrOrUnreg = 0xA9
+	LoginResultCodeInterrupt     = 0xAA
+	LoginResultCodeAbstractErr   = 0xAB
+	LoginResultCodeAesOutOfDate  = 0xAC
+
+	KeyTypePreAesKey     = 0
+	KeyTypeCurrentAesKey = 1
+
+	FailureCodeKeyErr       = 0
+	FailureCodeKeyOutOfDate = 1
+	FailureCodeSystemErr    = 2
+)
+
+type LoginReqServData struct {
+	KeyType     byte   `json:"keytype"`
+	ThingSN     string `json:"thingsn"`
+	ThingId     string `json:"thingid"`
+	ThingRandom string `json:"thingrandom"`
+}
+
+type LoginChallengeServData struct {
+	ThingRandomMd5 string `json:"thingrandommd5"`
+	PlatRandom     string `json:"platrandom"`
+}
+
+type LoginResponseServData struct {
+	SerialUP  string `json:"serialup"`
+	AccessKey string `json:"accesskey"`
+}
+
+type LoginFailureServData struct {
+	FailureCode byte `json:"failurecode"`
+}
+
+type LoginSuccessServData struct {
+	AesRandom     string `json:"aesrandom"`
+	InitSerial    byte   `json:"initserial"`
+	TimeStamp     int64  `json:"timestamp"`
+	WorkWindow    int64  `json:"workwindow"`
+	LinkHeartbeat int64  `json:"linkheartbeat"`
+}
+
+func LoginResultName(result byte) string {
+	switch result {
+	case LoginResultCodeSuccess:
+		return "success"
+	case LoginResultCodeSnVinErr:
+		return "sn_vin_error"
+	case LoginResultCodeBidErrOrUnreg:
+		return "bid_error_or_unregistered"
+	case LoginResultCodeInterrupt:
+		return "interrupt"
+	case LoginResultCodeAbstractErr:
+		return "abstract_error"
+	case LoginResultCodeAesOutOfDate:
+		return "aes_out_of_date"
+	}
+
+	return "unknown"
+}
+
+func init() {
+	register(MessageType{
+		Name:       "LoginRequest",
+		Aid:        LoginRequestAid,
+		Mid:        LoginRequestMid,
+		Flag:       LoginMessageFlag,
+		NewPayload: func() interface{} { return &LoginReqServData{} },
+	})
+	register(MessageType{
+		Name:       "LoginChallenge",
+		Aid:        LoginChallengeAid,
+		Mid:        LoginChallengeMid,
+		Flag:       LoginMessageFlag,
+		NewPayload: func() interface{} { return &LoginChallengeServData{} },
+	})
+	register(MessageType{
+		Name:       "LoginResponse",
+		Aid:        LoginResponseAid,
+		Mid:        LoginResponseMid,
+		Flag:       LoginMessageFlag,
+		NewPayload: func() interface{} { return &LoginResponseServData{} },
+	})
+	register(MessageType{
+		Name:       "LoginFailure",
+		Aid:        LoginFailureAid,
+		Mid:        LoginFailureMid,
+		Flag:       LoginMessageFlag,
+		NewPayload: func() interface{} { return &LoginFailureServData{} },
+	})
+	register(MessageType{
+		Name:       "LoginSuccess",
+		Aid:        LoginSuccessAid,
+		Mid:        LoginSuccessMid,
+		Flag:       LoginMessageFlag,
+		NewPayload: func() interface{} { return &LoginSuccessServData{} },
+	})
+}
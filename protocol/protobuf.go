@@ -0,0 +1,428 @@
+package protocol
+
+import (
+	"errors"
+	"github.com/golang/protobuf/proto"
+	"github.com/harveywangdao/road/protobuf/servdata"
+)
+
+var (
+	ErrNoProto = errors.New("Payload has no protobuf definition!")
+)
+
+func newProto(payload interface{}) (proto.Message, error) {
+	switch payload.(type) {
+	case *RegisterReqData:
+		return &servdata.RegisterReq{}, nil
+	case *RegisterAckMsg:
+		return &servdata.RegisterAck{}, nil
+	case *LoginReqServData:
+		return &servdata.LoginRequest{}, nil
+	case *LoginChallengeServData:
+		return &servdata.LoginChallenge{}, nil
+	case *LoginResponseServData:
+		return &servdata.LoginResponse{}, nil
+	case *LoginFailureServData:
+		return &servdata.LoginFailure{}, nil
+	case *LoginSuccessServData:
+		return &servdata.LoginSuccess{}, nil
+	case *ReLoginReqServData:
+		return &servdata.ReLoginReq{}, nil
+	case *ReadConfigReqServData:
+		return &servdata.ReadConfigReq{}, nil
+	case *ReadConfigAckServData:
+		return &servdata.ReadConfigAck{}, nil
+	case *SetConfigReqServData:
+		return &servdata.SetConfigReq{}, nil
+	case *SetConfigAckServData:
+		return &servdata.SetConfigAck{}, nil
+	case *HeartbeatReqServData:
+		return &servdata.HeartbeatReq{}, nil
+	case *RemoteOperationReqServData:
+		return &servdata.RemoteOperationReq{}, nil
+	case *DispatcherAckMessageServData:
+		return &servdata.DispatcherAck{}, nil
+	case *RemoteOperationEndServData:
+		return &servdata.RemoteOperationEnd{}, nil
+	case *RemoteOperationAckServData:
+		return &servdata.RemoteOperationAck{}, nil
+	case *ThingInfor:
+		return &servdata.ThingInfor{}, nil
+	case *ThingInforBatch:
+		return &servdata.ThingInforBatch{}, nil
+	case *ThingInfoBatchAckServData:
+		return &servdata.ThingInfoBatchAck{}, nil
+	case *ErrorReplyServData:
+		return &servdata.ErrorReply{}, nil
+	}
+
+	return nil, ErrNoProto
+}
+
+func toProto(payload interface{}) (proto.Message, error) {
+	switch p := payload.(type) {
+	case *RegisterReqData:
+		return &servdata.RegisterReq{
+			Peraeskey:  p.PerAesKey,
+			Thingid:    p.ThingId,
+			Tboxsn:     p.TBoxSN,
+			Imsi:       p.IMSI,
+			Rollnumber: p.RollNumber,
+			Iccid:      p.ICCID,
+		}, nil
+
+	case *RegisterAckMsg:
+		return &servdata.RegisterAck{
+			Status:         uint32(p.Status),
+			Callbacknumber: p.CallbackNum,
+			Bid:            p.Bid,
+		}, nil
+
+	case *LoginReqServData:
+		return &servdata.LoginRequest{
+			Keytype:     uint32(p.KeyType),
+			Thingsn:     p.ThingSN,
+			Thingid:     p.ThingId,
+			Thingrandom: p.ThingRandom,
+		}, nil
+
+	case *LoginChallengeServData:
+		return &servdata.LoginChallenge{
+			Thingrandommd5: p.ThingRandomMd5,
+			Platrandom:     p.PlatRandom,
+		}, nil
+
+	case *LoginResponseServData:
+		return &servdata.LoginResponse{
+			Serialup:  p.SerialUP,
+			Accesskey: p.AccessKey,
+		}, nil
+
+	case *LoginFailureServData:
+		return &servdata.LoginFailure{
+			Failurecode: uint32(p.FailureCode),
+		}, nil
+
+	case *LoginSuccessServData:
+		return &servdata.LoginSuccess{
+			Aesrandom:     p.AesRandom,
+			Initserial:    uint32(p.InitSerial),
+			Timestamp:     p.TimeStamp,
+			Workwindow:    p.WorkWindow,
+			Linkheartbeat: p.LinkHeartbeat,
+		}, nil
+
+	case *ReLoginReqServData:
+		return &servdata.ReLoginReq{
+			Newtime: uint32(p.NewTime),
+		}, nil
+
+	case *ReadConfigReqServData:
+		return &servdata.ReadConfigReq{
+			Indexlist: p.IndexList,
+		}, nil
+
+	case *ReadConfigAckServData:
+		return &servdata.ReadConfigAck{
+			Workconfiglist: p.WorkConfigList,
+		}, nil
+
+	case *SetConfigReqServData:
+		return &servdata.SetConfigReq{
+			Indexlist:      p.IndexList,
+			Workconfiglist: p.WorkConfigList,
+		}, nil
+
+	case *SetConfigAckServData:
+		return &servdata.SetConfigAck{
+			Indexlist:      p.IndexList,
+			Workconfiglist: p.WorkConfigList,
+		}, nil
+
+	case *HeartbeatReqServData:
+		return &servdata.HeartbeatReq{
+			Appointmf:  uint32(p.AppointMF),
+			Appointaid: uint32(p.AppointAid),
+		}, nil
+
+	case *RemoteOperationReqServData:
+		return &servdata.RemoteOperationReq{
+			Operation:          uint32(p.Operation),
+			Operationparameter: p.OperationParameter,
+		}, nil
+
+	case *DispatcherAckMessageServData:
+		return &servdata.DispatcherAck{
+			Operation: uint32(p.Operation),
+		}, nil
+
+	case *RemoteOperationEndServData:
+		return &servdata.RemoteOperationEnd{
+			Operation: uint32(p.Operation),
+			Parameter: p.Parameter,
+		}, nil
+
+	case *RemoteOperationAckServData:
+		return &servdata.RemoteOperationAck{
+			Operation: uint32(p.Operation),
+			Status:    uint32(p.Status),
+			Parameter: p.Parameter,
+		}, nil
+
+	case *ThingInfor:
+		return thingInforToProto(p), nil
+
+	case *ThingInforBatch:
+		m := &servdata.ThingInforBatch{}
+		for i := range p.Samples {
+			m.Samples = append(m.Samples, thingInforToProto(&p.Samples[i]))
+		}
+		return m, nil
+
+	case *ThingInfoBatchAckServData:
+		return &servdata.ThingInfoBatchAck{
+			Lasttime: p.LastTime,
+			Count:    uint32(p.Count),
+		}, nil
+
+	case *ErrorReplyServData:
+		return &servdata.ErrorReply{
+			Aid: uint32(p.Aid),
+			Mid: uint32(p.Mid),
+		}, nil
+	}
+
+	return nil, ErrNoProto
+}
+
+func fromProto(pb proto.Message, payload interface{}) error {
+	switch p := payload.(type) {
+	case *RegisterReqData:
+		m := pb.(*servdata.RegisterReq)
+		p.PerAesKey = m.Peraeskey
+		p.ThingId = m.Thingid
+		p.TBoxSN = m.Tboxsn
+		p.IMSI = m.Imsi
+		p.RollNumber = m.Rollnumber
+		p.ICCID = m.Iccid
+
+	case *RegisterAckMsg:
+		m := pb.(*servdata.RegisterAck)
+		p.Status = byte(m.Status)
+		p.CallbackNum = m.Callbacknumber
+		p.Bid = m.Bid
+
+	case *LoginReqServData:
+		m := pb.(*servdata.LoginRequest)
+		p.KeyType = byte(m.Keytype)
+		p.ThingSN = m.Thingsn
+		p.ThingId = m.Thingid
+		p.ThingRandom = m.Thingrandom
+
+	case *LoginChallengeServData:
+		m := pb.(*servdata.LoginChallenge)
+		p.ThingRandomMd5 = m.Thingrandommd5
+		p.PlatRandom = m.Platrandom
+
+	case *LoginResponseServData:
+		m := pb.(*servdata.LoginResponse)
+		p.SerialUP = m.Serialup
+		p.AccessKey = m.Accesskey
+
+	case *LoginFailureServData:
+		m := pb.(*servdata.LoginFailure)
+		p.FailureCode = byte(m.Failurecode)
+
+	case *LoginSuccessServData:
+		m := pb.(*servdata.LoginSuccess)
+		p.AesRandom = m.Aesrandom
+		p.InitSerial = byte(m.Initserial)
+		p.TimeStamp = m.Timestamp
+		p.WorkWindow = m.Workwindow
+		p.LinkHeartbeat = m.Linkheartbeat
+
+	case *ReLoginReqServData:
+		m := pb.(*servdata.ReLoginReq)
+		p.NewTime = byte(m.Newtime)
+
+	case *ReadConfigReqServData:
+		m := pb.(*servdata.ReadConfigReq)
+		p.IndexList = m.Indexlist
+
+	case *ReadConfigAckServData:
+		m := pb.(*servdata.ReadConfigAck)
+		p.WorkConfigList = m.Workconfiglist
+
+	case *SetConfigReqServData:
+		m := pb.(*servdata.SetConfigReq)
+		p.IndexList = m.Indexlist
+		p.WorkConfigList = m.Workconfiglist
+
+	case *SetConfigAckServData:
+		m := pb.(*servdata.SetConfigAck)
+		p.IndexList = m.Indexlist
+		p.WorkConfigList = m.Workconfiglist
+
+	case *HeartbeatReqServData:
+		m := pb.(*servdata.HeartbeatReq)
+		p.AppointMF = byte(m.Appointmf)
+		p.AppointAid = byte(m.Appointaid)
+
+	case *RemoteOperationReqServData:
+		m := pb.(*servdata.RemoteOperationReq)
+		p.Operation = uint16(m.Operation)
+		p.OperationParameter = m.Operationparameter
+
+	case *DispatcherAckMessageServData:
+		m := pb.(*servdata.DispatcherAck)
+		p.Operation = uint16(m.Operation)
+
+	case *RemoteOperationEndServData:
+		m := pb.(*servdata.RemoteOperationEnd)
+		p.Operation = uint16(m.Operation)
+		p.Parameter = m.Parameter
+
+	case *RemoteOperationAckServData:
+		m := pb.(*servdata.RemoteOperationAck)
+		p.Operation = uint16(m.Operation)
+		p.Status = byte(m.Status)
+		p.Parameter = m.Parameter
+
+	case *ThingInfor:
+		thingInforFromProto(pb.(*servdata.ThingInfor), p)
+
+	case *ThingInforBatch:
+		m := pb.(*servdata.ThingInforBatch)
+		p.Samples = make([]ThingInfor, len(m.Samples))
+		for i := range m.Samples {
+			thingInforFromProto(m.Samples[i], &p.Samples[i])
+		}
+
+	case *ThingInfoBatchAckServData:
+		m := pb.(*servdata.ThingInfoBatchAck)
+		p.LastTime = m.Lasttime
+		p.Count = uint16(m.Count)
+
+	case *ErrorReplyServData:
+		m := pb.(*servdata.ErrorReply)
+		p.Aid = uint8(m.Aid)
+		p.Mid = uint8(m.Mid)
+
+	default:
+		return ErrNoProto
+	}
+
+	return nil
+}
+
+func thingInforToProto(p *ThingInfor) *servdata.ThingInfor {
+	m := &servdata.ThingInfor{
+		Version:          p.Version,
+		Time:             p.Time,
+		Islocation:       uint32(p.IsLocation),
+		Latitude:         p.Latitude,
+		Longitude:        p.Longitude,
+		Heading:          uint32(p.Heading),
+		Speed:            uint32(p.Speed),
+		Telemetryversion: uint32(p.TelemetryVersion),
+		Dtcs:             p.Dtcs,
+	}
+
+	if p.Energy != nil {
+		m.Energy = &servdata.EnergyInfor{
+			Fuellevel:      uint32(p.Energy.FuelLevel),
+			Batterysoc:     uint32(p.Energy.BatterySoc),
+			Odometer:       p.Energy.Odometer,
+			Batteryvoltage: uint32(p.Energy.BatteryVoltage),
+		}
+	}
+
+	if p.Body != nil {
+		m.Body = &servdata.BodyInfor{
+			Doors:   uint32(p.Body.Doors),
+			Windows: uint32(p.Body.Windows),
+			Locks:   uint32(p.Body.Locks),
+		}
+	}
+
+	for _, tyre := range p.Tyres {
+		m.Tyres = append(m.Tyres, &servdata.TyreInfor{
+			Position:    uint32(tyre.Position),
+			Pressure:    uint32(tyre.Pressure),
+			Temperature: int32(tyre.Temperature),
+		})
+	}
+
+	if p.Engine != nil {
+		m.Engine = &servdata.EngineInfor{
+			Status:             uint32(p.Engine.Status),
+			Rpm:                uint32(p.Engine.Rpm),
+			Coolanttemperature: int32(p.Engine.CoolantTemperature),
+		}
+	}
+
+	if p.Gnss != nil {
+		m.Gnss = &servdata.GnssInfor{
+			Fix:        uint32(p.Gnss.Fix),
+			Satellites: uint32(p.Gnss.Satellites),
+			Hdop:       uint32(p.Gnss.Hdop),
+			Altitude:   p.Gnss.Altitude,
+		}
+	}
+
+	return m
+}
+
+func thingInforFromProto(m *servdata.ThingInfor, p *ThingInfor) {
+	p.Version = m.Version
+	p.Time = m.Time
+	p.IsLocation = uint8(m.Islocation)
+	p.Latitude = m.Latitude
+	p.Longitude = m.Longitude
+	p.Heading = uint16(m.Heading)
+	p.Speed = uint16(m.Speed)
+	p.TelemetryVersion = uint8(m.Telemetryversion)
+	p.Dtcs = m.Dtcs
+
+	if m.Energy != nil {
+		p.Energy = &EnergyInfor{
+			FuelLevel:      uint8(m.Energy.Fuellevel),
+			BatterySoc:     uint8(m.Energy.Batterysoc),
+			Odometer:       m.Energy.Odometer,
+			BatteryVoltage: uint16(m.Energy.Batteryvoltage),
+		}
+	}
+
+	if m.Body != nil {
+		p.Body = &BodyInfor{
+			Doors:   uint8(m.Body.Doors),
+			Windows: uint8(m.Body.Windows),
+			Locks:   uint8(m.Body.Locks),
+		}
+	}
+
+	for _, tyre := range m.Tyres {
+		p.Tyres = append(p.Tyres, TyreInfor{
+			Position:    uint8(tyre.Position),
+			Pressure:    uint16(tyre.Pressure),
+			Temperature: int16(tyre.Temperature),
+		})
+	}
+
+	if m.Engine != nil {
+		p.Engine = &EngineInfor{
+			Status:             uint8(m.Engine.Status),
+			Rpm:                uint16(m.Engine.Rpm),
+			CoolantTemperature: int16(m.Engine.Coolanttemperature),
+		}
+	}
+
+	if m.Gnss != nil {
+		p.Gnss = &GnssInfor{
+			Fix:        uint8(m.Gnss.Fix),
+			Satellites: uint8(m.Gnss.Satellites),
+			Hdop:       uint16(m.Gnss.Hdop),
+			Altitude:   m.Gnss.Altitude,
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+)
+
+//ServiceVersion is sent in MessageHeader.ServiceVersion, things built before versioning send 0x0
+const (
+	ServiceVersion    = 0x1
+	MinServiceVersion = 0x0
+)
+
+var (
+	ErrUnknownMessage = errors.New("Unknown Aid/Mid!")
+	ErrNoPayload      = errors.New("Message has no payload!")
+)
+
+type MessageType struct {
+	ID         int
+	Name       string
+	Aid        uint8
+	Mid        uint8
+	Flag       uint8
+	NewPayload func() interface{} //nil when the service data is not json
+}
+
+var (
+	catalogue = make(map[int]*MessageType)
+)
+
+func MsgID(aid uint8, mid uint8) int {
+	return int(aid)<<8 | int(mid)
+}
+
+func IsSupportedVersion(version uint8) bool {
+	return version <= ServiceVersion
+}
+
+func register(mt MessageType) {
+	mt.ID = MsgID(mt.Aid, mt.Mid)
+
+	if _, ok := catalogue[mt.ID]; ok {
+		panic(fmt.Sprintf("protocol: message 0x%04X registered twice", mt.ID))
+	}
+
+	catalogue[mt.ID] = &mt
+}
+
+func Lookup(aid uint8, mid uint8) (*MessageType, bool) {
+	mt, ok := catalogue[MsgID(aid, mid)]
+	return mt, ok
+}
+
+func MessageName(aid uint8, mid uint8) string {
+	if mt, ok := Lookup(aid, mid); ok {
+		return mt.Name
+	}
+
+	return fmt.Sprintf("Unknown(0x%02X,0x%02X)", aid, mid)
+}
+
+func NewPayload(aid uint8, mid uint8) (interface{}, error) {
+	mt, ok := Lookup(aid, mid)
+	if !ok {
+		return nil, ErrUnknownMessage
+	}
+
+	if mt.NewPayload == nil {
+		return nil, ErrNoPayload
+	}
+
+	return mt.NewPayload(), nil
+}
+
+func Encode(encoding int, payload interface{}) ([]byte, error) {
+	return Marshal(encoding, payload)
+}
+
+func Decode(encoding int, aid uint8, mid uint8, data []byte) (interface{}, error) {
+	payload, err := NewPayload(aid, mid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = Unmarshal(encoding, data, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
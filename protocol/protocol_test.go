@@ -0,0 +1,218 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func scaledSample(time uint32) ThingInfor {
+	return ThingInfor{
+		Version:          1,
+		Time:             time,
+		IsLocation:       1,
+		Latitude:         EncodeLatitude(31.230416),
+		Longitude:        EncodeLongitude(121.473701),
+		Heading:          27350, //273.5 degree
+		Speed:            1205,  //120.5 km/h
+		TelemetryVersion: TelemetryVersion1,
+		Energy: &EnergyInfor{
+			FuelLevel:      63,
+			BatterySoc:     88,
+			Odometer:       1234567,
+			BatteryVoltage: 1262,
+		},
+		Body: &BodyInfor{
+			Doors:   BodyFrontLeft | BodyTrunk,
+			Windows: BodyRearRight,
+			Locks:   BodyFrontLeft | BodyFrontRight | BodyRearLeft | BodyRearRight,
+		},
+		Tyres: []TyreInfor{
+			{Position: TyreFrontLeft, Pressure: 240, Temperature: 31},
+			{Position: TyreSpare, Pressure: 420, Temperature: -12},
+		},
+		Engine: &EngineInfor{
+			Status:             EngineRunning,
+			Rpm:                2150,
+			CoolantTemperature: -5,
+		},
+		Gnss: &GnssInfor{
+			Fix:        Gnss3DFix,
+			Satellites: 11,
+			Hdop:       9,
+			Altitude:   -152,
+		},
+		Dtcs: []string{"P0301", "U0100"},
+	}
+}
+
+var payloadTests = []struct {
+	aid     uint8
+	mid     uint8
+	payload interface{}
+}{
+	{RegisterReqAid, RegisterReqMid, &RegisterReqData{
+		PerAesKey:  "0123456789abcdef",
+		ThingId:    "WDDUX52684DFR4582",
+		TBoxSN:     "TB0001",
+		IMSI:       "460001234567890",
+		RollNumber: "R01",
+		ICCID:      "89860012345678901234",
+	}},
+	{RegisterAckAid, RegisterAckMid, &RegisterAckMsg{Status: 1, CallbackNum: "10086", Bid: 0x12345678}},
+	{LoginRequestAid, LoginRequestMid, &LoginReqServData{
+		KeyType:     1,
+		ThingSN:     "TB0001",
+		ThingId:     "WDDUX52684DFR4582",
+		ThingRandom: "a1b2c3",
+	}},
+	{LoginChallengeAid, LoginChallengeMid, &LoginChallengeServData{ThingRandomMd5: "d41d8cd98f00b204", PlatRandom: "e9800998ecf8427e"}},
+	{LoginResponseAid, LoginResponseMid, &LoginResponseServData{SerialUP: "0001", AccessKey: "key"}},
+	{LoginFailureAid, LoginFailureMid, &LoginFailureServData{FailureCode: LoginResultCodeBidErrOrUnreg}},
+	{LoginSuccessAid, LoginSuccessMid, &LoginSuccessServData{
+		AesRandom:     "0011223344556677",
+		InitSerial:    7,
+		TimeStamp:     1500000000,
+		WorkWindow:    300,
+		LinkHeartbeat: 60,
+	}},
+	{ReLoginReqAid, ReLoginReqMid, &ReLoginReqServData{NewTime: 3}},
+	{ReadConfigReqAid, ReadConfigReqMid, &ReadConfigReqServData{IndexList: []string{"1", "2"}}},
+	{ReadConfigAckAid, ReadConfigAckMid, &ReadConfigAckServData{WorkConfigList: []string{"a", "b"}}},
+	{SetConfigReqAid, SetConfigReqMid, &SetConfigReqServData{IndexList: []string{"1"}, WorkConfigList: []string{"a"}}},
+	{SetConfigAckAid, SetConfigAckMid, &SetConfigAckServData{IndexList: []string{"1"}, WorkConfigList: []string{"a"}}},
+	{HeartbeatReqAid, HeartbeatReqMid, &HeartbeatReqServData{AppointMF: 1, AppointAid: 0xF5}},
+	{RemoteOperationRequestAid, RemoteOperationRequestMid, &RemoteOperationReqServData{Operation: EngineLock, OperationParameter: -1}},
+	{DispatcherAckMessageAid, DispatcherAckMessageMid, &DispatcherAckMessageServData{Operation: EngineUnlock}},
+	{RemoteOperationEndAid, RemoteOperationEndMid, &RemoteOperationEndServData{Operation: EngineLock, Parameter: 42}},
+	{RemoteOperationAckAid, RemoteOperationAckMid, &RemoteOperationAckServData{Operation: EngineLock, Status: 1, Parameter: 42}},
+	{ThingInfoUploadAid, ThingInfoUploadMid, &ThingInfor{Version: 1, Time: 1500000000, IsLocation: 1, Latitude: 31, Longitude: 121, Heading: 90, Speed: 60}},
+	{ThingInfoUploadAid, ThingInfoUploadMid, func() *ThingInfor { info := scaledSample(1500000000); return &info }()},
+	{ThingInfoBatchUploadAid, ThingInfoBatchUploadMid, &ThingInforBatch{Samples: []ThingInfor{scaledSample(1500000000), scaledSample(1500000010)}}},
+	{ThingInfoBatchUploadAckAid, ThingInfoBatchUploadAckMid, &ThingInfoBatchAckServData{LastTime: 1500000010, Count: 2}},
+	{ErrorReplyAid, ErrorReplyMid, &ErrorReplyServData{Aid: ThingInfoUploadAid, Mid: ThingInfoUploadMid}},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, encoding := range []int{EncodingJSON, EncodingProtobuf} {
+		for _, tt := range payloadTests {
+			name := EncodingName(encoding) + "/" + MessageName(tt.aid, tt.mid)
+
+			data, err := Encode(encoding, tt.payload)
+			if err != nil {
+				t.Errorf("%s: Encode: %v", name, err)
+				continue
+			}
+
+			payload, err := Decode(encoding, tt.aid, tt.mid, data)
+			if err != nil {
+				t.Errorf("%s: Decode: %v", name, err)
+				continue
+			}
+
+			if !reflect.DeepEqual(payload, tt.payload) {
+				t.Errorf("%s: got %+v, want %+v", name, payload, tt.payload)
+			}
+		}
+	}
+}
+
+//Every message with service data must be in payloadTests
+func TestRoundTripCoversCatalogue(t *testing.T) {
+	tested := make(map[int]bool)
+	for _, tt := range payloadTests {
+		tested[MsgID(tt.aid, tt.mid)] = true
+	}
+
+	for id, mt := range catalogue {
+		if mt.NewPayload != nil && !tested[id] {
+			t.Errorf("%s (0x%04X) has no round-trip test", mt.Name, id)
+		}
+	}
+}
+
+func TestScaledFields(t *testing.T) {
+	info := scaledSample(0)
+
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"latitude", info.LatitudeDegree(), 31.230416},
+		{"longitude", info.LongitudeDegree(), 121.473701},
+		{"heading", info.HeadingDegree(), 273.5},
+		{"speed", info.SpeedKmh(), 120.5},
+	}
+
+	for _, tt := range tests {
+		if diff := tt.got - tt.want; diff > 1e-6 || diff < -1e-6 {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	legacy := ThingInfor{Latitude: 31, Longitude: 121, Heading: 90, Speed: 60}
+	if legacy.LatitudeDegree() != 31 || legacy.LongitudeDegree() != 121 || legacy.HeadingDegree() != 90 || legacy.SpeedKmh() != 60 {
+		t.Errorf("legacy sample is scaled: %+v", legacy)
+	}
+}
+
+func TestFlagWithEncoding(t *testing.T) {
+	tests := []struct {
+		flag     uint8
+		encoding int
+		want     uint8
+	}{
+		{0x01, EncodingJSON, 0x01},
+		{0x01, EncodingProtobuf, 0x81},
+		{0x81, EncodingJSON, 0x01},
+		{0x81, EncodingProtobuf, 0x81},
+		{0x00, EncodingProtobuf, MessageFlagProtobuf},
+	}
+
+	for _, tt := range tests {
+		got := FlagWithEncoding(tt.flag, tt.encoding)
+		if got != tt.want {
+			t.Errorf("FlagWithEncoding(0x%02X, %s) = 0x%02X, want 0x%02X", tt.flag, EncodingName(tt.encoding), got, tt.want)
+		}
+
+		if EncodingOf(got) != tt.encoding {
+			t.Errorf("EncodingOf(0x%02X) = %s, want %s", got, EncodingName(EncodingOf(got)), EncodingName(tt.encoding))
+		}
+	}
+}
+
+func TestIsSupportedVersion(t *testing.T) {
+	tests := []struct {
+		version uint8
+		want    bool
+	}{
+		{MinServiceVersion, true},
+		{ServiceVersion, true},
+		{ServiceVersion + 1, false},
+		{0xFF, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSupportedVersion(tt.version); got != tt.want {
+			t.Errorf("IsSupportedVersion(%d) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestMsgID(t *testing.T) {
+	tests := []struct {
+		aid, mid uint8
+		want     int
+	}{
+		{LoginRequestAid, LoginRequestMid, 0x0201},
+		{ThingInfoUploadAid, ThingInfoUploadMid, MsgThingInfoUpload},
+		{ThingInfoBatchUploadAckAid, ThingInfoBatchUploadAckMid, MsgThingInfoBatchUploadAck},
+		{0xFF, 0xFF, 0xFFFF},
+		{0x00, 0x00, 0x0000},
+	}
+
+	for _, tt := range tests {
+		if got := MsgID(tt.aid, tt.mid); got != tt.want {
+			t.Errorf("MsgID(0x%02X, 0x%02X) = 0x%04X, want 0x%04X", tt.aid, tt.mid, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package protocol
+
+const (
+	RegisterReqAid = 0x1
+	RegisterReqMid = 0x1
+
+	RegisterAckAid = 0x1
+	RegisterAckMid = 0x2
+
+	RegisterMessageFlag = 0x0
+
+	MsgRegisterReq = 0x0101
+	MsgRegisterAck = 0x0102
+)
+
+const (
+	RegisterSuccess byte = 0x78
+	RegisterFailure byte = 0xA8
+	AlreadyRegister byte = 0x79
+)
+
+type RegisterReqData struct {
+	PerAesKey  string `json:"peraeskey"`
+	ThingId    string `json:"thingid"`
+	TBoxSN     string `json:"tboxsn"`
+	IMSI       string `json:"imsi"`
+	RollNumber string `json:"rollnumber"`
+	ICCID      string `json:"iccid"`
+}
+
+type RegisterAckMsg struct {
+	Status      byte   `json:"status"`
+	CallbackNum string `json:"callbacknumber"`
+	Bid         uint32 `json:"bid"`
+}
+
+func init() {
+	register(MessageType{
+		Name:       "RegisterReq",
+		Aid:        RegisterReqAid,
+		Mid:        RegisterReqMid,
+		Flag:       RegisterMessageFlag,
+		NewPayload: func() interface{} { return &RegisterReqData{} },
+	})
+	register(MessageType{
+		Name:       "RegisterAck",
+		Aid:        RegisterAckAid,
+		Mid:        RegisterAckMid,
+		Flag:       RegisterMessageFlag,
+		NewPayload: func() interface{} { return &RegisterAckMsg{} },
+	})
+}
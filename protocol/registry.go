@@ -0,0 +1,150 @@
+package protocol
+
+import (
+	"fmt"
+)
+
+//MsgUnknown is the event of messages no app registered
+const MsgUnknown = 0x0000
+
+//AppEvent is a message id handled by an app, H is the handler type of the side, gateway or thing
+type AppEvent[H any] struct {
+	Event   int
+	Name    string
+	Payload func() interface{} //optional, service data is decoded into it before the handler
+	Handler H
+}
+
+//App is a vehicle service, E is the handler type for error replies to its messages, the gateway has none
+type App[H, E any] struct {
+	Name       string
+	NewState   func() interface{}
+	Events     []AppEvent[H]
+	ErrorReply E
+}
+
+//Registry maps message ids to apps, the gateway and the thing simulator each keep one
+type Registry[H, E any] struct {
+	name   string //of the side, for the panics
+	core   map[int]string
+	apps   []*App[H, E]
+	events map[int]*registeredEvent[H, E]
+}
+
+type registeredEvent[H, E any] struct {
+	app   *App[H, E]
+	event *AppEvent[H]
+}
+
+//NewRegistry takes the internal events of the side, event -> name, apps may not use them
+func NewRegistry[H, E any](name string, core map[int]string) *Registry[H, E] {
+	return &Registry[H, E]{
+		name:   name,
+		core:   core,
+		events: make(map[int]*registeredEvent[H, E]),
+	}
+}
+
+//Register is called from init, a duplicate name or event is a programming error
+func (r *Registry[H, E]) Register(app App[H, E]) {
+	for _, a := range r.apps {
+		if a.Name == app.Name {
+			panic(r.name + ": RegisterApp called twice for app " + app.Name)
+		}
+	}
+
+	for i := range app.Events {
+		event := app.Events[i].Event
+		if _, ok := r.core[event]; ok {
+			panic(fmt.Sprintf("%s: app %s uses core event 0x%04X", r.name, app.Name, event))
+		}
+
+		if e, ok := r.events[event]; ok {
+			panic(fmt.Sprintf("%s: event 0x%04X registered by app %s and %s", r.name, event, e.app.Name, app.Name))
+		}
+	}
+
+	a := &app
+	r.apps = append(r.apps, a)
+
+	for i := range a.Events {
+		r.events[a.Events[i].Event] = &registeredEvent[H, E]{
+			app:   a,
+			event: &a.Events[i],
+		}
+	}
+}
+
+//NewStates returns a new state of every app that has one, by app name
+func (r *Registry[H, E]) NewStates() map[string]interface{} {
+	states := make(map[string]interface{}, len(r.apps))
+
+	for _, app := range r.apps {
+		if app.NewState != nil {
+			states[app.Name] = app.NewState()
+		}
+	}
+
+	return states
+}
+
+func (r *Registry[H, E]) Lookup(event int) (*App[H, E], *AppEvent[H], bool) {
+	e, ok := r.events[event]
+	if !ok {
+		return nil, nil, false
+	}
+
+	return e.app, e.event, true
+}
+
+//EventByAidMid is MsgUnknown for ids no app registered, Aid 0x00 is internal and never comes from outside
+func (r *Registry[H, E]) EventByAidMid(aid uint8, mid uint8) int {
+	event := MsgID(aid, mid)
+
+	if _, ok := r.events[event]; !ok || aid == 0 {
+		return MsgUnknown
+	}
+
+	return event
+}
+
+func (r *Registry[H, E]) EventName(event int) string {
+	if name, ok := r.core[event]; ok {
+		return name
+	}
+
+	if e, ok := r.events[event]; ok {
+		return e.event.Name
+	}
+
+	return "UnknownEventMessage"
+}
+
+//AppName is "core" for the internal events of the side
+func (r *Registry[H, E]) AppName(event int) string {
+	if _, ok := r.core[event]; ok {
+		return "core"
+	}
+
+	if e, ok := r.events[event]; ok {
+		return e.app.Name
+	}
+
+	return "unknown"
+}
+
+//DecodePayload returns nil for events without Payload
+func (r *Registry[H, E]) DecodePayload(event int, encoding int, data []byte) (interface{}, error) {
+	e, ok := r.events[event]
+	if !ok || e.event.Payload == nil {
+		return nil, nil
+	}
+
+	payload := e.event.Payload()
+	err := Unmarshal(encoding, data, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
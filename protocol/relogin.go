@@ -0,0 +1,34 @@
+package protocol
+
+const (
+	ReLoginReqAid = 0x4
+	ReLoginReqMid = 0x1
+
+	ReLoginAckAid = 0x4
+	ReLoginAckMid = 0x2
+
+	ReLoginMessageFlag = 0x0
+
+	MsgReLoginReq = 0x0401
+	MsgReLoginAck = 0x0402
+)
+
+type ReLoginReqServData struct {
+	NewTime byte `json:"newtime"` //NewTime*10 minute
+}
+
+func init() {
+	register(MessageType{
+		Name:       "ReLoginReq",
+		Aid:        ReLoginReqAid,
+		Mid:        ReLoginReqMid,
+		Flag:       ReLoginMessageFlag,
+		NewPayload: func() interface{} { return &ReLoginReqServData{} },
+	})
+	register(MessageType{
+		Name: "ReLoginAck",
+		Aid:  ReLoginAckAid,
+		Mid:  ReLoginAckMid,
+		Flag: ReLoginMessageFlag,
+	})
+}
@@ -0,0 +1,126 @@
+package protocol
+
+import (
+	"errors"
+)
+
+const (
+	RemoteOperationRequestAid = 0xF1
+	RemoteOperationRequestMid = 0x1
+
+	DispatcherAckMessageAid = 0xF1
+	DispatcherAckMessageMid = 0x2
+
+	RemoteOperationEndAid = 0xF1
+	RemoteOperationEndMid = 0x3
+
+	RemoteOperationAckAid = 0xF1
+	RemoteOperationAckMid = 0x4
+
+	ThingControlMessageFlag = 0x1
+
+	MsgRemoteOperationRequest = 0xF101
+	MsgDispatcherAckMessage   = 0xF102
+	MsgRemoteOperationEnd     = 0xF103
+	MsgRemoteOperationAck     = 0xF104
+
+	RemoteOperationSuccess = 1
+)
+
+//Operation
+const (
+	CentralLockOpen     = 0x00F1
+	CentralLockClose    = 0x00F2
+	WindowClose         = 0x00F4
+	WhistleAndFlash     = 0x00F5
+	AirConditionerOpen  = 0x00F6
+	AirConditionerClose = 0x00F7
+	EngineStart         = 0x00F8
+	EngineStop          = 0x00F9
+	SkyWindowOpen       = 0x00FA
+	SkyWindowClose      = 0x00FB
+	FrontDefrostStart   = 0x0001
+	FrontDefrostStop    = 0x0002
+	BackDefrostStart    = 0x0003
+	BackDefrostStop     = 0x0004
+	SeatheatStart       = 0x0005
+	SeatheatStop        = 0x0006
+	TwoFlashStart       = 0x0007
+	ThingDefence        = 0x0008
+	ThingUndefence      = 0x0009
+	EngineLock          = 0x000A
+	EngineUnlock        = 0x000B
+
+	UnknownRemoteOparetion = 0xFFFF
+)
+
+var (
+	ErrUnknownOperation = errors.New("Unknown remote operation!")
+)
+
+type RemoteOperationReqServData struct {
+	Operation          uint16 `json:"operation"`
+	OperationParameter int64  `json:"operationparameter"`
+}
+
+type DispatcherAckMessageServData struct {
+	Operation uint16 `json:"operation"`
+}
+
+type RemoteOperationEndServData struct {
+	Operation uint16 `json:"operation"`
+	Parameter int64  `json:"parameter"`
+}
+
+type RemoteOperationAckServData struct {
+	Operation uint16 `json:"operation"`
+	Status    byte   `json:"status"`
+	Parameter int64  `json:"parameter"`
+}
+
+//ConvertOperation converts the command from web or mq to operation code
+func ConvertOperation(op string) (uint16, error) {
+	switch op {
+	case "lock":
+		return EngineLock, nil
+	case "unlock":
+		return EngineUnlock, nil
+	case "defence":
+		return ThingDefence, nil
+	case "undefence":
+		return ThingUndefence, nil
+	}
+
+	return UnknownRemoteOparetion, ErrUnknownOperation
+}
+
+func init() {
+	register(MessageType{
+		Name:       "RemoteOperationRequest",
+		Aid:        RemoteOperationRequestAid,
+		Mid:        RemoteOperationRequestMid,
+		Flag:       ThingControlMessageFlag,
+		NewPayload: func() interface{} { return &RemoteOperationReqServData{} },
+	})
+	register(MessageType{
+		Name:       "DispatcherAckMessage",
+		Aid:        DispatcherAckMessageAid,
+		Mid:        DispatcherAckMessageMid,
+		Flag:       ThingControlMessageFlag,
+		NewPayload: func() interface{} { return &DispatcherAckMessageServData{} },
+	})
+	register(MessageType{
+		Name:       "RemoteOperationEnd",
+		Aid:        RemoteOperationEndAid,
+		Mid:        RemoteOperationEndMid,
+		Flag:       ThingControlMessageFlag,
+		NewPayload: func() interface{} { return &RemoteOperationEndServData{} },
+	})
+	register(MessageType{
+		Name:       "RemoteOperationAck",
+		Aid:        RemoteOperationAckAid,
+		Mid:        RemoteOperationAckMid,
+		Flag:       ThingControlMessageFlag,
+		NewPayload: func() interface{} { return &RemoteOperationAckServData{} },
+	})
+}
@@ -0,0 +1,223 @@
+package protocol
+
+const (
+	ThingInfoUploadAid = 0xF5
+	ThingInfoUploadMid = 0x4
+
+	ThingInfoUploadAckAid = 0xF5
+	ThingInfoUploadAckMid = 0x2
+
+	ThingInfoBatchUploadAid = 0xF5
+	ThingInfoBatchUploadMid = 0x5
+
+	ThingInfoBatchUploadAckAid = 0xF5
+	ThingInfoBatchUploadAckMid = 0x6
+
+	ThingInfoUploadMessageFlag = 0x1
+
+	MsgThingInfoUpload         = 0xF504
+	MsgThingInfoUploadAck      = 0xF502
+	MsgThingInfoBatchUpload    = 0xF505
+	MsgThingInfoBatchUploadAck = 0xF506
+)
+
+//Telemetry version, older things do not send it and only fill the seven location fields without scaling
+const (
+	TelemetryVersionLegacy = 0
+	TelemetryVersion1      = 1
+
+	TelemetryVersion = TelemetryVersion1
+)
+
+//Fixed-point scaling since TelemetryVersion1
+const (
+	CoordinateScale = 1000000 //Latitude = (degree+90)*1e6, Longitude = (degree+180)*1e6
+	HeadingScale    = 100     //0.01 degree
+	SpeedScale      = 10      //0.1 km/h
+	OdometerScale   = 10      //0.1 km
+	VoltageScale    = 100     //0.01 V
+	HdopScale       = 10      //0.1
+	AltitudeScale   = 10      //0.1 m
+)
+
+//Body bits, a set bit means open for doors and windows, locked for locks
+const (
+	BodyFrontLeft = 1 << iota
+	BodyFrontRight
+	BodyRearLeft
+	BodyRearRight
+	BodyTrunk
+	BodyHood
+)
+
+const (
+	TyreFrontLeft = iota
+	TyreFrontRight
+	TyreRearLeft
+	TyreRearRight
+	TyreSpare
+)
+
+const (
+	EngineOff = iota
+	EngineOn
+	EngineRunning
+)
+
+const (
+	GnssNoFix = iota
+	Gnss2DFix
+	Gnss3DFix
+)
+
+type ThingInfor struct {
+	Version uint32
+	Time    uint32
+
+	IsLocation uint8
+	Latitude   uint32
+	Longitude  uint32
+	Heading    uint16
+	Speed      uint16
+
+	TelemetryVersion uint8        `json:",omitempty"`
+	Energy           *EnergyInfor `json:",omitempty"`
+	Body             *BodyInfor   `json:",omitempty"`
+	Tyres            []TyreInfor  `json:",omitempty"`
+	Engine           *EngineInfor `json:",omitempty"`
+	Gnss             *GnssInfor   `json:",omitempty"`
+	Dtcs             []string     `json:",omitempty"`
+}
+
+//Samples are sent in the order they were taken, ThingInfor.Time is the sample timestamp and may step back
+//with the clock of the thing
+type ThingInforBatch struct {
+	Samples []ThingInfor
+}
+
+//Count is the number of samples of the batch the gateway published, the first ones as sent, the thing drops them.
+//LastTime is the timestamp of the last of them
+type ThingInfoBatchAckServData struct {
+	LastTime uint32
+	Count    uint16
+}
+
+type EnergyInfor struct {
+	FuelLevel      uint8  //percent
+	BatterySoc     uint8  //percent
+	Odometer       uint32 //0.1 km
+	BatteryVoltage uint16 //0.01 V
+}
+
+type BodyInfor struct {
+	Doors   uint8
+	Windows uint8
+	Locks   uint8
+}
+
+type TyreInfor struct {
+	Position    uint8
+	Pressure    uint16 //kPa
+	Temperature int16  //degree celsius
+}
+
+type EngineInfor struct {
+	Status             uint8
+	Rpm                uint16
+	CoolantTemperature int16 //degree celsius
+}
+
+type GnssInfor struct {
+	Fix        uint8
+	Satellites uint8
+	Hdop       uint16 //0.1
+	Altitude   int32  //0.1 m
+}
+
+func EncodeLatitude(degree float64) uint32 {
+	return uint32((degree + 90) * CoordinateScale)
+}
+
+func EncodeLongitude(degree float64) uint32 {
+	return uint32((degree + 180) * CoordinateScale)
+}
+
+//Legacy things send unscaled values, they are returned as they are
+func (info *ThingInfor) LatitudeDegree() float64 {
+	if info.TelemetryVersion == TelemetryVersionLegacy {
+		return float64(info.Latitude)
+	}
+
+	return float64(info.Latitude)/CoordinateScale - 90
+}
+
+func (info *ThingInfor) LongitudeDegree() float64 {
+	if info.TelemetryVersion == TelemetryVersionLegacy {
+		return float64(info.Longitude)
+	}
+
+	return float64(info.Longitude)/CoordinateScale - 180
+}
+
+func (info *ThingInfor) HeadingDegree() float64 {
+	if info.TelemetryVersion == TelemetryVersionLegacy {
+		return float64(info.Heading)
+	}
+
+	return float64(info.Heading) / HeadingScale
+}
+
+func (info *ThingInfor) SpeedKmh() float64 {
+	if info.TelemetryVersion == TelemetryVersionLegacy {
+		return float64(info.Speed)
+	}
+
+	return float64(info.Speed) / SpeedScale
+}
+
+func TyrePositionName(position uint8) string {
+	switch position {
+	case TyreFrontLeft:
+		return "fl"
+	case TyreFrontRight:
+		return "fr"
+	case TyreRearLeft:
+		return "rl"
+	case TyreRearRight:
+		return "rr"
+	case TyreSpare:
+		return "spare"
+	}
+
+	return "unknown"
+}
+
+func init() {
+	register(MessageType{
+		Name:       "ThingInfoUpload",
+		Aid:        ThingInfoUploadAid,
+		Mid:        ThingInfoUploadMid,
+		Flag:       ThingInfoUploadMessageFlag,
+		NewPayload: func() interface{} { return &ThingInfor{} },
+	})
+	register(MessageType{
+		Name: "ThingInfoUploadAck",
+		Aid:  ThingInfoUploadAckAid,
+		Mid:  ThingInfoUploadAckMid,
+		Flag: ThingInfoUploadMessageFlag,
+	})
+	register(MessageType{
+		Name:       "ThingInfoBatchUpload",
+		Aid:        ThingInfoBatchUploadAid,
+		Mid:        ThingInfoBatchUploadMid,
+		Flag:       ThingInfoUploadMessageFlag,
+		NewPayload: func() interface{} { return &ThingInforBatch{} },
+	})
+	register(MessageType{
+		Name:       "ThingInfoBatchUploadAck",
+		Aid:        ThingInfoBatchUploadAckAid,
+		Mid:        ThingInfoBatchUploadAckMid,
+		Flag:       ThingInfoUploadMessageFlag,
+		NewPayload: func() interface{} { return &ThingInfoBatchAckServData{} },
+	})
+}
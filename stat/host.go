@@ -0,0 +1,129 @@
+package stat
+
+import (
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"io/ioutil"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	ProcMounts    = "/proc/mounts"
+	ProcOSRelease = "/proc/sys/kernel/osrelease"
+	EtcOSRelease  = "/etc/os-release"
+)
+
+//Total, Used and Free are in KB
+type DiskStat struct {
+	Label string `json:"label"`
+	Mount string `json:"mount"`
+	Total uint64 `json:"total"`
+	Used  uint64 `json:"used"`
+	Free  uint64 `json:"free"`
+}
+
+type HostInfo struct {
+	OSType    string
+	OSRelease string
+	Release   string
+	OSBit     string
+}
+
+//GetDiskStat reports every mounted block device once
+func GetDiskStat() ([]DiskStat, error) {
+	stats := []DiskStat{}
+	seen := make(map[string]bool)
+
+	err := readLines(ProcMounts, func(fields []string) {
+		if len(fields) < 2 || !strings.HasPrefix(fields[0], "/dev/") || seen[fields[0]] {
+			return
+		}
+
+		var fs syscall.Statfs_t
+		err := syscall.Statfs(fields[1], &fs)
+		if err != nil {
+			logger.Warn(fields[1], err)
+			return
+		}
+
+		seen[fields[0]] = true
+
+		bsize := uint64(fs.Bsize)
+		total := fs.Blocks * bsize / 1024
+		free := fs.Bavail * bsize / 1024
+
+		stats = append(stats, DiskStat{
+			Label: fields[0],
+			Mount: fields[1],
+			Total: total,
+			Used:  total - fs.Bfree*bsize/1024,
+			Free:  free,
+		})
+	})
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func GetHostInfo() (HostInfo, error) {
+	host := HostInfo{
+		OSType: runtime.GOOS,
+		OSBit:  strconv.Itoa(strconv.IntSize) + "bit",
+	}
+
+	release, err := ioutil.ReadFile(ProcOSRelease)
+	if err != nil {
+		logger.Error(err)
+		return host, err
+	}
+	host.Release = strings.TrimSpace(string(release))
+
+	//Not every image has it, the kernel release is enough then
+	osRelease, err := ioutil.ReadFile(EtcOSRelease)
+	if err == nil {
+		for _, line := range strings.Split(string(osRelease), "\n") {
+			if strings.HasPrefix(line, "PRETTY_NAME=") {
+				host.OSRelease = strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), `"`)
+				break
+			}
+		}
+	}
+
+	return host, nil
+}
+
+//GetDockerVersion is empty when docker is not installed
+func GetDockerVersion() (string, error) {
+	out, err := exec.Command("docker", "version", "--format", "{{.Server.Version}}").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+//GetLocalIP returns the first non-loopback IPv4 address
+func GetLocalIP() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		logger.Error(err)
+		return "", err
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String(), nil
+		}
+	}
+
+	return "", errors.New("No IPv4 address!")
+}
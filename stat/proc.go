@@ -0,0 +1,249 @@
+package stat
+
+import (
+	"bufio"
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProcStat      = "/proc/stat"
+	ProcCPUInfo   = "/proc/cpuinfo"
+	ProcMemInfo   = "/proc/meminfo"
+	ProcNetDev    = "/proc/net/dev"
+	ProcDiskStats = "/proc/diskstats"
+)
+
+//CPUStat is the aggregate cpu line of /proc/stat in jiffies
+type CPUStat struct {
+	User      uint64
+	Nice      uint64
+	System    uint64
+	Idle      uint64
+	IOWait    uint64
+	IRQ       uint64
+	SoftIRQS  uint64
+	Steal     uint64
+	Guest     uint64
+	GuestNice uint64
+}
+
+type CPUInfo struct {
+	ModelName string
+	CoreCount int
+}
+
+type MemStat struct {
+	MemTotal uint64  `json:"mem_total"` //KB
+	MemUsed  uint64  `json:"mem_used"`
+	MemFree  uint64  `json:"mem_free"`
+	MemRate  float64 `json:"mem_rate"` //percent
+}
+
+type NetStat struct {
+	Device  string `json:"device"`
+	RXBytes uint64 `json:"rx_bytes"`
+	TXBytes uint64 `json:"tx_bytes"`
+}
+
+//IOTicks is the time in milliseconds the disk was busy, TimeStamp is when it was read
+type IOStat struct {
+	Name      string `json:"name"`
+	IOTicks   uint64 `json:"io_ticks"`
+	TimeStamp uint64 `json:"timestamp"`
+}
+
+//readLines calls fn with the fields of every line of a proc file
+func readLines(path string, fn func(fields []string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fn(strings.Fields(scanner.Text()))
+	}
+
+	return scanner.Err()
+}
+
+func parseUint(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
+
+func (st *CPUStat) Total() uint64 {
+	return st.User + st.Nice + st.System + st.Idle + st.IOWait + st.IRQ + st.SoftIRQS + st.Steal + st.Guest + st.GuestNice
+}
+
+func GetCPUStat() (CPUStat, error) {
+	st := CPUStat{}
+	found := false
+
+	err := readLines(ProcStat, func(fields []string) {
+		if found || len(fields) < 5 || fields[0] != "cpu" {
+			return
+		}
+
+		values := make([]uint64, 10)
+		for i := 1; i < len(fields) && i <= len(values); i++ {
+			values[i-1] = parseUint(fields[i])
+		}
+
+		st = CPUStat{
+			User:      values[0],
+			Nice:      values[1],
+			System:    values[2],
+			Idle:      values[3],
+			IOWait:    values[4],
+			IRQ:       values[5],
+			SoftIRQS:  values[6],
+			Steal:     values[7],
+			Guest:     values[8],
+			GuestNice: values[9],
+		}
+		found = true
+	})
+	if err != nil {
+		logger.Error(err)
+		return st, err
+	}
+
+	if !found {
+		return st, errors.New("No cpu line in " + ProcStat)
+	}
+
+	return st, nil
+}
+
+func GetCPUInfo() (CPUInfo, error) {
+	info := CPUInfo{}
+
+	err := readLines(ProcCPUInfo, func(fields []string) {
+		if len(fields) < 2 {
+			return
+		}
+
+		switch {
+		case fields[0] == "processor":
+			info.CoreCount++
+		case fields[0] == "model" && fields[1] == "name" && info.ModelName == "":
+			if len(fields) > 3 {
+				info.ModelName = strings.Join(fields[3:], " ")
+			}
+		}
+	})
+	if err != nil {
+		logger.Error(err)
+		return info, err
+	}
+
+	return info, nil
+}
+
+func GetMemStat() (MemStat, error) {
+	mem := MemStat{}
+	var available uint64
+	hasAvailable := false
+
+	err := readLines(ProcMemInfo, func(fields []string) {
+		if len(fields) < 2 {
+			return
+		}
+
+		switch fields[0] {
+		case "MemTotal:":
+			mem.MemTotal = parseUint(fields[1])
+		case "MemFree:":
+			mem.MemFree = parseUint(fields[1])
+		case "MemAvailable:":
+			available = parseUint(fields[1])
+			hasAvailable = true
+		}
+	})
+	if err != nil {
+		logger.Error(err)
+		return mem, err
+	}
+
+	if mem.MemTotal == 0 {
+		return mem, errors.New("No MemTotal in " + ProcMemInfo)
+	}
+
+	if !hasAvailable {
+		available = mem.MemFree
+	}
+
+	mem.MemUsed = mem.MemTotal - available
+	mem.MemRate = float64(mem.MemUsed) / float64(mem.MemTotal) * 100
+
+	return mem, nil
+}
+
+//GetNetStat skips the loopback interface, the order is the one of /proc/net/dev
+func GetNetStat() ([]NetStat, error) {
+	stats := []NetStat{}
+
+	err := readLines(ProcNetDev, func(fields []string) {
+		//"eth0: 123 ..." or "eth0:123 ..." when the counter is wide, the two header lines have no colon field
+		line := strings.Join(fields, " ")
+		i := strings.Index(line, ":")
+		if i <= 0 || strings.Contains(line, "|") {
+			return
+		}
+
+		device := strings.TrimSpace(line[:i])
+		fields = strings.Fields(line[i+1:])
+		if device == "lo" || len(fields) < 9 {
+			return
+		}
+
+		stats = append(stats, NetStat{
+			Device:  device,
+			RXBytes: parseUint(fields[0]),
+			TXBytes: parseUint(fields[8]),
+		})
+	})
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+//GetIOStat skips loop and ram devices
+func GetIOStat() ([]IOStat, error) {
+	stats := []IOStat{}
+	now := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+
+	err := readLines(ProcDiskStats, func(fields []string) {
+		if len(fields) < 13 {
+			return
+		}
+
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			return
+		}
+
+		stats = append(stats, IOStat{
+			Name:      name,
+			IOTicks:   parseUint(fields[12]),
+			TimeStamp: now,
+		})
+	})
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return stats, nil
+}
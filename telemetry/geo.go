@@ -0,0 +1,36 @@
+package telemetry
+
+import (
+	"github.com/harveywangdao/road/protocol"
+	"math"
+)
+
+const (
+	EarthRadius = 6371000 //meter
+)
+
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+//Distance is the haversine distance in meters
+func Distance(a, b Point) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * EarthRadius * math.Asin(math.Sqrt(h))
+}
+
+//Position is false for legacy samples and samples without a fix
+func Position(info *protocol.ThingInfor) (Point, bool) {
+	if info.TelemetryVersion == protocol.TelemetryVersionLegacy || info.IsLocation == 0 {
+		return Point{}, false
+	}
+
+	return Point{Latitude: info.LatitudeDegree(), Longitude: info.LongitudeDegree()}, true
+}
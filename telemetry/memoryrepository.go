@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"sort"
+	"sync"
+)
+
+//MemoryRepository is a Repository for tests and local runs, it keeps every sample in memory
+type MemoryRepository struct {
+	things map[string]map[uint32]Document
+	lock   sync.Mutex
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		things: make(map[string]map[uint32]Document),
+	}
+}
+
+func (repo *MemoryRepository) Save(records ...*Record) error {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	for _, record := range records {
+		samples, ok := repo.things[record.ThingId]
+		if !ok {
+			samples = make(map[uint32]Document)
+			repo.things[record.ThingId] = samples
+		}
+
+		samples[record.Sample.Time] = record.Document()
+	}
+
+	return nil
+}
+
+func (repo *MemoryRepository) Latest(thingid string) (Document, error) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	var latest Document
+	for t, doc := range repo.things[thingid] {
+		if latest == nil || t > sampleTime(latest) {
+			latest = doc
+		}
+	}
+
+	if latest == nil {
+		return nil, ErrNotFound
+	}
+
+	return latest, nil
+}
+
+func (repo *MemoryRepository) Range(thingid string, from, to uint32, limit int) ([]Document, error) {
+	repo.lock.Lock()
+	defer repo.lock.Unlock()
+
+	var docs []Document
+	for t, doc := range repo.things[thingid] {
+		if t >= from && t <= to {
+			docs = append(docs, doc)
+		}
+	}
+
+	sort.Slice(docs, func(i, j int) bool { return sampleTime(docs[i]) < sampleTime(docs[j]) })
+
+	if limit > 0 && len(docs) > limit {
+		docs = docs[:limit]
+	}
+
+	return docs, nil
+}
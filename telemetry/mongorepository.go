@@ -0,0 +1,136 @@
+package telemetry
+
+import (
+	"github.com/harveywangdao/road/database/mongo"
+	"github.com/harveywangdao/road/log/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	MongoCollectionName = "ThingInforData"
+)
+
+type MongoRepository struct {
+	db *mongo.DB
+}
+
+func NewMongoRepository(db *mongo.DB) *MongoRepository {
+	return &MongoRepository{db: db}
+}
+
+func (repo *MongoRepository) collection() *driver.Collection {
+	return repo.db.Collection(MongoCollectionName)
+}
+
+//EnsureIndexes creates the unique sample index, the receive time index and the retention TTL index
+func (repo *MongoRepository) EnsureIndexes() error {
+	ctx, cancel := repo.db.Context()
+	defer cancel()
+
+	_, err := repo.collection().Indexes().CreateMany(ctx, []driver.IndexModel{
+		{
+			Keys:    bson.D{{Key: "thingid", Value: 1}, {Key: "time", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "receivetime", Value: 1}},
+		},
+	})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return repo.db.EnsureRetention(MongoCollectionName, "receivedat")
+}
+
+//Save upserts all records in one unordered bulk write
+func (repo *MongoRepository) Save(records ...*Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	models := make([]driver.WriteModel, 0, len(records))
+	for _, record := range records {
+		models = append(models, driver.NewReplaceOneModel().
+			SetFilter(bson.M{"thingid": record.ThingId, "time": record.Sample.Time}).
+			SetReplacement(bson.M(record.Document())).
+			SetUpsert(true))
+	}
+
+	ctx, cancel := repo.db.Context()
+	defer cancel()
+
+	_, err := repo.collection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (repo *MongoRepository) Latest(thingid string) (Document, error) {
+	ctx, cancel := repo.db.Context()
+	defer cancel()
+
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "time", Value: -1}}).
+		SetProjection(bson.M{"_id": 0})
+
+	var doc bson.M
+	err := repo.collection().FindOne(ctx, bson.M{"thingid": thingid}, opts).Decode(&doc)
+	if err == driver.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return Document(doc), nil
+}
+
+func (repo *MongoRepository) Range(thingid string, from, to uint32, limit int) ([]Document, error) {
+	ctx, cancel := repo.db.Context()
+	defer cancel()
+
+	filter := bson.M{
+		"thingid": thingid,
+		"time":    bson.M{"$gte": from, "$lte": to},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "time", Value: 1}}).
+		SetProjection(bson.M{"_id": 0}).
+		SetLimit(int64(limit))
+
+	cursor, err := repo.collection().Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []Document
+	for cursor.Next(ctx) {
+		var doc bson.M
+		err = cursor.Decode(&doc)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		docs = append(docs, Document(doc))
+	}
+
+	err = cursor.Err()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return docs, nil
+}
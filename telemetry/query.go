@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+)
+
+const (
+	HistoryMaxSamples    = 10000
+	HistoryMaxRawSamples = 100000
+)
+
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+//Feature is a GeoJSON LineString of [longitude, latitude], properties.times holds the sample time of each point
+type Feature struct {
+	Type       string                 `json:"type"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+//History returns samples in [from, to] oldest first, with interval > 0 only the first sample of each interval is kept
+func History(repo Repository, thingid string, from, to, interval uint32) ([]Document, error) {
+	samples, err := repo.Range(thingid, from, to, HistoryMaxRawSamples)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	var docs []Document
+	var lastBucket int64 = -1
+	for _, doc := range samples {
+		if len(docs) >= HistoryMaxSamples {
+			break
+		}
+
+		if interval > 0 {
+			bucket := int64(sampleTime(doc) / interval)
+			if bucket == lastBucket {
+				continue
+			}
+			lastBucket = bucket
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+//Track skips samples without a position, legacy samples have no position in degrees
+func Track(repo Repository, thingid string, from, to, interval uint32) (*Feature, error) {
+	docs, err := History(repo, thingid, from, to, interval)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	coordinates := make([][]float64, 0, len(docs))
+	times := make([]uint32, 0, len(docs))
+
+	for _, doc := range docs {
+		latitude, ok1 := doc["latitude_deg"].(float64)
+		longitude, ok2 := doc["longitude_deg"].(float64)
+		if !ok1 || !ok2 {
+			continue
+		}
+
+		coordinates = append(coordinates, []float64{longitude, latitude})
+		times = append(times, sampleTime(doc))
+	}
+
+	feature := &Feature{
+		Type: "Feature",
+		Geometry: Geometry{
+			Type:        "LineString",
+			Coordinates: coordinates,
+		},
+		Properties: map[string]interface{}{
+			"thingid": thingid,
+			"from":    from,
+			"to":      to,
+			"times":   times,
+		},
+	}
+
+	return feature, nil
+}
@@ -0,0 +1,34 @@
+package telemetry
+
+import (
+	"errors"
+)
+
+var (
+	ErrNotFound = errors.New("Telemetry not found!")
+)
+
+//Repository stores samples identified by thingid and sample time, saving a sample twice keeps one copy
+type Repository interface {
+	Save(records ...*Record) error
+	Latest(thingid string) (Document, error)
+	//Range returns at most limit samples in [from, to] oldest first
+	Range(thingid string, from, to uint32, limit int) ([]Document, error)
+}
+
+func sampleTime(doc Document) uint32 {
+	switch t := doc["time"].(type) {
+	case uint32:
+		return t
+	case int:
+		return uint32(t)
+	case int32:
+		return uint32(t)
+	case int64:
+		return uint32(t)
+	case float64:
+		return uint32(t)
+	}
+
+	return 0
+}
@@ -0,0 +1,135 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/protocol"
+	"time"
+)
+
+const (
+	DefaultTopic = "ThingTelemetry"
+)
+
+//Record is one sample on the telemetry topic, the message key is ThingId
+type Record struct {
+	ThingId     string
+	Bid         uint32
+	ReceiveTime uint32 //set by the gateway, Sample.Time is set by the thing
+	Sample      protocol.ThingInfor
+}
+
+func (record *Record) Marshal() ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+//UnmarshalRecord opens the envelope of data, bare records are still accepted
+func UnmarshalRecord(data []byte) (*Record, error) {
+	env, err := msgqueue.UnmarshalEnvelope(data)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return EnvelopeRecord(env)
+}
+
+func EnvelopeRecord(env *msgqueue.Envelope) (*Record, error) {
+	if env.Type != "" && env.Type != msgqueue.MessageTypeTelemetry {
+		logger.Error("Envelope type =", env.Type)
+		return nil, errors.New("Not a telemetry record!")
+	}
+
+	record := &Record{}
+	err := env.Decode(record)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return record, nil
+}
+
+//Document is what the repository stores for the record
+func (record *Record) Document() Document {
+	doc := Fields(&record.Sample)
+	doc["thingid"] = record.ThingId
+	doc["bid"] = record.Bid
+	doc["receivetime"] = record.ReceiveTime
+	doc["receivedat"] = time.Unix(int64(record.ReceiveTime), 0) //date for the retention TTL index
+
+	return doc
+}
+
+//Document is one stored sample, the keys are the ones of Fields plus thingid, bid and receivetime
+type Document map[string]interface{}
+
+//Fields flattens ThingInfor into one key per field, the unit is part of the key
+func Fields(info *protocol.ThingInfor) Document {
+	fields := Document{
+		"version":          info.Version,
+		"time":             info.Time,
+		"telemetryversion": info.TelemetryVersion,
+		"islocation":       info.IsLocation,
+	}
+
+	//Legacy things send unscaled location, keep it raw
+	if info.TelemetryVersion == protocol.TelemetryVersionLegacy {
+		fields["latitude_raw"] = info.Latitude
+		fields["longitude_raw"] = info.Longitude
+		fields["heading_raw"] = info.Heading
+		fields["speed_raw"] = info.Speed
+		return fields
+	}
+
+	fields["latitude_deg"] = info.LatitudeDegree()
+	fields["longitude_deg"] = info.LongitudeDegree()
+	fields["heading_deg"] = info.HeadingDegree()
+	fields["speed_kmh"] = info.SpeedKmh()
+
+	if info.Energy != nil {
+		fields["fuel_pct"] = info.Energy.FuelLevel
+		fields["battery_soc_pct"] = info.Energy.BatterySoc
+		fields["odometer_km"] = float64(info.Energy.Odometer) / protocol.OdometerScale
+		fields["battery_voltage_v"] = float64(info.Energy.BatteryVoltage) / protocol.VoltageScale
+	}
+
+	if info.Body != nil {
+		fields["doors_open"] = info.Body.Doors
+		fields["windows_open"] = info.Body.Windows
+		fields["locks_locked"] = info.Body.Locks
+	}
+
+	for _, tyre := range info.Tyres {
+		name := protocol.TyrePositionName(tyre.Position)
+		fields["tyre_"+name+"_pressure_kpa"] = tyre.Pressure
+		fields["tyre_"+name+"_temperature_c"] = tyre.Temperature
+	}
+
+	if info.Engine != nil {
+		fields["engine_status"] = info.Engine.Status
+		fields["engine_rpm"] = info.Engine.Rpm
+		fields["coolant_temperature_c"] = info.Engine.CoolantTemperature
+	}
+
+	if info.Gnss != nil {
+		fields["gnss_fix"] = info.Gnss.Fix
+		fields["gnss_satellites"] = info.Gnss.Satellites
+		fields["gnss_hdop"] = float64(info.Gnss.Hdop) / protocol.HdopScale
+		fields["altitude_m"] = float64(info.Gnss.Altitude) / protocol.AltitudeScale
+	}
+
+	if len(info.Dtcs) > 0 {
+		fields["dtcs"] = info.Dtcs
+	}
+
+	return fields
+}
@@ -0,0 +1,46 @@
+package telemetry
+
+import (
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+)
+
+//Writer consumes the telemetry topic and saves every sample before its offset is committed
+type Writer struct {
+	MQAddr             string
+	Topic              string
+	ConsumerRoutineNum int
+	Repo               Repository
+	Broker             msgqueue.Broker //optional, kafka at MQAddr by default
+}
+
+//handle returns the Save error so the sample is retried and then dead lettered, never dropped
+func (w *Writer) handle(msg *msgqueue.Message) error {
+	record, err := UnmarshalRecord(msg.Value)
+	if err != nil {
+		return msgqueue.Permanent(err)
+	}
+
+	err = w.Repo.Save(record)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	logger.Debug("Save telemetry sample, thingid =", record.ThingId, "time =", record.Sample.Time)
+
+	return nil
+}
+
+func (w *Writer) Run() {
+	mqs, err := msgqueue.NewMqService(w.MQAddr, w.Topic, "", w.ConsumerRoutineNum, 0, nil, nil)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	mqs.ConsumerName = "MongoWriter"
+	mqs.Broker = w.Broker
+	mqs.Handler = w.handle
+
+	mqs.Start()
+}
@@ -0,0 +1,135 @@
+//kafkatool works on the topics of the gateway and its services:
+//
+//	kafkatool send -thingid WDDUX52684DFR4582 -command lock -wait 10s
+//	kafkatool config -jobs configjobs.json
+//	kafkatool tail -topic ThingLifecycle -from oldest
+//	kafkatool replay -topic WebToGateway -partition 0 -offset 42 -count 1
+//	kafkatool offsets dump -group Alert -topic ThingTelemetry > offsets.json
+//	kafkatool offsets restore -file offsets.json
+//
+//Every command takes -brokers and -config, a JSON toolConfig. Flags win over the config file
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue/kafka"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	defaultBrokers    = "localhost:9092"
+	defaultConfigFile = "kafkatool.json"
+)
+
+//toolConfig is the optional config file, PackMode and PackKey must match the services, see kafka.Packing
+type toolConfig struct {
+	Brokers  []string `json:"brokers"`
+	PackMode int      `json:"packmode"`
+	PackKey  string   `json:"packkey"`
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"send", "send a gateway command to a thing", runSend},
+	{"config", "send the command jobs of a file", runConfig},
+	{"tail", "print the messages of a topic with their envelopes", runTail},
+	{"replay", "publish the messages of a partition again from an offset", runReplay},
+	{"offsets", "dump or restore the offsets of a consumer group", runOffsets},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: kafkatool <command> [flags]")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(os.Stderr, "Run kafkatool <command> -h for the flags of a command.")
+}
+
+//flagSet holds the flags every command has
+type flagSet struct {
+	*flag.FlagSet
+	brokers    string
+	configFile string
+	packing    kafka.Packing //set by load
+}
+
+func newFlagSet(name string) *flagSet {
+	fs := &flagSet{FlagSet: flag.NewFlagSet(name, flag.ExitOnError)}
+	fs.StringVar(&fs.brokers, "brokers", "", "comma separated kafka brokers, "+defaultBrokers+" by default")
+	fs.StringVar(&fs.configFile, "config", defaultConfigFile, "optional JSON config file")
+
+	return fs
+}
+
+//load reads the config file and sets fs.packing, it returns the brokers
+func (fs *flagSet) load() ([]string, error) {
+	config := &toolConfig{}
+
+	data, err := ioutil.ReadFile(fs.configFile)
+	if err == nil {
+		err = json.Unmarshal(data, config)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) || fs.configFile != defaultConfigFile {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if fs.brokers != "" {
+		config.Brokers = strings.Split(fs.brokers, ",")
+	}
+	if len(config.Brokers) == 0 {
+		config.Brokers = []string{defaultBrokers}
+	}
+
+	fs.packing = kafka.Packing{Mode: config.PackMode}
+	if config.PackKey != "" {
+		fs.packing.Key = []byte(config.PackKey)
+	}
+
+	err = fs.packing.Check()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return config.Brokers, nil
+}
+
+func main() {
+	logger.SetHandlers(logger.Console)
+	logger.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+	logger.SetLevel(logger.WARN)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			err := c.run(os.Args[2:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "kafkatool:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	usage()
+	os.Exit(2)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/harveywangdao/road/log/logger"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+//groupOffsets is the file of offsets dump and offsets restore, topic -> partition -> next offset to read
+type groupOffsets struct {
+	Group   string                     `json:"group"`
+	Offsets map[string]map[int32]int64 `json:"offsets"`
+}
+
+func runOffsets(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "dump":
+			return runOffsetsDump(args[1:])
+		case "restore":
+			return runOffsetsRestore(args[1:])
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "Usage: kafkatool offsets dump|restore [flags]")
+	return errors.New("dump or restore expected")
+}
+
+//runOffsetsDump prints the committed offsets of a group, partitions without a commit are left out
+func runOffsetsDump(args []string) error {
+	fs := newFlagSet("offsets dump")
+	group := fs.String("group", "", "consumer group, see msgqueue.MqService.ConsumerName")
+	topics := fs.String("topic", "", "comma separated topics")
+	fs.Parse(args)
+
+	if *group == "" || *topics == "" {
+		fs.Usage()
+		return errors.New("-group and -topic are required")
+	}
+
+	brokers, err := fs.load()
+	if err != nil {
+		return err
+	}
+
+	client, err := sarama.NewClient(brokers, nil)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	defer client.Close()
+
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	topicPartitions := make(map[string][]int32)
+	for _, topic := range strings.Split(*topics, ",") {
+		partitions, err := client.Partitions(topic)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+		topicPartitions[topic] = partitions
+	}
+
+	resp, err := admin.ListConsumerGroupOffsets(*group, topicPartitions)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	dump := &groupOffsets{
+		Group:   *group,
+		Offsets: make(map[string]map[int32]int64),
+	}
+
+	for topic, partitions := range topicPartitions {
+		dump.Offsets[topic] = make(map[int32]int64)
+		for _, partition := range partitions {
+			block := resp.GetBlock(topic, partition)
+			if block == nil || block.Offset < 0 {
+				continue
+			}
+			if block.Err != sarama.ErrNoError {
+				logger.Error(topic, partition, block.Err)
+				return block.Err
+			}
+			dump.Offsets[topic][partition] = block.Offset
+		}
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
+
+//runOffsetsRestore commits the offsets of a dump, stop the consumers of the group first or they commit over it
+func runOffsetsRestore(args []string) error {
+	fs := newFlagSet("offsets restore")
+	file := fs.String("file", "", "dump of offsets dump")
+	group := fs.String("group", "", "restore into this group instead of the one of the dump")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return errors.New("-file is required")
+	}
+
+	data, err := ioutil.ReadFile(*file)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	dump := &groupOffsets{}
+	err = json.Unmarshal(data, dump)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if *group != "" {
+		dump.Group = *group
+	}
+	if dump.Group == "" {
+		return errors.New("no group in the dump, use -group")
+	}
+
+	brokers, err := fs.load()
+	if err != nil {
+		return err
+	}
+
+	client, err := sarama.NewClient(brokers, nil)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	defer client.Close()
+
+	om, err := sarama.NewOffsetManagerFromClient(dump.Group, client)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	topics := make([]string, 0, len(dump.Offsets))
+	for topic := range dump.Offsets {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	poms := make([]sarama.PartitionOffsetManager, 0)
+	for _, topic := range topics {
+		for partition, offset := range dump.Offsets[topic] {
+			pom, err := om.ManagePartition(topic, partition)
+			if err != nil {
+				logger.Error(err)
+				om.Close()
+				return err
+			}
+			poms = append(poms, pom)
+
+			//ResetOffset can also move back
+			pom.ResetOffset(offset, "")
+			fmt.Printf("%s %s/%d -> %d\n", dump.Group, topic, partition, offset)
+		}
+	}
+
+	om.Commit()
+
+	for _, pom := range poms {
+		pom.Close()
+	}
+
+	//Close flushes what Commit could not send yet
+	err = om.Close()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
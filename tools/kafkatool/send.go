@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/harveywangdao/road/iot/gateway"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/msgqueue/kafka"
+	"io/ioutil"
+	"time"
+)
+
+//sender publishes web requests and optionally waits for the answers of the gateway
+type sender struct {
+	broker    *msgqueue.KafkaBroker
+	topic     string
+	responses *tailer
+	wait      time.Duration
+}
+
+func newSender(brokers []string, packing kafka.Packing, topic string, wait time.Duration) (*sender, error) {
+	s := &sender{
+		broker: msgqueue.NewKafkaBroker(brokers[0]),
+		topic:  topic,
+		wait:   wait,
+	}
+	s.broker.Addrs = brokers
+	s.broker.Packing = packing
+
+	//Listen before sending, the answer may come quickly
+	if wait > 0 {
+		var err error
+		s.responses, err = newTailer(brokers, packing, gateway.GatewayToWebTopic, -1, sarama.OffsetNewest)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *sender) close() {
+	if s.responses != nil {
+		s.responses.close()
+	}
+	s.broker.Close()
+}
+
+//send returns the correlation id of the request
+func (s *sender) send(request *gateway.WebRequest) (string, error) {
+	env, err := msgqueue.NewEnvelope(msgqueue.MessageTypeWebCommand, request.ThingId, "", request)
+	if err != nil {
+		logger.Error(err)
+		return "", err
+	}
+
+	data, err := env.Marshal()
+	if err != nil {
+		logger.Error(err)
+		return "", err
+	}
+
+	err = s.broker.Publish(s.topic, request.ThingId, data)
+	if err != nil {
+		logger.Error(err)
+		return "", err
+	}
+
+	return env.CorrelationId, nil
+}
+
+//await prints the responses of the correlation ids until all came or the wait is over
+func (s *sender) await(correlationIds map[string]bool) error {
+	if s.responses == nil || len(correlationIds) == 0 {
+		return nil
+	}
+
+	timeout := time.After(s.wait)
+
+	for len(correlationIds) > 0 {
+		select {
+		case rec, ok := <-s.responses.records:
+			if !ok {
+				return errors.New("Response topic closed!")
+			}
+
+			if rec.env == nil || !correlationIds[rec.env.CorrelationId] {
+				continue
+			}
+			delete(correlationIds, rec.env.CorrelationId)
+
+			fmt.Println(string(rec.env.Payload))
+
+		case <-timeout:
+			return fmt.Errorf("%d responses missing after %s", len(correlationIds), s.wait)
+		}
+	}
+
+	return nil
+}
+
+func runSend(args []string) error {
+	fs := newFlagSet("send")
+	thingId := fs.String("thingid", "", "thing to send to")
+	cmd := fs.String("command", "", "lock, unlock, defence or undefence")
+	topic := fs.String("topic", gateway.WebToGatewayTopic, "request topic")
+	wait := fs.Duration("wait", 0, "wait this long for the response of the gateway")
+	fs.Parse(args)
+
+	if *thingId == "" || *cmd == "" {
+		fs.Usage()
+		return errors.New("-thingid and -command are required")
+	}
+
+	brokers, err := fs.load()
+	if err != nil {
+		return err
+	}
+
+	s, err := newSender(brokers, fs.packing, *topic, *wait)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	request := &gateway.WebRequest{
+		ThingId: *thingId,
+		Command: *cmd,
+	}
+
+	correlationId, err := s.send(request)
+	if err != nil {
+		return err
+	}
+	fmt.Println("sent", correlationId)
+
+	return s.await(map[string]bool{correlationId: true})
+}
+
+//runConfig sends the jobs of a JSON file, an array of gateway.WebRequest, every job needs a command
+func runConfig(args []string) error {
+	fs := newFlagSet("config")
+	jobsFile := fs.String("jobs", "", "JSON file of the jobs")
+	topic := fs.String("topic", gateway.WebToGatewayTopic, "request topic")
+	wait := fs.Duration("wait", 0, "wait this long for the responses of the gateway")
+	fs.Parse(args)
+
+	if *jobsFile == "" {
+		fs.Usage()
+		return errors.New("-jobs is required")
+	}
+
+	data, err := ioutil.ReadFile(*jobsFile)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	jobs := []*gateway.WebRequest{}
+	err = json.Unmarshal(data, &jobs)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	for i, job := range jobs {
+		if job.ThingId == "" || job.Command == "" {
+			return fmt.Errorf("job %d needs thingid and command", i)
+		}
+	}
+
+	brokers, err := fs.load()
+	if err != nil {
+		return err
+	}
+
+	s, err := newSender(brokers, fs.packing, *topic, *wait)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	correlationIds := make(map[string]bool)
+	for _, job := range jobs {
+		correlationId, err := s.send(job)
+		if err != nil {
+			return err
+		}
+		fmt.Println("sent", job.ThingId, job.Command, correlationId)
+
+		correlationIds[correlationId] = true
+	}
+
+	return s.await(correlationIds)
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Shopify/sarama"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/msgqueue/kafka"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//record is a consumed message, env is nil when the value is no envelope
+type record struct {
+	msg  *sarama.ConsumerMessage
+	data []byte
+	env  *msgqueue.Envelope
+	err  error
+}
+
+//tailer reads partitions without joining a consumer group, so it takes nothing from the services
+type tailer struct {
+	consumer   sarama.Consumer
+	partitions []sarama.PartitionConsumer
+	packing    kafka.Packing
+	records    chan *record
+	done       chan bool
+	wg         sync.WaitGroup
+}
+
+//newTailer reads partition, or every partition when it is negative, from offset
+func newTailer(brokers []string, packing kafka.Packing, topic string, partition int32, offset int64) (*tailer, error) {
+	consumer, err := sarama.NewConsumer(brokers, nil)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	partitionList := []int32{partition}
+	if partition < 0 {
+		partitionList, err = consumer.Partitions(topic)
+		if err != nil {
+			logger.Error(err)
+			consumer.Close()
+			return nil, err
+		}
+	}
+
+	t := &tailer{
+		consumer: consumer,
+		packing:  packing,
+		records:  make(chan *record, 128),
+		done:     make(chan bool),
+	}
+
+	for _, p := range partitionList {
+		pc, err := consumer.ConsumePartition(topic, p, offset)
+		if err != nil {
+			logger.Error("partition =", p, err)
+			t.close()
+			return nil, err
+		}
+		t.partitions = append(t.partitions, pc)
+
+		t.wg.Add(1)
+		go t.read(pc)
+	}
+
+	go func() {
+		t.wg.Wait()
+		close(t.records)
+	}()
+
+	return t, nil
+}
+
+func (t *tailer) read(pc sarama.PartitionConsumer) {
+	defer t.wg.Done()
+
+	for msg := range pc.Messages() {
+		rec := &record{msg: msg}
+
+		rec.data, rec.err = t.packing.Unpack(msg.Value)
+		if rec.err == nil {
+			env, err := msgqueue.UnmarshalEnvelope(rec.data)
+			if err == nil && env.Version > 0 {
+				rec.env = env
+			}
+		}
+
+		select {
+		case t.records <- rec:
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *tailer) close() {
+	close(t.done)
+	for _, pc := range t.partitions {
+		pc.AsyncClose()
+	}
+	t.consumer.Close()
+}
+
+//tailLine is what tail prints for every message, one JSON object per line
+type tailLine struct {
+	Topic     string             `json:"topic"`
+	Partition int32              `json:"partition"`
+	Offset    int64              `json:"offset"`
+	Key       string             `json:"key,omitempty"`
+	Time      time.Time          `json:"time"`
+	Envelope  *msgqueue.Envelope `json:"envelope,omitempty"`
+	Value     string             `json:"value,omitempty"` //when the value is no envelope
+	Error     string             `json:"error,omitempty"`
+}
+
+func printRecord(rec *record) {
+	line := &tailLine{
+		Topic:     rec.msg.Topic,
+		Partition: rec.msg.Partition,
+		Offset:    rec.msg.Offset,
+		Key:       string(rec.msg.Key),
+		Time:      rec.msg.Timestamp,
+		Envelope:  rec.env,
+	}
+
+	if rec.err != nil {
+		line.Error = rec.err.Error()
+	} else if rec.env == nil {
+		line.Value = string(rec.data)
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	fmt.Println(string(data))
+}
+
+//parseOffset takes newest, oldest or an offset
+func parseOffset(s string) (int64, error) {
+	switch s {
+	case "newest":
+		return sarama.OffsetNewest, nil
+	case "oldest":
+		return sarama.OffsetOldest, nil
+	}
+
+	offset, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("bad offset %q, want newest, oldest or a number", s)
+	}
+
+	return offset, nil
+}
+
+func runTail(args []string) error {
+	fs := newFlagSet("tail")
+	topic := fs.String("topic", "", "topic to read")
+	partition := fs.Int("partition", -1, "partition to read, all by default")
+	from := fs.String("from", "newest", "newest, oldest or an offset, an offset needs -partition")
+	fs.Parse(args)
+
+	if *topic == "" {
+		fs.Usage()
+		return errors.New("-topic is required")
+	}
+
+	offset, err := parseOffset(*from)
+	if err != nil {
+		return err
+	}
+	if offset >= 0 && *partition < 0 {
+		return errors.New("an offset needs -partition")
+	}
+
+	brokers, err := fs.load()
+	if err != nil {
+		return err
+	}
+
+	t, err := newTailer(brokers, fs.packing, *topic, int32(*partition), offset)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	for rec := range t.records {
+		printRecord(rec)
+	}
+
+	return nil
+}
+
+//runReplay publishes the messages as they are stored, key and packing included
+func runReplay(args []string) error {
+	fs := newFlagSet("replay")
+	topic := fs.String("topic", "", "topic to read")
+	partition := fs.Int("partition", -1, "partition to read")
+	offset := fs.Int64("offset", -1, "first offset to replay")
+	count := fs.Int64("count", 0, "messages to replay, up to the end of the partition by default")
+	to := fs.String("to", "", "topic to publish to, -topic by default")
+	fs.Parse(args)
+
+	if *topic == "" || *partition < 0 || *offset < 0 {
+		fs.Usage()
+		return errors.New("-topic, -partition and -offset are required")
+	}
+	if *to == "" {
+		*to = *topic
+	}
+
+	brokers, err := fs.load()
+	if err != nil {
+		return err
+	}
+
+	client, err := sarama.NewClient(brokers, nil)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	defer client.Close()
+
+	end, err := client.GetOffset(*topic, int32(*partition), sarama.OffsetNewest)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	last := end - 1
+	if *count > 0 && *offset+*count-1 < last {
+		last = *offset + *count - 1
+	}
+	if *offset > last {
+		fmt.Println("nothing to replay, end of partition is", end)
+		return nil
+	}
+
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	config.Producer.Return.Successes = true
+
+	producer, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+	defer producer.Close()
+
+	t, err := newTailer(brokers, fs.packing, *topic, int32(*partition), *offset)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	for rec := range t.records {
+		msg := &sarama.ProducerMessage{
+			Topic: *to,
+			Value: sarama.ByteEncoder(rec.msg.Value),
+		}
+		if len(rec.msg.Key) > 0 {
+			msg.Key = sarama.ByteEncoder(rec.msg.Key)
+		}
+
+		p, o, err := producer.SendMessage(msg)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+		fmt.Printf("replayed %s/%d/%d as %s/%d/%d\n", *topic, rec.msg.Partition, rec.msg.Offset, *to, p, o)
+
+		if rec.msg.Offset >= last {
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package trip
+
+import (
+	"github.com/harveywangdao/road/protocol"
+	"github.com/harveywangdao/road/telemetry"
+	"sync"
+)
+
+const (
+	TripGapTime  = 10 * 60 //seconds without samples that end a trip
+	IdleSpeed    = 1       //km/h, slower is idle
+	MinTripTime  = 60      //shorter trips are dropped
+	MaxTripSpeed = 300     //km/h, faster jumps between positions are gps noise
+)
+
+type Trip struct {
+	ThingId        string
+	StartTime      uint32
+	EndTime        uint32
+	StartLatitude  float64
+	StartLongitude float64
+	EndLatitude    float64
+	EndLongitude   float64
+	Distance       float64 //meter
+	MaxSpeed       float64 //km/h
+	AvgSpeed       float64 //km/h, distance over duration
+	IdleTime       uint32  //seconds
+}
+
+type thingState struct {
+	lastTime     uint32
+	lastPosition telemetry.Point
+	hasPosition  bool
+	lastIdle     bool
+
+	trip *Trip
+}
+
+//Segmenter cuts the samples of each thing into trips, samples must come in time order per thing
+type Segmenter struct {
+	things map[string]*thingState
+	lock   sync.Mutex
+}
+
+func NewSegmenter() *Segmenter {
+	return &Segmenter{
+		things: make(map[string]*thingState),
+	}
+}
+
+//moving is true while the engine runs, things without engine status are moving above IdleSpeed
+func moving(info *protocol.ThingInfor) bool {
+	if info.Engine != nil {
+		return info.Engine.Status == protocol.EngineRunning
+	}
+
+	return info.TelemetryVersion != protocol.TelemetryVersionLegacy && info.SpeedKmh() > IdleSpeed
+}
+
+func (seg *Segmenter) finish(state *thingState) *Trip {
+	t := state.trip
+	state.trip = nil
+
+	if t == nil || t.EndTime-t.StartTime < MinTripTime {
+		return nil
+	}
+
+	t.AvgSpeed = t.Distance / float64(t.EndTime-t.StartTime) * 3.6
+
+	return t
+}
+
+//Feed returns the trip ended by this sample, or nil
+func (seg *Segmenter) Feed(record *telemetry.Record) *Trip {
+	seg.lock.Lock()
+	defer seg.lock.Unlock()
+
+	state, ok := seg.things[record.ThingId]
+	if !ok {
+		state = &thingState{}
+		seg.things[record.ThingId] = state
+	}
+
+	sample := &record.Sample
+	if sample.Time <= state.lastTime {
+		return nil
+	}
+
+	var ended *Trip
+	if state.trip != nil && sample.Time-state.lastTime > TripGapTime {
+		ended = seg.finish(state)
+	}
+
+	position, hasPosition := telemetry.Position(sample)
+	speed := sample.SpeedKmh()
+	idle := speed <= IdleSpeed
+
+	if moving(sample) {
+		if state.trip == nil {
+			state.trip = &Trip{
+				ThingId:   record.ThingId,
+				StartTime: sample.Time,
+			}
+			if hasPosition {
+				state.trip.StartLatitude = position.Latitude
+				state.trip.StartLongitude = position.Longitude
+			}
+		} else {
+			t := state.trip
+			elapsed := sample.Time - state.lastTime
+
+			if hasPosition && state.hasPosition {
+				d := telemetry.Distance(state.lastPosition, position)
+				if d/float64(elapsed)*3.6 <= MaxTripSpeed {
+					t.Distance += d
+				}
+			}
+
+			if idle && state.lastIdle {
+				t.IdleTime += elapsed
+			}
+		}
+
+		t := state.trip
+		t.EndTime = sample.Time
+		if hasPosition {
+			t.EndLatitude = position.Latitude
+			t.EndLongitude = position.Longitude
+		}
+		if speed > t.MaxSpeed {
+			t.MaxSpeed = speed
+		}
+	} else if state.trip != nil {
+		ended = seg.finish(state)
+	}
+
+	state.lastTime = sample.Time
+	state.lastIdle = idle
+	if hasPosition {
+		state.lastPosition = position
+		state.hasPosition = true
+	}
+
+	return ended
+}
+
+//Expire ends the trips of things silent for TripGapTime, now is unix seconds
+func (seg *Segmenter) Expire(now uint32) []*Trip {
+	seg.lock.Lock()
+	defer seg.lock.Unlock()
+
+	var trips []*Trip
+	for _, state := range seg.things {
+		if state.trip != nil && now > state.lastTime && now-state.lastTime > TripGapTime {
+			if t := seg.finish(state); t != nil {
+				trips = append(trips, t)
+			}
+		}
+	}
+
+	return trips
+}
@@ -0,0 +1,99 @@
+package trip
+
+import (
+	"github.com/harveywangdao/road/database/mongo"
+	"github.com/harveywangdao/road/log/logger"
+	"github.com/harveywangdao/road/msgqueue"
+	"github.com/harveywangdao/road/telemetry"
+	"sync"
+	"time"
+)
+
+const (
+	ExpireCheckTime = time.Minute
+)
+
+//Service segments the telemetry topic into trips and daily mileage
+type Service struct {
+	MQAddr             string
+	TelemetryTopic     string
+	ConsumerRoutineNum int
+
+	db      *mongo.DB
+	seg     *Segmenter
+	pending []*Trip //ended but not stored yet
+	lock    sync.Mutex
+}
+
+//save stores the trips after the ones still pending, a trip that fails stays pending for the next call
+//because the segmenter returns every trip once
+func (s *Service) save(trips ...*Trip) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pending = append(s.pending, trips...)
+
+	for len(s.pending) > 0 {
+		t := s.pending[0]
+		logger.Info("Trip, thingid =", t.ThingId, "start =", t.StartTime, "end =", t.EndTime, "distance =", t.Distance)
+
+		err := SaveTrip(s.db, t)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+		s.pending = s.pending[1:]
+	}
+
+	return nil
+}
+
+//handle acks the record only once the trip it ended is stored, bad records go to the dead letter topic
+func (s *Service) handle(msg *msgqueue.Message) error {
+	record, err := telemetry.UnmarshalRecord(msg.Value)
+	if err != nil {
+		return msgqueue.Permanent(err)
+	}
+
+	if t := s.seg.Feed(record); t != nil {
+		return s.save(t)
+	}
+
+	return s.save()
+}
+
+func (s *Service) Run() {
+	var err error
+	s.db, err = mongo.GetDB()
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+
+	err = EnsureIndexes(s.db)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	s.seg = NewSegmenter()
+
+	mqs, err := msgqueue.NewMqService(s.MQAddr, s.TelemetryTopic, "", s.ConsumerRoutineNum, 0, nil, nil)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	mqs.ConsumerName = "Trip"
+	mqs.Handler = s.handle
+
+	go mqs.Start()
+
+	ticker := time.NewTicker(ExpireCheckTime)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err = s.save(s.seg.Expire(uint32(time.Now().Unix()))...)
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+}
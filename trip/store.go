@@ -0,0 +1,143 @@
+package trip
+
+import (
+	"github.com/harveywangdao/road/database/mongo"
+	"github.com/harveywangdao/road/log/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+const (
+	TripCollectionName  = "ThingTrips"
+	DailyCollectionName = "ThingDailyMileage"
+
+	secondsPerDay = 24 * 60 * 60
+)
+
+//Day is the UTC day of the trip start, a trip over midnight counts for the day it started
+func Day(t *Trip) string {
+	return time.Unix(int64(t.StartTime), 0).UTC().Format("2006-01-02")
+}
+
+func uniqueThingIndex(field string) driver.IndexModel {
+	return driver.IndexModel{
+		Keys:    bson.D{{Key: "thingid", Value: 1}, {Key: field, Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+}
+
+//EnsureIndexes creates the unique trip and day indexes and the retention TTL indexes
+func EnsureIndexes(db *mongo.DB) error {
+	ctx, cancel := db.Context()
+	defer cancel()
+
+	_, err := db.Collection(TripCollectionName).Indexes().CreateOne(ctx, uniqueThingIndex("starttime"))
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	_, err = db.Collection(DailyCollectionName).Indexes().CreateOne(ctx, uniqueThingIndex("day"))
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = db.EnsureRetention(TripCollectionName, "endedat")
+	if err != nil {
+		return err
+	}
+
+	return db.EnsureRetention(DailyCollectionName, "updatedat")
+}
+
+//dailyMileage is the daily aggregate as summed from the trips of the day
+type dailyMileage struct {
+	Trips    int64   `bson:"trips"`
+	Distance float64 `bson:"distance"`
+	Duration int64   `bson:"duration"`
+	IdleTime int64   `bson:"idletime"`
+}
+
+//SaveTrip stores the trip next to the raw telemetry and sums the daily aggregate again from the stored trips,
+//so a retry or a trip computed again with another end after a restart leaves the day right
+func SaveTrip(db *mongo.DB, t *Trip) error {
+	ctx, cancel := db.Context()
+	defer cancel()
+
+	doc := bson.M{
+		"thingid":        t.ThingId,
+		"starttime":      t.StartTime,
+		"endtime":        t.EndTime,
+		"startlatitude":  t.StartLatitude,
+		"startlongitude": t.StartLongitude,
+		"endlatitude":    t.EndLatitude,
+		"endlongitude":   t.EndLongitude,
+		"distance":       t.Distance,
+		"maxspeed":       t.MaxSpeed,
+		"avgspeed":       t.AvgSpeed,
+		"idletime":       t.IdleTime,
+		"endedat":        time.Unix(int64(t.EndTime), 0),
+	}
+
+	//Upsert so a trip computed twice after a restart is stored once
+	_, err := db.Collection(TripCollectionName).ReplaceOne(ctx,
+		bson.M{"thingid": t.ThingId, "starttime": t.StartTime}, doc,
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	dayStart := t.StartTime - t.StartTime%secondsPerDay
+	cursor, err := db.Collection(TripCollectionName).Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{
+			"thingid":   t.ThingId,
+			"starttime": bson.M{"$gte": dayStart, "$lt": dayStart + secondsPerDay},
+		}},
+		bson.M{"$group": bson.M{
+			"_id":      nil,
+			"trips":    bson.M{"$sum": 1},
+			"distance": bson.M{"$sum": "$distance"},
+			"duration": bson.M{"$sum": bson.M{"$subtract": bson.A{"$endtime", "$starttime"}}},
+			"idletime": bson.M{"$sum": "$idletime"},
+		}},
+	})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	var days []dailyMileage
+	err = cursor.All(ctx, &days)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	//The trip was just stored, only a trip removed by retention at the same time leaves nothing
+	if len(days) == 0 {
+		return nil
+	}
+
+	_, err = db.Collection(DailyCollectionName).UpdateOne(ctx,
+		bson.M{"thingid": t.ThingId, "day": Day(t)},
+		bson.M{
+			"$set": bson.M{
+				"trips":     days[0].Trips,
+				"distance":  days[0].Distance,
+				"duration":  days[0].Duration,
+				"idletime":  days[0].IdleTime,
+				"updatedat": time.Now(),
+			},
+		},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"github.com/harveywangdao/road/log/logger"
 	"hash/crc32"
+	"math"
 	"math/rand"
 	"time"
 )
@@ -165,3 +166,9 @@ func Substr(str string, start int, length int) string {
 
 	return string(rs[start:end])
 }
+
+//Round keeps n decimal places
+func Round(f float64, n int) float64 {
+	pow := math.Pow10(n)
+	return math.Floor(f*pow+0.5) / pow
+}